@@ -0,0 +1,77 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"whatsapp-parser/internal/config"
+	"whatsapp-parser/internal/domain"
+	"whatsapp-parser/internal/repository"
+)
+
+// apikey generates a new API key, stores its hash in the repository and
+// prints the raw key once
+func main() {
+	name := flag.String("name", "", "Human readable key name")
+	scopes := flag.String("scopes", "", "Comma separated scopes, e.g. sessions:write,messages:send,chats:read")
+	tenant := flag.String("tenant", domain.DefaultTenantID, "Tenant the key acts for")
+	sessions := flag.String("sessions", "", "Comma separated session IDs the key is bound to (empty for all)")
+	flag.Parse()
+
+	if *name == "" || *scopes == "" {
+		log.Fatal("Both -name and -scopes are required")
+	}
+	if !domain.ValidTenantID(*tenant) {
+		log.Fatalf("Invalid tenant %q", *tenant)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	repo, err := repository.NewAPIKeyRepository(cfg.StorageDir, nil)
+	if err != nil {
+		log.Fatalf("Failed to create API key repository: %v", err)
+	}
+
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		log.Fatalf("Failed to generate key: %v", err)
+	}
+	raw := hex.EncodeToString(buf)
+
+	key := &domain.APIKey{
+		ID:         uuid.New().String(),
+		Name:       *name,
+		Hash:       domain.HashAPIKey(raw),
+		TenantID:   *tenant,
+		SessionIDs: splitList(*sessions),
+		CreatedAt:  time.Now(),
+	}
+	for _, s := range splitList(*scopes) {
+		key.Scopes = append(key.Scopes, domain.Scope(s))
+	}
+
+	if err := repo.Save(key); err != nil {
+		log.Fatalf("Failed to save API key: %v", err)
+	}
+
+	fmt.Printf("API key %q (%s) created. Store it now, it is not shown again:\n%s\n", key.Name, key.ID, raw)
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -1,51 +1,195 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"syscall"
+	"time"
+	_ "time/tzdata" // Cron time zones work without a system zoneinfo database
 
 	"github.com/gorilla/mux"
+	"whatsapp-parser/internal/config"
 	httphandler "whatsapp-parser/internal/delivery/http"
 	"whatsapp-parser/internal/repository"
 	"whatsapp-parser/internal/usecase"
+	"whatsapp-parser/pkg/selenium"
 )
 
 func main() {
+	// Load configuration
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
 	// Create storage directory
-	storageDir := filepath.Join(".", "storage", "sessions")
+	storageDir := filepath.Join(cfg.StorageDir, "sessions")
 	if err := os.MkdirAll(storageDir, 0755); err != nil {
 		log.Fatalf("Failed to create storage directory: %v", err)
 	}
 
+	// Kill browsers left behind by a previous run; two instances must not share the browser data
+	if err := selenium.KillOrphans(cfg.BrowserDataDir); err != nil {
+		if errors.Is(err, selenium.ErrDataDirInUse) {
+			log.Fatalf("Failed to start: %v", err)
+		}
+		log.Printf("Warning: failed to clean up orphaned browser processes: %v", err)
+	}
+
 	// Initialize repository
 	sessionRepo, err := repository.NewSessionRepository(storageDir)
 	if err != nil {
 		log.Fatalf("Failed to create session repository: %v", err)
 	}
 
+	usageRepo, err := repository.NewUsageRepository(filepath.Join(cfg.StorageDir, "tenants"))
+	if err != nil {
+		log.Fatalf("Failed to create usage repository: %v", err)
+	}
+
+	pacingRepo, err := repository.NewPacingRepository(filepath.Join(cfg.StorageDir, "pacing"))
+	if err != nil {
+		log.Fatalf("Failed to create pacing repository: %v", err)
+	}
+
+	contactRepo, err := repository.NewContactCheckRepository(filepath.Join(cfg.StorageDir, "contacts"))
+	if err != nil {
+		log.Fatalf("Failed to create contact check repository: %v", err)
+	}
+
+	suppressionRepo, err := repository.NewSuppressionRepository(filepath.Join(cfg.StorageDir, "suppressions"))
+	if err != nil {
+		log.Fatalf("Failed to create suppression repository: %v", err)
+	}
+
+	// Idempotency records sit next to the sessions they send through
+	idempotencyRepo, err := repository.NewIdempotencyRepository(filepath.Join(cfg.StorageDir, "idempotency"))
+	if err != nil {
+		log.Fatalf("Failed to create idempotency repository: %v", err)
+	}
+
+	messageRepo, err := repository.NewMessageRepository(filepath.Join(cfg.StorageDir, "archive.db"))
+	if err != nil {
+		log.Fatalf("Failed to open message archive: %v", err)
+	}
+	defer messageRepo.Close()
+
+	mediaRepo, err := repository.NewMediaRepository(filepath.Join(cfg.StorageDir, "media"), cfg.MediaQuotaMB<<20)
+	if err != nil {
+		log.Fatalf("Failed to create media repository: %v", err)
+	}
+
+	apiKeyRepo, err := repository.NewAPIKeyRepository(cfg.StorageDir, cfg.Auth.Keys)
+	if err != nil {
+		log.Fatalf("Failed to create API key repository: %v", err)
+	}
+	if cfg.Auth.Disabled {
+		log.Println("Warning: API key authentication is disabled")
+	}
+
 	// Initialize use case
-	sessionUseCase, err := usecase.NewSessionUseCase(sessionRepo)
+	sessionUseCase, err := usecase.NewSessionUseCase(sessionRepo, usageRepo, pacingRepo, contactRepo, suppressionRepo, messageRepo, mediaRepo, cfg)
 	if err != nil {
 		log.Fatalf("Failed to create session use case: %v", err)
 	}
 
+	suppressionUseCase := usecase.NewSuppressionUseCase(suppressionRepo, sessionUseCase, cfg)
+
+	importUseCase := usecase.NewImportUseCase(cfg)
+
+	archiveUseCase := usecase.NewArchiveUseCase(messageRepo, mediaRepo, sessionUseCase, cfg)
+
+	templateRepo, err := repository.NewTemplateRepository(filepath.Join(cfg.StorageDir, "templates"))
+	if err != nil {
+		log.Fatalf("Failed to create template repository: %v", err)
+	}
+	templateUseCase := usecase.NewTemplateUseCase(templateRepo)
+
+	campaignRepo, err := repository.NewCampaignRepository(filepath.Join(cfg.StorageDir, "campaigns"))
+	if err != nil {
+		log.Fatalf("Failed to create campaign repository: %v", err)
+	}
+	sender := usecase.NewSender(sessionUseCase, cfg)
+	campaignUseCase := usecase.NewCampaignUseCase(campaignRepo, sessionRepo, templateUseCase, sender, cfg)
+
+	scheduleRepo, err := repository.NewScheduleRepository(filepath.Join(cfg.StorageDir, "scheduled"))
+	if err != nil {
+		log.Fatalf("Failed to create schedule repository: %v", err)
+	}
+	scheduleUseCase := usecase.NewScheduleUseCase(scheduleRepo, sessionRepo, sender, cfg)
+
+	// Resume campaigns interrupted by the previous shutdown
+	if err := campaignUseCase.Start(); err != nil {
+		log.Fatalf("Failed to resume campaigns: %v", err)
+	}
+	if err := scheduleUseCase.Start(); err != nil {
+		log.Fatalf("Failed to start scheduler: %v", err)
+	}
+	archiveUseCase.Start()
+
 	// Initialize HTTP handler
-	h := httphandler.NewHandler(sessionUseCase)
+	h := httphandler.NewHandler(sessionUseCase, templateUseCase, campaignUseCase, scheduleUseCase, suppressionUseCase, importUseCase, archiveUseCase, apiKeyRepo, idempotencyRepo, cfg)
 
 	// Create router
 	r := mux.NewRouter()
 	h.RegisterRoutes(r)
 
 	// Start server
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8081"
+	srv := &http.Server{
+		Addr:    ":" + cfg.Port,
+		Handler: r,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	serverErr := make(chan error, 1)
+	go func() {
+		log.Printf("Server starting on port %s...", cfg.Port)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- err
+		}
+		close(serverErr)
+	}()
+
+	select {
+	case err := <-serverErr:
+		if err != nil {
+			log.Printf("Server failed: %v", err)
+		}
+	case <-ctx.Done():
+		log.Println("Shutdown signal received")
+	}
+	stop()
+
+	// Stop accepting requests and let in-flight ones finish
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.ShutdownTimeout))
+	defer cancel()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Warning: HTTP server shutdown: %v", err)
 	}
 
-	log.Printf("Server starting on port %s...", port)
-	if err := http.ListenAndServe(":"+port, r); err != nil {
-		log.Fatalf("Server failed to start: %v", err)
+	// Stop campaigns, the scheduler and the archive sync before the browsers they send through
+	if err := campaignUseCase.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Warning: campaign shutdown: %v", err)
+	}
+	if err := scheduleUseCase.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Warning: scheduler shutdown: %v", err)
+	}
+	if err := archiveUseCase.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Warning: archive sync shutdown: %v", err)
 	}
-} 
\ No newline at end of file
+
+	// Drain remaining work, save session state and close browsers
+	if err := sessionUseCase.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Warning: session shutdown: %v", err)
+	}
+
+	log.Println("Server stopped")
+}
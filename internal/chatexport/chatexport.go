@@ -0,0 +1,276 @@
+// Package chatexport parses the text files produced by WhatsApp's "Export
+// chat" on Android and iOS, in any of the date and time formats phones use.
+package chatexport
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"whatsapp-parser/internal/domain"
+	"whatsapp-parser/pkg/phone"
+)
+
+// Options control how an export is interpreted
+type Options struct {
+	// DateOrder forces "dmy", "mdy" or "ymd"; detected from the file when empty
+	DateOrder string
+	// Location is the time zone of the exporting phone; UTC when nil
+	Location *time.Location
+	// SelfName is the exporting user's display name; their messages get FromMe
+	SelfName string
+	// DefaultRegion resolves senders saved without a name, shown as local numbers
+	DefaultRegion string
+}
+
+// maxLineLength bounds a single line of an export
+const maxLineLength = 1 << 20
+
+const (
+	datePattern = `(\d{1,4})([./-])(\d{1,2})[./-](\d{1,4})`
+	timePattern = `(\d{1,2})[:.](\d{2})(?:[:.](\d{2}))?(?:[\s\x{202f}\x{00a0}]*([AaPp])\.?\s?[Mm]\.?)?`
+)
+
+var (
+	// androidHeader matches "31.12.2020, 23:59 - Name: text"
+	androidHeader = regexp.MustCompile(`^` + datePattern + `,?\s` + timePattern + `\s[-–]\s(.*)$`)
+	// iosHeader matches "[31.12.2020, 23:59:59] Name: text"
+	iosHeader = regexp.MustCompile(`^\[` + datePattern + `,?\s` + timePattern + `\]\s(.*)$`)
+)
+
+// header is a line starting a new message
+type header struct {
+	day, sep, month, year string // Date components in file order
+	hour, minute, second  string
+	ampm                  string
+	rest                  string
+}
+
+// entry is a message whose continuation lines are still being collected
+type entry struct {
+	header header
+	lines  []string
+}
+
+// Parse reads an exported chat and returns its messages in file order
+func Parse(r io.Reader, opts Options) ([]domain.Message, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxLineLength)
+
+	var entries []*entry
+	first := true
+	for scanner.Scan() {
+		line := scanner.Text()
+		if first {
+			line = strings.TrimPrefix(line, "\ufeff")
+			first = false
+		}
+		line = strings.TrimRight(line, "\r")
+
+		if h, ok := parseHeader(line); ok {
+			entries = append(entries, &entry{header: h, lines: []string{h.rest}})
+			continue
+		}
+		// Continuation of a multi-line message; text before the first message is dropped
+		if len(entries) > 0 {
+			last := entries[len(entries)-1]
+			last.lines = append(last.lines, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidChatExport, err)
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("%w: no messages found", domain.ErrInvalidChatExport)
+	}
+
+	order := opts.DateOrder
+	if order == "" {
+		order = detectDateOrder(entries)
+	}
+	loc := opts.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	messages := make([]domain.Message, 0, len(entries))
+	occurrences := make(map[string]int)
+	for i, e := range entries {
+		ts, err := e.header.time(order, loc)
+		if err != nil {
+			return nil, fmt.Errorf("%w: message %d: %v", domain.ErrInvalidChatExport, i+1, err)
+		}
+		msg := parseMessage(ts, strings.Join(e.lines, "\n"), opts)
+
+		// IDs stay stable when the same export is imported again
+		key := fmt.Sprintf("%d|%s|%s", ts.Unix(), msg.SenderName, msg.Text+msg.Attachment)
+		occurrences[key]++
+		sum := sha1.Sum([]byte(fmt.Sprintf("%s#%d", key, occurrences[key])))
+		msg.ID = "export_" + hex.EncodeToString(sum[:10])
+
+		messages = append(messages, msg)
+	}
+	return messages, nil
+}
+
+func parseHeader(line string) (header, bool) {
+	line = strings.TrimLeft(line, "\u200e\u200f")
+	m := iosHeader.FindStringSubmatch(line)
+	if m == nil {
+		m = androidHeader.FindStringSubmatch(line)
+	}
+	if m == nil {
+		return header{}, false
+	}
+	return header{
+		day: m[1], sep: m[2], month: m[3], year: m[4],
+		hour: m[5], minute: m[6], second: m[7],
+		ampm: strings.ToLower(m[8]),
+		rest: m[9],
+	}, true
+}
+
+// detectDateOrder finds which date component is the day. A component above 12
+// settles it; otherwise dotted dates are day first (Russian, German) and
+// slashed dates with AM/PM are month first (US).
+func detectDateOrder(entries []*entry) string {
+	twelveHour := false
+	slashed := false
+	for _, e := range entries {
+		h := e.header
+		if len(h.day) == 4 {
+			return "ymd"
+		}
+		first, _ := strconv.Atoi(h.day)
+		second, _ := strconv.Atoi(h.month)
+		if first > 12 {
+			return "dmy"
+		}
+		if second > 12 {
+			return "mdy"
+		}
+		if h.ampm != "" {
+			twelveHour = true
+		}
+		if h.sep == "/" {
+			slashed = true
+		}
+	}
+	if slashed && twelveHour {
+		return "mdy"
+	}
+	return "dmy"
+}
+
+// time converts the header's date and time using the given component order
+func (h header) time(order string, loc *time.Location) (time.Time, error) {
+	a, _ := strconv.Atoi(h.day)
+	b, _ := strconv.Atoi(h.month)
+	c, _ := strconv.Atoi(h.year)
+
+	var year, month, day int
+	switch order {
+	case "dmy":
+		day, month, year = a, b, c
+	case "mdy":
+		month, day, year = a, b, c
+	case "ymd":
+		year, month, day = a, b, c
+	default:
+		return time.Time{}, fmt.Errorf("unknown date order %q", order)
+	}
+	if year < 100 {
+		year += 2000
+	}
+
+	hour, _ := strconv.Atoi(h.hour)
+	minute, _ := strconv.Atoi(h.minute)
+	second, _ := strconv.Atoi(h.second)
+	switch h.ampm {
+	case "a":
+		if hour == 12 {
+			hour = 0
+		}
+	case "p":
+		if hour < 12 {
+			hour += 12
+		}
+	}
+
+	if month < 1 || month > 12 || day < 1 || day > 31 || hour > 23 || minute > 59 || second > 59 {
+		return time.Time{}, fmt.Errorf("invalid date %s%s%s%s%s %s:%s", h.day, h.sep, h.month, h.sep, h.year, h.hour, h.minute)
+	}
+	return time.Date(year, time.Month(month), day, hour, minute, second, 0, loc), nil
+}
+
+// parseMessage splits the author from the text and recognizes system
+// messages, attachments and placeholders
+func parseMessage(ts time.Time, body string, opts Options) domain.Message {
+	msg := domain.Message{Type: "chat", Timestamp: ts}
+
+	body = strings.TrimLeft(body, "\u200e")
+	sender, text, ok := splitSender(body)
+	if !ok {
+		msg.Type = "system"
+		msg.Text = body
+		return msg
+	}
+	// iOS writes system messages of groups as "<group name>: <LRM><event>"
+	if strings.HasPrefix(text, "\u200e") && isSystem(text) {
+		msg.Type = "system"
+		msg.Text = strings.TrimLeft(text, "\u200e")
+		return msg
+	}
+
+	msg.SenderName = sender
+	msg.FromMe = opts.SelfName != "" && sender == opts.SelfName
+	if number, err := phone.Parse(sender, opts.DefaultRegion); err == nil && looksLikeNumber(sender) {
+		msg.SenderJID = number.JID()
+	}
+
+	text = strings.TrimLeft(text, "\u200e")
+	text = editedSuffix.ReplaceAllString(text, "")
+	msg.Type, msg.Text, msg.Attachment = classify(text)
+	return msg
+}
+
+// splitSender separates "Name: text". Lines without an author, or whose
+// supposed author is a system event, are system messages.
+func splitSender(body string) (string, string, bool) {
+	i := strings.Index(body, ": ")
+	if i <= 0 {
+		// Messages with an empty text end with ":" only
+		if strings.HasSuffix(body, ":") && !strings.Contains(body, "\n") && !isSystem(body) {
+			return cleanName(strings.TrimSuffix(body, ":")), "", true
+		}
+		return "", "", false
+	}
+	name := body[:i]
+	if strings.Contains(name, "\n") || isSystem(name) {
+		return "", "", false
+	}
+	return cleanName(name), body[i+2:], true
+}
+
+// cleanName drops direction marks and the "~" WhatsApp shows before names of unsaved contacts
+func cleanName(name string) string {
+	name = strings.Trim(name, "\u200e\u200f\u202a\u202c")
+	name = strings.TrimPrefix(name, "~")
+	return strings.TrimSpace(strings.Trim(name, "\u202f\u00a0"))
+}
+
+// looksLikeNumber reports whether a sender name is a phone number rather than a contact name
+func looksLikeNumber(name string) bool {
+	for _, r := range name {
+		if !strings.ContainsRune("+0123456789 -()\u00a0\u202f", r) {
+			return false
+		}
+	}
+	return true
+}
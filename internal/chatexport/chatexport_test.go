@@ -0,0 +1,318 @@
+package chatexport
+
+import (
+	"archive/zip"
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// parsed is the part of a parsed message the tests compare; IDs are hashes
+type parsed struct {
+	Time       string
+	Sender     string
+	Type       string
+	Text       string
+	Attachment string
+	FromMe     bool
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		opts  Options
+		want  []parsed
+	}{
+		{
+			name: "android russian",
+			input: "\ufeff31.12.2020, 23:58 - Сообщения и звонки защищены сквозным шифрованием. Подробнее.\n" +
+				"31.12.2020, 23:59 - Иван Петров: С наступающим!\n" +
+				"Всего самого лучшего\n" +
+				"\n" +
+				"и до встречи\n" +
+				"01.01.2021, 0:01 - Мария: <Без медиафайлов>\n" +
+				"01.01.2021, 0:02 - Мария: IMG-20210101-WA0001.jpg (файл добавлен)\n" +
+				"Салют\n" +
+				"01.01.2021, 0:03 - Иван Петров добавил(а) +7 999 123-45-67\n" +
+				"01.01.2021, 0:04 - Мария: Данное сообщение удалено\n" +
+				"01.01.2021, 0:05 - Иван Петров: Встречаемся завтра <Сообщение изменено>\n",
+			opts: Options{SelfName: "Мария"},
+			want: []parsed{
+				{"2020-12-31 23:58:00", "", "system", "Сообщения и звонки защищены сквозным шифрованием. Подробнее.", "", false},
+				{"2020-12-31 23:59:00", "Иван Петров", "chat", "С наступающим!\nВсего самого лучшего\n\nи до встречи", "", false},
+				{"2021-01-01 00:01:00", "Мария", "media", "", "", true},
+				{"2021-01-01 00:02:00", "Мария", "image", "Салют", "IMG-20210101-WA0001.jpg", true},
+				{"2021-01-01 00:03:00", "", "system", "Иван Петров добавил(а) +7 999 123-45-67", "", false},
+				{"2021-01-01 00:04:00", "Мария", "revoked", "", "", true},
+				{"2021-01-01 00:05:00", "Иван Петров", "chat", "Встречаемся завтра", "", false},
+			},
+		},
+		{
+			name: "android english 12 hour",
+			input: "12/31/20, 11:58 PM - Messages and calls are end-to-end encrypted. No one outside of this chat, not even WhatsApp, can read or listen to them. Tap to learn more.\n" +
+				"12/31/20, 11:59 PM - John Smith: Happy new year\n" +
+				"1/1/21, 12:00 AM - John Smith created group \"Party\"\n" +
+				"1/1/21, 12:30 PM - John Smith: <Media omitted>\n" +
+				"1/1/21, 12:31 PM - John Smith: PTT-20210101-WA0002.opus (file attached)\n" +
+				"1/1/21, 1:05 PM - Mary left\n",
+			want: []parsed{
+				{"2020-12-31 23:58:00", "", "system", "Messages and calls are end-to-end encrypted. No one outside of this chat, not even WhatsApp, can read or listen to them. Tap to learn more.", "", false},
+				{"2020-12-31 23:59:00", "John Smith", "chat", "Happy new year", "", false},
+				{"2021-01-01 00:00:00", "", "system", "John Smith created group \"Party\"", "", false},
+				{"2021-01-01 12:30:00", "John Smith", "media", "", "", false},
+				{"2021-01-01 12:31:00", "John Smith", "audio", "", "PTT-20210101-WA0002.opus", false},
+				{"2021-01-01 13:05:00", "", "system", "Mary left", "", false},
+			},
+		},
+		{
+			name: "android slashed 24 hour is day first",
+			input: "02/01/2021, 10:15 - Hans: Guten Morgen\n" +
+				"03/01/2021, 18:40 - Hans: Bis morgen\n",
+			want: []parsed{
+				{"2021-01-02 10:15:00", "Hans", "chat", "Guten Morgen", "", false},
+				{"2021-01-03 18:40:00", "Hans", "chat", "Bis morgen", "", false},
+			},
+		},
+		{
+			name: "ios english",
+			input: "[12/31/20, 11:58:01\u202fPM] Party: \u200eMessages and calls are end-to-end encrypted. No one outside of this chat, not even WhatsApp, can read or listen to them.\n" +
+				"[12/31/20, 11:58:30\u202fPM] Party: \u200eJohn Smith added Mary\n" +
+				"[12/31/20, 11:59:59\u202fPM] John Smith: Happy new year\n" +
+				"see you all\n" +
+				"[1/1/21, 12:00:05\u202fAM] ~\u202fMary: \u200e<attached: 00000012-PHOTO-2021-01-01-00-00-05.jpg>\n" +
+				"[1/1/21, 12:01:00\u202fAM] John Smith: \u200eimage omitted\n" +
+				"[1/1/21, 12:02:00\u202fAM] +1 (415) 555-2671: Who is this?\n",
+			opts: Options{DefaultRegion: "US"},
+			want: []parsed{
+				{"2020-12-31 23:58:01", "", "system", "Messages and calls are end-to-end encrypted. No one outside of this chat, not even WhatsApp, can read or listen to them.", "", false},
+				{"2020-12-31 23:58:30", "", "system", "John Smith added Mary", "", false},
+				{"2020-12-31 23:59:59", "John Smith", "chat", "Happy new year\nsee you all", "", false},
+				{"2021-01-01 00:00:05", "Mary", "image", "", "00000012-PHOTO-2021-01-01-00-00-05.jpg", false},
+				{"2021-01-01 00:01:00", "John Smith", "image", "", "", false},
+				{"2021-01-01 00:02:00", "+1 (415) 555-2671", "chat", "Who is this?", "", false},
+			},
+		},
+		{
+			name: "ios russian",
+			input: "[31.12.2020, 23:59:59] Иван: \u200eизображение отсутствует\n" +
+				"[01.01.2021, 00:00:10] Иван: Открытка \u200e<прикреплено: 00000013-PHOTO-2021-01-01-00-00-10.jpg>\n" +
+				"[01.01.2021, 00:01:00] Семья: \u200eИван изменил(а) тему на «Семья 2021»\n" +
+				"[01.01.2021, 00:02:00] Иван: Первая строка\n" +
+				"Вторая строка\n",
+			want: []parsed{
+				{"2020-12-31 23:59:59", "Иван", "image", "", "", false},
+				{"2021-01-01 00:00:10", "Иван", "image", "Открытка", "00000013-PHOTO-2021-01-01-00-00-10.jpg", false},
+				{"2021-01-01 00:01:00", "", "system", "Иван изменил(а) тему на «Семья 2021»", "", false},
+				{"2021-01-01 00:02:00", "Иван", "chat", "Первая строка\nВторая строка", "", false},
+			},
+		},
+		{
+			name:  "ambiguous dates with forced order",
+			input: "01/02/21, 09:30 - Ann: Hi\n",
+			opts:  Options{DateOrder: "mdy"},
+			want: []parsed{
+				{"2021-01-02 09:30:00", "Ann", "chat", "Hi", "", false},
+			},
+		},
+		{
+			name:  "year first",
+			input: "2021-01-02, 09:30 - Ann: Hi\n",
+			want: []parsed{
+				{"2021-01-02 09:30:00", "Ann", "chat", "Hi", "", false},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			messages, err := Parse(strings.NewReader(tt.input), tt.opts)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			got := make([]parsed, len(messages))
+			for i, m := range messages {
+				got[i] = parsed{m.Timestamp.Format("2006-01-02 15:04:05"), m.SenderName, m.Type, m.Text, m.Attachment, m.FromMe}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse mismatch\n got: %+v\nwant: %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSenderJID(t *testing.T) {
+	messages, err := Parse(strings.NewReader("31.12.2020, 23:59 - +7 999 123-45-67: Привет\n31.12.2020, 23:59 - Иван: Привет\n"), Options{})
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if got := messages[0].SenderJID; got != "79991234567@c.us" {
+		t.Errorf("SenderJID of a number = %q, want 79991234567@c.us", got)
+	}
+	if got := messages[1].SenderJID; got != "" {
+		t.Errorf("SenderJID of a name = %q, want empty", got)
+	}
+}
+
+func TestParseIDs(t *testing.T) {
+	input := "31.12.2020, 23:59 - Иван: Ура\n31.12.2020, 23:59 - Иван: Ура\n"
+	first, err := Parse(strings.NewReader(input), Options{})
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	again, _ := Parse(strings.NewReader(input), Options{})
+	if first[0].ID == first[1].ID {
+		t.Errorf("repeated messages share ID %s", first[0].ID)
+	}
+	if first[0].ID != again[0].ID || first[1].ID != again[1].ID {
+		t.Errorf("IDs changed between imports: %s %s, then %s %s", first[0].ID, first[1].ID, again[0].ID, again[1].ID)
+	}
+}
+
+func TestParseLocation(t *testing.T) {
+	messages, err := Parse(strings.NewReader("31.12.2020, 23:59 - Иван: 13:00 - 14:00\n"), Options{Location: time.FixedZone("MSK", 3*60*60)})
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if got, want := messages[0].Timestamp.UTC(), time.Date(2020, 12, 31, 20, 59, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("Timestamp = %v, want %v", got, want)
+	}
+	if got := messages[0].Text; got != "13:00 - 14:00" {
+		t.Errorf("Text = %q, want 13:00 - 14:00", got)
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, input := range []string{"", "just some notes\nwithout messages\n", "31.13.2020, 23:59 - Иван: Привет\n"} {
+		if _, err := Parse(strings.NewReader(input), Options{}); err == nil {
+			t.Errorf("Parse(%q) succeeded, want an error", input)
+		}
+	}
+}
+
+func TestTitleFromFileName(t *testing.T) {
+	tests := map[string]string{
+		"WhatsApp Chat with John Smith.zip":       "John Smith",
+		"WhatsApp Chat - Party.zip":               "Party",
+		"Чат WhatsApp с Иван Петров.txt":          "Иван Петров",
+		"WhatsApp-чат с Мария (2).txt":            "Мария",
+		`C:\Downloads\WhatsApp Chat with Ann.txt`: "Ann",
+		"_chat.txt": "",
+		"notes.txt": "",
+	}
+	for name, want := range tests {
+		if got := TitleFromFileName(name); got != want {
+			t.Errorf("TitleFromFileName(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+// zipFile is a file of an export archive built by a test
+type zipFile struct {
+	name, body string
+}
+
+func buildZip(t *testing.T, files []zipFile) *bytes.Reader {
+	t.Helper()
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for _, f := range files {
+		fw, err := w.Create(f.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fw.Write([]byte(f.body))
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return bytes.NewReader(buf.Bytes())
+}
+
+func TestParseFile(t *testing.T) {
+	const chat = "31.12.2020, 23:59 - Иван: Привет\n"
+	const notes = "31.12.2020, 10:00 - Список: купить ёлку\n"
+
+	tests := []struct {
+		name        string
+		file        string
+		files       []zipFile
+		title       string
+		text        string
+		attachments []string
+	}{
+		{
+			name: "android export after a shared document",
+			file: "export.zip",
+			files: []zipFile{
+				{"notes.txt", notes},
+				{"WhatsApp Chat with Иван.txt", chat},
+				{"IMG-20201231-WA0001.jpg", "jpeg"},
+			},
+			title:       "Иван",
+			text:        "Привет",
+			attachments: []string{"IMG-20201231-WA0001.jpg", "notes.txt"},
+		},
+		{
+			name: "ios export with a shared document",
+			file: "WhatsApp Chat - Иван.zip",
+			files: []zipFile{
+				{"00000012-notes.txt", notes},
+				{"_chat.txt", "[31.12.2020, 23:59:59] Иван: Привет\n"},
+				{"00000013-PHOTO-2020-12-31-23-59-59.jpg", "jpeg"},
+			},
+			title:       "Иван",
+			text:        "Привет",
+			attachments: []string{"00000012-notes.txt", "00000013-PHOTO-2020-12-31-23-59-59.jpg"},
+		},
+		{
+			name: "unnamed text file",
+			file: "WhatsApp Chat with Иван.zip",
+			files: []zipFile{
+				{"chat.txt", chat},
+				{"folder/", ""},
+			},
+			title: "Иван",
+			text:  "Привет",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := buildZip(t, tt.files)
+			result, err := ParseFile(tt.file, r, r.Size(), Options{})
+			if err != nil {
+				t.Fatalf("ParseFile: %v", err)
+			}
+			if result.Title != tt.title {
+				t.Errorf("Title = %q, want %q", result.Title, tt.title)
+			}
+			if len(result.Messages) != 1 || result.Messages[0].Text != tt.text {
+				t.Errorf("Messages = %+v, want one with text %q", result.Messages, tt.text)
+			}
+			if !reflect.DeepEqual(result.Attachments, tt.attachments) {
+				t.Errorf("Attachments = %q, want %q", result.Attachments, tt.attachments)
+			}
+		})
+	}
+}
+
+func TestParseFileText(t *testing.T) {
+	r := strings.NewReader("31.12.2020, 23:59 - Иван: Привет\n")
+	result, err := ParseFile("WhatsApp Chat with Иван.txt", r, r.Size(), Options{})
+	if err != nil {
+		t.Fatalf("ParseFile: %v", err)
+	}
+	if result.Title != "Иван" || len(result.Messages) != 1 {
+		t.Errorf("ParseFile = %+v, want one message of Иван", result)
+	}
+}
+
+func TestParseFileWithoutChat(t *testing.T) {
+	r := buildZip(t, []zipFile{{"IMG-20201231-WA0001.jpg", "jpeg"}})
+	if _, err := ParseFile("export.zip", r, r.Size(), Options{}); err == nil {
+		t.Error("ParseFile succeeded on an archive without a chat")
+	}
+}
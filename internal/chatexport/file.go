@@ -0,0 +1,101 @@
+package chatexport
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"whatsapp-parser/internal/domain"
+)
+
+// maxChatSize bounds the chat text read from an export, zipped or not
+const maxChatSize = 256 << 20
+
+// titlePattern extracts the chat name from the file names WhatsApp gives exports
+var titlePattern = regexp.MustCompile(`(?i)^(?:WhatsApp Chat (?:with|-) |Чат WhatsApp с |WhatsApp-чат с )(.+?)(?: \(\d+\))?$`)
+
+// TitleFromFileName returns the chat name of an export file, e.g. "John" for
+// "WhatsApp Chat with John.zip", or "" for other names
+func TitleFromFileName(name string) string {
+	base := path.Base(strings.ReplaceAll(name, `\`, "/"))
+	base = strings.TrimSuffix(base, path.Ext(base))
+	if m := titlePattern.FindStringSubmatch(base); m != nil {
+		return strings.TrimSpace(m[1])
+	}
+	return ""
+}
+
+// ParseFile parses a .txt export or a .zip export that holds the chat text
+// and the media files it references
+func ParseFile(name string, file io.ReaderAt, size int64, opts Options) (*domain.ChatImport, error) {
+	magic := make([]byte, 4)
+	if _, err := file.ReadAt(magic, 0); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("failed to read file: %v", err)
+	}
+	if !bytes.Equal(magic, []byte("PK\x03\x04")) {
+		messages, err := Parse(io.LimitReader(io.NewSectionReader(file, 0, size), maxChatSize), opts)
+		if err != nil {
+			return nil, err
+		}
+		return &domain.ChatImport{Title: TitleFromFileName(name), Messages: messages}, nil
+	}
+
+	archive, err := zip.NewReader(file, size)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidChatExport, err)
+	}
+
+	chat := chatFile(archive.File)
+	if chat == nil {
+		return nil, fmt.Errorf("%w: archive has no chat text file", domain.ErrInvalidChatExport)
+	}
+	var attachments []string
+	for _, f := range archive.File {
+		if f != chat && !f.FileInfo().IsDir() {
+			attachments = append(attachments, path.Base(f.Name))
+		}
+	}
+
+	rc, err := chat.Open()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidChatExport, err)
+	}
+	defer rc.Close()
+	messages, err := Parse(io.LimitReader(rc, maxChatSize), opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// iOS names the text "_chat.txt"; the archive name carries the title then
+	title := TitleFromFileName(chat.Name)
+	if title == "" {
+		title = TitleFromFileName(name)
+	}
+	sort.Strings(attachments)
+	return &domain.ChatImport{Title: title, Messages: messages, Attachments: attachments}, nil
+}
+
+// chatFile picks the chat text of an export archive: the file named like an
+// export ("_chat.txt" on iOS, "WhatsApp Chat with …" on Android) over any other
+// .txt, which may be a shared document. Without such a file the first .txt is
+// taken.
+func chatFile(files []*zip.File) *zip.File {
+	var first *zip.File
+	for _, f := range files {
+		if f.FileInfo().IsDir() || !strings.EqualFold(path.Ext(f.Name), ".txt") {
+			continue
+		}
+		if path.Base(f.Name) == "_chat.txt" || TitleFromFileName(f.Name) != "" {
+			return f
+		}
+		if first == nil {
+			first = f
+		}
+	}
+	return first
+}
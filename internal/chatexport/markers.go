@@ -0,0 +1,135 @@
+package chatexport
+
+import (
+	"path"
+	"regexp"
+	"strings"
+)
+
+// systemPhrases identify events WhatsApp writes into exports instead of messages
+var systemPhrases = []string{
+	// English
+	"end-to-end encrypted", "created group", "created this group", "added", "removed",
+	" left", "joined using this group's invite link", "changed the subject",
+	"changed this group's icon", "deleted this group's icon", "changed the group description",
+	"changed their phone number", "security code changed", "changed the group settings",
+	"is now an admin", "no longer an admin", "disappearing messages", "pinned a message",
+	"missed voice call", "missed video call",
+	// Russian
+	"сквозным шифрованием", "создал(а) группу", "создал группу", "создала группу",
+	"добавил", "удалил", "вышел", "вышла", "по ссылке-приглашению", "изменил(а) тему",
+	"изменил тему", "изменила тему", "изменил(а) изображение группы", "изменил(а) описание группы",
+	"изменил(а) номер телефона", "код безопасности", "теперь администратор",
+	"больше не администратор", "исчезающие сообщения", "закрепил(а) сообщение",
+	"пропущенный аудиозвонок", "пропущенный видеозвонок",
+}
+
+// isSystem reports whether text describes a group or chat event
+func isSystem(text string) bool {
+	lower := strings.ToLower(text)
+	for _, phrase := range systemPhrases {
+		if strings.Contains(lower, phrase) {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	// editedSuffix is appended to edited messages
+	editedSuffix = regexp.MustCompile(`\s*<(This message was edited|Сообщение изменено|Изменено)>$`)
+	// iosAttachment marks media included in an iOS export
+	iosAttachment = regexp.MustCompile(`<(?:attached|прикреплено|вложение): ([^>]+)>`)
+	// androidAttachment is the first line of a message with media included in an Android export
+	androidAttachment = regexp.MustCompile(`^(\S.*?\.[A-Za-z0-9]{2,5}) \((?:file attached|файл добавлен|файл прикреплён|файл прикреплен)\)$`)
+)
+
+// omitted maps placeholders of media left out of an export to the message type
+var omitted = map[string]string{
+	"<media omitted>":               "media",
+	"<без медиафайлов>":             "media",
+	"<медиафайл пропущен>":          "media",
+	"<медиа отсутствует>":           "media",
+	"image omitted":                 "image",
+	"video omitted":                 "video",
+	"audio omitted":                 "audio",
+	"sticker omitted":               "sticker",
+	"gif omitted":                   "image",
+	"document omitted":              "document",
+	"contact card omitted":          "vcard",
+	"изображение отсутствует":       "image",
+	"видео отсутствует":             "video",
+	"аудиофайл отсутствует":         "audio",
+	"стикер отсутствует":            "sticker",
+	"gif отсутствует":               "image",
+	"документ отсутствует":          "document",
+	"карточка контакта отсутствует": "vcard",
+}
+
+// deleted are the texts left in place of deleted messages
+var deleted = map[string]bool{
+	"this message was deleted":    true,
+	"you deleted this message":    true,
+	"данное сообщение удалено":    true,
+	"это сообщение было удалено":  true,
+	"вы удалили данное сообщение": true,
+	"вы удалили это сообщение":    true,
+}
+
+// locationPrefixes start messages sharing a location
+var locationPrefixes = []string{"location: ", "live location shared", "геопозиция: ", "местоположение: ", "трансляция геоданных"}
+
+// classify returns the message type, the text without media markers and the attachment file name
+func classify(text string) (string, string, string) {
+	trimmed := strings.ToLower(strings.TrimSpace(strings.TrimLeft(text, "\u200e")))
+	if t, ok := omitted[trimmed]; ok {
+		return t, "", ""
+	}
+	if deleted[trimmed] {
+		return "revoked", "", ""
+	}
+	for _, prefix := range locationPrefixes {
+		if strings.HasPrefix(trimmed, prefix) {
+			return "location", text, ""
+		}
+	}
+
+	if m := iosAttachment.FindStringSubmatchIndex(text); m != nil {
+		name := text[m[2]:m[3]]
+		caption := strings.TrimSpace(strings.Trim(text[:m[0]]+text[m[1]:], "\u200e"))
+		return attachmentType(name), caption, name
+	}
+	firstLine, caption, _ := strings.Cut(text, "\n")
+	if m := androidAttachment.FindStringSubmatch(strings.TrimLeft(firstLine, "\u200e")); m != nil {
+		return attachmentType(m[1]), strings.TrimSpace(caption), m[1]
+	}
+	return "chat", text, ""
+}
+
+// attachmentType guesses the message type of a media file from WhatsApp's
+// naming (IMG-…, PTT-…) or the extension
+func attachmentType(name string) string {
+	upper := strings.ToUpper(path.Base(name))
+	switch {
+	case strings.HasPrefix(upper, "STK-"), strings.Contains(upper, "-STICKER-"):
+		return "sticker"
+	case strings.HasPrefix(upper, "PTT-"), strings.HasPrefix(upper, "AUD-"), strings.Contains(upper, "-AUDIO-"):
+		return "audio"
+	case strings.HasPrefix(upper, "IMG-"), strings.Contains(upper, "-PHOTO-"):
+		return "image"
+	case strings.HasPrefix(upper, "VID-"), strings.Contains(upper, "-VIDEO-"):
+		return "video"
+	}
+
+	switch strings.ToLower(path.Ext(name)) {
+	case ".jpg", ".jpeg", ".png", ".webp", ".heic", ".gif":
+		return "image"
+	case ".mp4", ".3gp", ".mov", ".mkv":
+		return "video"
+	case ".opus", ".ogg", ".m4a", ".mp3", ".aac", ".amr":
+		return "audio"
+	case ".vcf":
+		return "vcard"
+	}
+	return "document"
+}
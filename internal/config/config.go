@@ -0,0 +1,300 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"whatsapp-parser/internal/domain"
+)
+
+// Duration wraps time.Duration so it can be written as "30s" in JSON config
+type Duration time.Duration
+
+// UnmarshalJSON parses durations written either as strings ("1m30s") or as nanoseconds
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		parsed, err := time.ParseDuration(s)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q: %v", s, err)
+		}
+		*d = Duration(parsed)
+		return nil
+	}
+
+	var n int64
+	if err := json.Unmarshal(data, &n); err != nil {
+		return fmt.Errorf("invalid duration %s", string(data))
+	}
+	*d = Duration(n)
+	return nil
+}
+
+// MarshalJSON writes the duration in its string form
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// Timeouts holds per-endpoint request deadlines
+type Timeouts struct {
+	CreateSession  Duration `json:"create_session"`
+	RestoreSession Duration `json:"restore_session"`
+	SendMessage    Duration `json:"send_message"`
+	CheckContacts  Duration `json:"check_contacts"`
+	ExportChat     Duration `json:"export_chat"`
+	ManageGroup    Duration `json:"manage_group"`
+	ChatAction     Duration `json:"chat_action"`
+}
+
+// Auth holds API key authentication settings
+type Auth struct {
+	// Disabled turns off authentication; intended for local development only
+	Disabled bool `json:"disabled"`
+	// Keys are accepted in addition to keys stored in the repository.
+	// Only the SHA-256 hash of each key is kept in the config.
+	Keys []domain.APIKey `json:"keys"`
+}
+
+// WarmUpStage caps daily sends of a newly linked number until it is Days old
+type WarmUpStage struct {
+	Days       int `json:"days"`
+	DailyLimit int `json:"daily_limit"`
+}
+
+// Pacing holds anti-ban send limits applied to each session. Zero values disable a limit.
+type Pacing struct {
+	// MinGap is the minimum time between two sends of a session
+	MinGap Duration `json:"min_gap"`
+	// Jitter adds a random extra delay of up to this value to MinGap
+	Jitter      Duration `json:"jitter"`
+	HourlyLimit int      `json:"hourly_limit"`
+	DailyLimit  int      `json:"daily_limit"`
+	// RecipientCooldown is the minimum time between two sends to the same recipient
+	RecipientCooldown Duration `json:"recipient_cooldown"`
+	// WarmUp lists stages ordered by Days; the first stage the session is younger than applies
+	WarmUp []WarmUpStage `json:"warm_up"`
+}
+
+// Typing controls how messages are typed into WhatsApp Web
+type Typing struct {
+	CharDelay       Duration `json:"char_delay"`
+	CharDelayJitter Duration `json:"char_delay_jitter"`
+	// PasteThreshold is the line length above which a line is pasted instead of typed
+	PasteThreshold     int      `json:"paste_threshold"`
+	ShowTyping         bool     `json:"show_typing"`
+	MaxTypingIndicator Duration `json:"max_typing_indicator"`
+}
+
+// Scheduler controls the loop sending scheduled messages
+type Scheduler struct {
+	// Interval is how often due messages are looked for
+	Interval Duration `json:"interval"`
+	// Grace is how late a run may start before it counts as missed
+	Grace Duration `json:"grace"`
+	// CatchUp is the default policy for runs missed while the service was down
+	CatchUp domain.CatchUpPolicy `json:"catch_up"`
+}
+
+// Config holds application settings
+type Config struct {
+	Port       string `json:"port"`
+	StorageDir string `json:"storage_dir"`
+	// BrowserDataDir holds Chrome profiles, one directory per tenant and session
+	BrowserDataDir string   `json:"browser_data_dir"`
+	Timeouts       Timeouts `json:"timeouts"`
+	// ShutdownTimeout bounds how long in-flight requests may run after SIGINT/SIGTERM
+	ShutdownTimeout Duration `json:"shutdown_timeout"`
+	Auth            Auth     `json:"auth"`
+	// CORSOrigins lists origins allowed to call the API from a browser; "*" allows any
+	CORSOrigins []string `json:"cors_origins"`
+	// Tenants lists per-tenant quotas; tenants not listed get DefaultQuota
+	Tenants      []domain.Tenant `json:"tenants"`
+	DefaultQuota domain.Tenant   `json:"default_quota"`
+	// Pacing applies to every session unless overridden in SessionPacing by session ID
+	Pacing        Pacing            `json:"pacing"`
+	SessionPacing map[string]Pacing `json:"session_pacing"`
+	Typing        Typing            `json:"typing"`
+	Scheduler     Scheduler         `json:"scheduler"`
+	// IncomingPoll is how often running sessions are checked for new incoming messages
+	IncomingPoll Duration `json:"incoming_poll"`
+	// ArchiveSync is how often archived chats of running sessions are caught up; 0 disables the sync
+	ArchiveSync Duration `json:"archive_sync"`
+	// ArchiveMedia downloads media files of synced messages into the media store
+	ArchiveMedia bool `json:"archive_media"`
+	// MediaQuotaMB bounds the size of the media store in megabytes; 0 means unlimited
+	MediaQuotaMB int64 `json:"media_quota_mb"`
+	// OptOutKeywords put the sender on the suppression list when a message consists of one of them
+	OptOutKeywords []string `json:"opt_out_keywords"`
+	// DefaultPhoneRegion is used for phone numbers given without an international prefix
+	DefaultPhoneRegion string `json:"default_phone_region"`
+	// ContactCheckTTL is how long registration check results are reused
+	ContactCheckTTL Duration `json:"contact_check_ttl"`
+	// IdempotencyTTL is how long responses to requests with an Idempotency-Key are replayed
+	IdempotencyTTL Duration `json:"idempotency_ttl"`
+}
+
+// PacingFor returns the pacing limits of a session
+func (c *Config) PacingFor(sessionID string) Pacing {
+	if p, ok := c.SessionPacing[sessionID]; ok {
+		return p
+	}
+	return c.Pacing
+}
+
+// Tenant returns the settings of a tenant, falling back to the default quota
+func (c *Config) Tenant(id string) domain.Tenant {
+	for _, t := range c.Tenants {
+		if t.ID == id {
+			return t
+		}
+	}
+	tenant := c.DefaultQuota
+	tenant.ID = id
+	return tenant
+}
+
+// Default returns configuration used when no config file is present
+func Default() *Config {
+	return &Config{
+		Port:               "8081",
+		StorageDir:         filepath.Join(".", "storage"),
+		BrowserDataDir:     filepath.Join(".", "chrome_data"),
+		DefaultPhoneRegion: "RU",
+		Timeouts: Timeouts{
+			CreateSession:  Duration(90 * time.Second),
+			RestoreSession: Duration(60 * time.Second),
+			SendMessage:    Duration(60 * time.Second),
+			CheckContacts:  Duration(5 * time.Minute),
+			ExportChat:     Duration(10 * time.Minute),
+			ManageGroup:    Duration(2 * time.Minute),
+			ChatAction:     Duration(60 * time.Second),
+		},
+		ShutdownTimeout: Duration(30 * time.Second),
+		ContactCheckTTL: Duration(7 * 24 * time.Hour),
+		IdempotencyTTL:  Duration(24 * time.Hour),
+		Pacing: Pacing{
+			MinGap:            Duration(15 * time.Second),
+			Jitter:            Duration(15 * time.Second),
+			HourlyLimit:       60,
+			DailyLimit:        500,
+			RecipientCooldown: Duration(time.Minute),
+			WarmUp: []WarmUpStage{
+				{Days: 3, DailyLimit: 20},
+				{Days: 7, DailyLimit: 50},
+				{Days: 14, DailyLimit: 150},
+			},
+		},
+		Typing: Typing{
+			CharDelay:          Duration(70 * time.Millisecond),
+			CharDelayJitter:    Duration(40 * time.Millisecond),
+			PasteThreshold:     200,
+			ShowTyping:         true,
+			MaxTypingIndicator: Duration(8 * time.Second),
+		},
+		IncomingPoll:   Duration(15 * time.Second),
+		ArchiveSync:    Duration(15 * time.Minute),
+		MediaQuotaMB:   1024,
+		OptOutKeywords: []string{"STOP", "UNSUBSCRIBE", "СТОП", "ОТПИСАТЬСЯ"},
+		Scheduler: Scheduler{
+			Interval: Duration(5 * time.Second),
+			Grace:    Duration(time.Minute),
+			CatchUp:  domain.CatchUpOnce,
+		},
+	}
+}
+
+// Load reads configuration from the JSON file at CONFIG_PATH (config/config.json
+// by default) on top of the defaults and then applies environment overrides
+func Load() (*Config, error) {
+	cfg := Default()
+
+	path := os.Getenv("CONFIG_PATH")
+	if path == "" {
+		path = filepath.Join(".", "config", "config.json")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read config file: %v", err)
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, cfg); err != nil {
+			return nil, fmt.Errorf("failed to parse config file: %v", err)
+		}
+	}
+
+	if port := os.Getenv("PORT"); port != "" {
+		cfg.Port = port
+	}
+	if dir := os.Getenv("STORAGE_DIR"); dir != "" {
+		cfg.StorageDir = dir
+	}
+	if dir := os.Getenv("BROWSER_DATA_DIR"); dir != "" {
+		cfg.BrowserDataDir = dir
+	}
+	if region := os.Getenv("DEFAULT_PHONE_REGION"); region != "" {
+		cfg.DefaultPhoneRegion = region
+	}
+	if origins := os.Getenv("CORS_ORIGINS"); origins != "" {
+		cfg.CORSOrigins = strings.Split(origins, ",")
+		for i := range cfg.CORSOrigins {
+			cfg.CORSOrigins[i] = strings.TrimSpace(cfg.CORSOrigins[i])
+		}
+	}
+	if os.Getenv("AUTH_DISABLED") == "true" {
+		cfg.Auth.Disabled = true
+	}
+	if os.Getenv("ARCHIVE_MEDIA") == "true" {
+		cfg.ArchiveMedia = true
+	}
+	if quota := os.Getenv("MEDIA_QUOTA_MB"); quota != "" {
+		mb, err := strconv.ParseInt(quota, 10, 64)
+		if err != nil || mb < 0 {
+			return nil, fmt.Errorf("invalid MEDIA_QUOTA_MB %q", quota)
+		}
+		cfg.MediaQuotaMB = mb
+	}
+	if policy := os.Getenv("SCHEDULER_CATCH_UP"); policy != "" {
+		cfg.Scheduler.CatchUp = domain.CatchUpPolicy(policy)
+	}
+
+	overrides := map[string]*Duration{
+		"TIMEOUT_CREATE_SESSION":  &cfg.Timeouts.CreateSession,
+		"TIMEOUT_RESTORE_SESSION": &cfg.Timeouts.RestoreSession,
+		"TIMEOUT_SEND_MESSAGE":    &cfg.Timeouts.SendMessage,
+		"TIMEOUT_CHECK_CONTACTS":  &cfg.Timeouts.CheckContacts,
+		"TIMEOUT_EXPORT_CHAT":     &cfg.Timeouts.ExportChat,
+		"TIMEOUT_MANAGE_GROUP":    &cfg.Timeouts.ManageGroup,
+		"TIMEOUT_CHAT_ACTION":     &cfg.Timeouts.ChatAction,
+		"SHUTDOWN_TIMEOUT":        &cfg.ShutdownTimeout,
+		"CONTACT_CHECK_TTL":       &cfg.ContactCheckTTL,
+		"INCOMING_POLL":           &cfg.IncomingPoll,
+		"ARCHIVE_SYNC":            &cfg.ArchiveSync,
+		"IDEMPOTENCY_TTL":         &cfg.IdempotencyTTL,
+	}
+	for name, target := range overrides {
+		value := os.Getenv(name)
+		if value == "" {
+			continue
+		}
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %v", name, err)
+		}
+		*target = Duration(d)
+	}
+
+	if !domain.ValidCatchUpPolicy(cfg.Scheduler.CatchUp) {
+		return nil, fmt.Errorf("invalid scheduler catch_up policy %q", cfg.Scheduler.CatchUp)
+	}
+	if cfg.Scheduler.Interval <= 0 {
+		return nil, fmt.Errorf("scheduler interval must be positive")
+	}
+
+	return cfg, nil
+}
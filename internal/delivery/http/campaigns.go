@@ -0,0 +1,287 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"whatsapp-parser/internal/delivery/http/middleware"
+	"whatsapp-parser/internal/domain"
+)
+
+// maxCampaignUpload limits the size of an uploaded recipient CSV
+const maxCampaignUpload = 32 << 20
+
+// CampaignResponse describes a campaign without its recipient list
+type CampaignResponse struct {
+	ID         string                  `json:"id"`
+	Name       string                  `json:"name"`
+	TemplateID string                  `json:"template_id"`
+	Locale     string                  `json:"locale,omitempty"`
+	SessionIDs []string                `json:"session_ids"`
+	Status     domain.CampaignStatus   `json:"status"`
+	Error      string                  `json:"error,omitempty"`
+	Progress   domain.CampaignProgress `json:"progress"`
+	CreatedAt  time.Time               `json:"created_at"`
+	UpdatedAt  time.Time               `json:"updated_at"`
+	FinishedAt *time.Time              `json:"finished_at,omitempty"`
+}
+
+func newCampaignResponse(c *domain.Campaign) CampaignResponse {
+	return CampaignResponse{
+		ID:         c.ID,
+		Name:       c.Name,
+		TemplateID: c.TemplateID,
+		Locale:     c.Locale,
+		SessionIDs: c.SessionIDs,
+		Status:     c.Status,
+		Error:      c.Error,
+		Progress:   c.Progress(),
+		CreatedAt:  c.CreatedAt,
+		UpdatedAt:  c.UpdatedAt,
+		FinishedAt: c.FinishedAt,
+	}
+}
+
+// campaignAllowed reports whether an API key may see and control a campaign:
+// keys bound to sessions need every session the campaign sends through
+func campaignAllowed(key *domain.APIKey, c *domain.Campaign) bool {
+	if key == nil {
+		return true
+	}
+	for _, id := range c.SessionIDs {
+		if !key.AllowsSession(id) {
+			return false
+		}
+	}
+	return true
+}
+
+// authorizeCampaign loads the campaign of the request and checks the API key
+// may access it; it writes the error response and returns nil otherwise
+func (h *Handler) authorizeCampaign(w http.ResponseWriter, r *http.Request) *domain.Campaign {
+	campaign, err := h.campaignUseCase.GetCampaign(r.Context(), tenantID(r), mux.Vars(r)["campaignId"])
+	if err != nil {
+		writeError(w, err)
+		return nil
+	}
+	if !campaignAllowed(middleware.APIKeyFromContext(r.Context()), campaign) {
+		http.Error(w, fmt.Sprintf("API key is not allowed to access campaign %s", campaign.ID), http.StatusForbidden)
+		return nil
+	}
+	return campaign
+}
+
+func writeCampaign(w http.ResponseWriter, status int, c *domain.Campaign) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(newCampaignResponse(c))
+}
+
+// CreateCampaign godoc
+// @Summary Создать рассылку
+// @Description Загружает CSV получателей (колонка phone_number, остальные колонки — переменные шаблона) и сразу запускает рассылку через пул сессий с соблюдением лимитов отправки
+// @Tags campaigns
+// @Accept multipart/form-data
+// @Produce json
+// @Param name formData string false "Название рассылки"
+// @Param template_id formData string true "ID шаблона"
+// @Param locale formData string false "Локаль шаблона"
+// @Param session_ids formData string true "ID сессий через запятую"
+// @Param recipients formData file true "CSV получателей"
+// @Success 201 {object} CampaignResponse
+// @Failure 400 {string} string "Некорректный CSV или шаблон"
+// @Failure 404 {string} string "Сессия или шаблон не найдены"
+// @Security ApiKeyAuth
+// @Router /campaigns [post]
+func (h *Handler) CreateCampaign(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseMultipartForm(maxCampaignUpload); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	file, _, err := r.FormFile("recipients")
+	if err != nil {
+		http.Error(w, "recipients file is required", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	var sessionIDs []string
+	for _, value := range r.MultipartForm.Value["session_ids"] {
+		for _, id := range strings.Split(value, ",") {
+			if id = strings.TrimSpace(id); id != "" {
+				sessionIDs = append(sessionIDs, id)
+			}
+		}
+	}
+	if key := middleware.APIKeyFromContext(r.Context()); key != nil {
+		for _, id := range sessionIDs {
+			if !key.AllowsSession(id) {
+				http.Error(w, fmt.Sprintf("API key is not allowed to access session %s", id), http.StatusForbidden)
+				return
+			}
+		}
+	}
+
+	campaign := &domain.Campaign{
+		Name:       r.FormValue("name"),
+		TemplateID: r.FormValue("template_id"),
+		Locale:     r.FormValue("locale"),
+		SessionIDs: sessionIDs,
+	}
+	if err := h.campaignUseCase.CreateCampaign(r.Context(), tenantID(r), campaign, file); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeCampaign(w, http.StatusCreated, campaign)
+}
+
+// ListCampaigns godoc
+// @Summary Список рассылок
+// @Description Ключи, привязанные к сессиям, видят только рассылки через эти сессии
+// @Tags campaigns
+// @Produce json
+// @Success 200 {array} CampaignResponse
+// @Security ApiKeyAuth
+// @Router /campaigns [get]
+func (h *Handler) ListCampaigns(w http.ResponseWriter, r *http.Request) {
+	campaigns, err := h.campaignUseCase.ListCampaigns(r.Context(), tenantID(r))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	key := middleware.APIKeyFromContext(r.Context())
+	response := make([]CampaignResponse, 0, len(campaigns))
+	for _, c := range campaigns {
+		if campaignAllowed(key, c) {
+			response = append(response, newCampaignResponse(c))
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetCampaign godoc
+// @Summary Состояние рассылки
+// @Tags campaigns
+// @Produce json
+// @Param campaignId path string true "ID рассылки"
+// @Success 200 {object} CampaignResponse
+// @Failure 403 {string} string "Ключ не имеет доступа к сессиям рассылки"
+// @Failure 404 {string} string "Рассылка не найдена"
+// @Security ApiKeyAuth
+// @Router /campaigns/{campaignId} [get]
+func (h *Handler) GetCampaign(w http.ResponseWriter, r *http.Request) {
+	campaign := h.authorizeCampaign(w, r)
+	if campaign == nil {
+		return
+	}
+
+	writeCampaign(w, http.StatusOK, campaign)
+}
+
+// PauseCampaign godoc
+// @Summary Приостановить рассылку
+// @Tags campaigns
+// @Produce json
+// @Param campaignId path string true "ID рассылки"
+// @Success 200 {object} CampaignResponse
+// @Failure 403 {string} string "Ключ не имеет доступа к сессиям рассылки"
+// @Failure 404 {string} string "Рассылка не найдена"
+// @Failure 409 {string} string "Рассылка уже завершена или отменена"
+// @Security ApiKeyAuth
+// @Router /campaigns/{campaignId}/pause [post]
+func (h *Handler) PauseCampaign(w http.ResponseWriter, r *http.Request) {
+	if h.authorizeCampaign(w, r) == nil {
+		return
+	}
+	campaign, err := h.campaignUseCase.PauseCampaign(r.Context(), tenantID(r), mux.Vars(r)["campaignId"])
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeCampaign(w, http.StatusOK, campaign)
+}
+
+// ResumeCampaign godoc
+// @Summary Возобновить рассылку
+// @Tags campaigns
+// @Produce json
+// @Param campaignId path string true "ID рассылки"
+// @Success 200 {object} CampaignResponse
+// @Failure 403 {string} string "Ключ не имеет доступа к сессиям рассылки"
+// @Failure 404 {string} string "Рассылка не найдена"
+// @Failure 409 {string} string "Рассылка не приостановлена"
+// @Security ApiKeyAuth
+// @Router /campaigns/{campaignId}/resume [post]
+func (h *Handler) ResumeCampaign(w http.ResponseWriter, r *http.Request) {
+	if h.authorizeCampaign(w, r) == nil {
+		return
+	}
+	campaign, err := h.campaignUseCase.ResumeCampaign(r.Context(), tenantID(r), mux.Vars(r)["campaignId"])
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeCampaign(w, http.StatusOK, campaign)
+}
+
+// CancelCampaign godoc
+// @Summary Отменить рассылку
+// @Description Останавливает рассылку; неотправленные получатели помечаются как skipped
+// @Tags campaigns
+// @Produce json
+// @Param campaignId path string true "ID рассылки"
+// @Success 200 {object} CampaignResponse
+// @Failure 403 {string} string "Ключ не имеет доступа к сессиям рассылки"
+// @Failure 404 {string} string "Рассылка не найдена"
+// @Failure 409 {string} string "Рассылка уже завершена или отменена"
+// @Security ApiKeyAuth
+// @Router /campaigns/{campaignId}/cancel [post]
+func (h *Handler) CancelCampaign(w http.ResponseWriter, r *http.Request) {
+	if h.authorizeCampaign(w, r) == nil {
+		return
+	}
+	campaign, err := h.campaignUseCase.CancelCampaign(r.Context(), tenantID(r), mux.Vars(r)["campaignId"])
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeCampaign(w, http.StatusOK, campaign)
+}
+
+// ExportCampaign godoc
+// @Summary Выгрузить статусы получателей
+// @Description Возвращает CSV со статусом отправки по каждому получателю
+// @Tags campaigns
+// @Produce text/csv
+// @Param campaignId path string true "ID рассылки"
+// @Success 200 {file} file "CSV"
+// @Failure 403 {string} string "Ключ не имеет доступа к сессиям рассылки"
+// @Failure 404 {string} string "Рассылка не найдена"
+// @Security ApiKeyAuth
+// @Router /campaigns/{campaignId}/export [get]
+func (h *Handler) ExportCampaign(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["campaignId"]
+	campaign := h.authorizeCampaign(w, r)
+	if campaign == nil {
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", "campaign-"+campaign.ID+".csv"))
+	if err := h.campaignUseCase.ExportCampaign(r.Context(), tenantID(r), id, w); err != nil {
+		// Headers are already sent; the truncated body is all the client gets
+		log.Printf("Failed to export campaign %s: %v", id, err)
+	}
+}
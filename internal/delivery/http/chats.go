@@ -0,0 +1,278 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"whatsapp-parser/internal/domain"
+	"whatsapp-parser/internal/exporter"
+	"whatsapp-parser/pkg/phone"
+)
+
+// unsafeFileChars are replaced in download file names
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9@._-]+`)
+
+// MessagesResponse is a page of archived messages
+type MessagesResponse struct {
+	Chat     *domain.ArchivedChat `json:"chat"`
+	Messages []domain.Message     `json:"messages"`
+	// NextBefore is passed as before to get the next, older page; empty once no messages are left
+	NextBefore string `json:"next_before,omitempty"`
+}
+
+// ListMessages godoc
+// @Summary Сообщения чата из архива
+// @Description Возвращает сохранённые сообщения чата, начиная с новых, не обращаясь к браузеру. Архив пополняется при чтении истории, из входящих сообщений и фоновой синхронизацией
+// @Tags chats
+// @Produce json
+// @Param id path string true "ID сессии"
+// @Param chatId path string true "JID, номер телефона или название чата"
+// @Param before query string false "ID сообщения; вернуть более старые сообщения"
+// @Param limit query int false "Размер страницы (по умолчанию 50, не больше 500)"
+// @Success 200 {object} MessagesResponse
+// @Failure 404 {string} string "Чат отсутствует в архиве"
+// @Failure 409 {string} string "Несколько чатов с таким названием"
+// @Security ApiKeyAuth
+// @Router /session/{id}/chats/{chatId}/messages [get]
+func (h *Handler) ListMessages(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	sessionID := vars["id"]
+
+	target, err := h.archiveTarget(vars["chatId"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	query := domain.MessageQuery{Before: r.URL.Query().Get("before")}
+	if limit := r.URL.Query().Get("limit"); limit != "" {
+		if query.Limit, err = strconv.Atoi(limit); err != nil || query.Limit < 0 {
+			http.Error(w, "limit must be a non-negative integer", http.StatusBadRequest)
+			return
+		}
+	}
+
+	chat, messages, err := h.archiveUseCase.ListMessages(r.Context(), tenantID(r), sessionID, target, query)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	resp := MessagesResponse{Chat: chat, Messages: messages}
+	if resp.Messages == nil {
+		resp.Messages = []domain.Message{}
+	}
+	if len(messages) > 0 {
+		resp.NextBefore = messages[len(messages)-1].ID
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// archiveTarget parses a chatId of an archive route, which may also be a phone number
+func (h *Handler) archiveTarget(chatID string) (domain.ChatTarget, error) {
+	if n, err := phone.Parse(chatID, h.cfg.DefaultPhoneRegion); err == nil && strings.HasPrefix(strings.TrimSpace(chatID), "+") {
+		return domain.ChatTarget{PhoneNumber: n.E164()}, nil
+	}
+	return domain.ParseChatID(chatID)
+}
+
+// ExportChat godoc
+// @Summary Выгрузить историю чата
+// @Description Открывает чат в браузере сессии и выгружает его историю в формате jsonl, csv, html (с превью медиа) или sqlite (таблицы chats, messages, participants). Файл передаётся потоком; на время выгрузки отправка сообщений этой сессией ожидает
+// @Tags chats
+// @Produce octet-stream
+// @Param id path string true "ID сессии"
+// @Param chatId path string true "JID, ссылка-приглашение или название чата"
+// @Param format query string false "Формат: jsonl (по умолчанию), csv, html или sqlite"
+// @Param since query string false "Выгрузить сообщения не старше этого времени (RFC 3339)"
+// @Param limit query int false "Выгрузить не больше стольких последних сообщений"
+// @Param media query bool false "Скачать медиафайлы в хранилище; в выгрузке появится media_hash"
+// @Success 200 {file} file "Файл выгрузки"
+// @Failure 400 {string} string "Некорректные параметры"
+// @Failure 404 {string} string "Сессия или чат не найдены"
+// @Failure 409 {string} string "Несколько чатов с таким названием"
+// @Security ApiKeyAuth
+// @Router /session/{id}/chats/{chatId}/export [get]
+func (h *Handler) ExportChat(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	sessionID := vars["id"]
+
+	target, err := domain.ParseChatID(vars["chatId"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	query := r.URL.Query()
+	formatName := query.Get("format")
+	if formatName == "" {
+		formatName = "jsonl"
+	}
+	format, err := exporter.Lookup(formatName)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	opts := domain.HistoryOptions{Thumbnails: format.Thumbnails}
+	if since := query.Get("since"); since != "" {
+		if opts.Since, err = time.Parse(time.RFC3339, since); err != nil {
+			http.Error(w, "since must be an RFC 3339 time", http.StatusBadRequest)
+			return
+		}
+	}
+	if limit := query.Get("limit"); limit != "" {
+		if opts.Limit, err = strconv.Atoi(limit); err != nil || opts.Limit < 0 {
+			http.Error(w, "limit must be a non-negative integer", http.StatusBadRequest)
+			return
+		}
+	}
+
+	if media := query.Get("media"); media != "" {
+		if opts.Media, err = strconv.ParseBool(media); err != nil {
+			http.Error(w, "media must be true or false", http.StatusBadRequest)
+			return
+		}
+	}
+
+	out := &exportWriter{
+		w:           w,
+		contentType: format.ContentType,
+		fileName:    "chat-" + unsafeFileChars.ReplaceAllString(target.Key(), "_") + format.Extension,
+	}
+	export, err := format.New(out)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	err = h.sessionUseCase.ReadChatHistory(r.Context(), tenantID(r), sessionID, target, opts, export)
+	if err == nil {
+		err = export.Close()
+	} else {
+		export.Discard()
+	}
+	if err == nil {
+		return
+	}
+	if !out.started {
+		writeError(w, err)
+		return
+	}
+	// Headers are already sent; the truncated body is all the client gets
+	log.Printf("Failed to export chat %s of session %s: %v", target.Key(), sessionID, err)
+}
+
+// exportWriter sends download headers with the first byte of an export, so an
+// error that happens before anything was written still gets its status code
+type exportWriter struct {
+	w           http.ResponseWriter
+	contentType string
+	fileName    string
+	started     bool
+}
+
+func (e *exportWriter) Write(p []byte) (int, error) {
+	if !e.started {
+		e.started = true
+		e.w.Header().Set("Content-Type", e.contentType)
+		e.w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", e.fileName))
+	}
+	return e.w.Write(p)
+}
+
+type ChatActionRequest struct {
+	// Action is archive, unarchive, pin, unpin, mute, unmute, mark_read, mark_unread, clear or delete
+	Action string `json:"action" example:"mute"`
+	// Duration of mute, e.g. "8h" or "168h"; empty mutes for good
+	Duration string `json:"duration,omitempty" example:"8h"`
+}
+
+// Validate checks the action and parses the mute duration
+func (req *ChatActionRequest) Validate() (domain.ChatActionRequest, error) {
+	var action domain.ChatActionRequest
+	for _, a := range domain.ChatActions {
+		if string(a) == req.Action {
+			action.Action = a
+		}
+	}
+	if action.Action == "" {
+		names := make([]string, len(domain.ChatActions))
+		for i, a := range domain.ChatActions {
+			names[i] = string(a)
+		}
+		return action, fmt.Errorf("action must be one of %s", strings.Join(names, ", "))
+	}
+	if req.Duration != "" {
+		if action.Action != domain.ChatMute {
+			return action, errors.New("duration is only used by mute")
+		}
+		d, err := time.ParseDuration(req.Duration)
+		if err != nil || d <= 0 {
+			return action, errors.New("duration must be a positive duration such as 8h")
+		}
+		action.MuteFor = d
+	}
+	return action, nil
+}
+
+// ChatActionResponse is the state of a chat after an action
+type ChatActionResponse struct {
+	Action domain.ChatAction `json:"action"`
+	// Chat is the chat's state in the chat list; absent once deleted
+	Chat *domain.Chat `json:"chat,omitempty"`
+}
+
+// ChatAction godoc
+// @Summary Действие с чатом
+// @Description Выполняет действие из контекстного меню чата в списке чатов: archive/unarchive, pin/unpin, mute/unmute, mark_read/mark_unread, clear (удалить сообщения) или delete (из группы нужно сначала выйти). WhatsApp отключает уведомления на 8 часов, неделю или навсегда; выбирается ближайший срок не короче duration. Если сменить срок у заглушенного чата не удалось, прежнее отключение восстанавливается, а ошибка сообщает, в каком состоянии остался чат. Возвращает состояние чата после действия; чат не открывается, поэтому не отмечается прочитанным
+// @Tags chats
+// @Accept json
+// @Produce json
+// @Param id path string true "ID сессии"
+// @Param chatId path string true "JID, номер телефона, ссылка-приглашение или название чата"
+// @Param request body ChatActionRequest true "Действие"
+// @Success 200 {object} ChatActionResponse
+// @Failure 400 {string} string "Некорректные параметры"
+// @Failure 404 {string} string "Сессия или чат не найдены"
+// @Failure 409 {string} string "Действие недоступно или не применено WhatsApp"
+// @Security ApiKeyAuth
+// @Router /session/{id}/chats/{chatId}/actions [post]
+func (h *Handler) ChatAction(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	sessionID := vars["id"]
+
+	target, err := h.archiveTarget(vars["chatId"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var req ChatActionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	action, err := req.Validate()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	chat, err := h.sessionUseCase.ChatAction(r.Context(), tenantID(r), sessionID, target, action)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ChatActionResponse{Action: action.Action, Chat: chat})
+}
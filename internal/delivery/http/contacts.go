@@ -0,0 +1,153 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"whatsapp-parser/internal/domain"
+	"whatsapp-parser/internal/exporter"
+)
+
+// listFormat is an output format of the contact and participant lists
+type listFormat struct {
+	contentType string
+	extension   string
+}
+
+var listFormats = map[string]listFormat{
+	"json": {contentType: "application/json", extension: ".json"},
+	"csv":  {contentType: "text/csv; charset=utf-8", extension: ".csv"},
+	"vcf":  {contentType: "text/vcard; charset=utf-8", extension: ".vcf"},
+}
+
+// parseListFormat reads the format query parameter, json by default
+func parseListFormat(r *http.Request) (string, listFormat, error) {
+	name := r.URL.Query().Get("format")
+	if name == "" {
+		name = "json"
+	}
+	format, ok := listFormats[name]
+	if !ok {
+		return "", listFormat{}, fmt.Errorf("unknown format %q, expected json, csv or vcf", name)
+	}
+	return name, format, nil
+}
+
+// writeList sends a list as JSON, or as a CSV or vCard download
+func writeList(w http.ResponseWriter, name string, format listFormat, fileName string, asJSON interface{}, write func(io.Writer) error) {
+	w.Header().Set("Content-Type", format.contentType)
+	if name == "json" {
+		json.NewEncoder(w).Encode(asJSON)
+		return
+	}
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fileName+format.extension))
+	if err := write(w); err != nil {
+		log.Printf("Failed to write %s%s: %v", fileName, format.extension, err)
+	}
+}
+
+// ListContacts godoc
+// @Summary Контакты сессии
+// @Description Возвращает контакты из адресной книги аккаунта: имя, номер, бизнес-аккаунт, ссылку на фото профиля. С about=true для каждого контакта запрашивается его статус, что занимает заметное время. Ссылки на фото со временем перестают работать
+// @Tags contacts
+// @Produce json
+// @Produce text/csv
+// @Produce text/vcard
+// @Param id path string true "ID сессии"
+// @Param format query string false "Формат: json (по умолчанию), csv или vcf"
+// @Param about query bool false "Загрузить статусы (about) контактов"
+// @Success 200 {object} map[string][]domain.Contact
+// @Failure 400 {string} string "Некорректные параметры"
+// @Failure 404 {string} string "Сессия не найдена"
+// @Security ApiKeyAuth
+// @Router /session/{id}/contacts [get]
+func (h *Handler) ListContacts(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	sessionID := vars["id"]
+
+	name, format, err := parseListFormat(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var withAbout bool
+	if about := r.URL.Query().Get("about"); about != "" {
+		if withAbout, err = strconv.ParseBool(about); err != nil {
+			http.Error(w, "about must be true or false", http.StatusBadRequest)
+			return
+		}
+	}
+
+	contacts, err := h.sessionUseCase.ListContacts(r.Context(), tenantID(r), sessionID, withAbout)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if contacts == nil {
+		contacts = []domain.Contact{}
+	}
+
+	writeList(w, name, format, "contacts-"+unsafeFileChars.ReplaceAllString(sessionID, "_"),
+		map[string]interface{}{"contacts": contacts},
+		func(out io.Writer) error {
+			if name == "vcf" {
+				return exporter.WriteContactsVCard(out, contacts)
+			}
+			return exporter.WriteContactsCSV(out, contacts)
+		})
+}
+
+// ListParticipants godoc
+// @Summary Участники группы
+// @Description Открывает группу в браузере сессии и возвращает её участников: номер, имя, признак администратора и время вступления, если WhatsApp его показывает. Номера участников, скрытые WhatsApp, не возвращаются; в vcf такие участники пропускаются
+// @Tags contacts
+// @Produce json
+// @Produce text/csv
+// @Produce text/vcard
+// @Param id path string true "ID сессии"
+// @Param groupId path string true "JID, ссылка-приглашение или название группы"
+// @Param format query string false "Формат: json (по умолчанию), csv или vcf"
+// @Success 200 {object} domain.Chat
+// @Failure 400 {string} string "Некорректные параметры"
+// @Failure 403 {string} string "Аккаунт не состоит в группе"
+// @Failure 404 {string} string "Сессия или группа не найдены"
+// @Failure 409 {string} string "Несколько чатов с таким названием"
+// @Security ApiKeyAuth
+// @Router /session/{id}/groups/{groupId}/participants [get]
+func (h *Handler) ListParticipants(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	sessionID := vars["id"]
+
+	target, err := domain.ParseChatID(vars["groupId"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	name, format, err := parseListFormat(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	group, err := h.sessionUseCase.GroupInfo(r.Context(), tenantID(r), sessionID, target)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if group.Participants == nil {
+		group.Participants = []domain.Participant{}
+	}
+
+	writeList(w, name, format, "participants-"+unsafeFileChars.ReplaceAllString(group.JID, "_"), group,
+		func(out io.Writer) error {
+			if name == "vcf" {
+				return exporter.WriteParticipantsVCard(out, group.Participants)
+			}
+			return exporter.WriteParticipantsCSV(out, group.Participants)
+		})
+}
@@ -0,0 +1,310 @@
+package http
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"whatsapp-parser/internal/domain"
+	"whatsapp-parser/pkg/phone"
+)
+
+const (
+	// maxGroupTitle is the longest group subject WhatsApp accepts
+	maxGroupTitle = 100
+	// maxGroupDescription is the longest group description WhatsApp accepts
+	maxGroupDescription = 2048
+	// maxGroupIcon limits the size of an uploaded group icon
+	maxGroupIcon = 5 << 20
+	// maxGroupParticipants limits the numbers of a single participants request
+	maxGroupParticipants = 256
+)
+
+type CreateGroupRequest struct {
+	Title        string   `json:"title" example:"Клуб выпускников"`
+	Participants []string `json:"participants" example:"+79991234567,+77001234567"`
+}
+
+// Validate checks the title and normalizes every number to E.164
+func (req *CreateGroupRequest) Validate(defaultRegion string) error {
+	req.Title = strings.TrimSpace(req.Title)
+	if req.Title == "" {
+		return errors.New("title is required")
+	}
+	if len([]rune(req.Title)) > maxGroupTitle {
+		return fmt.Errorf("title must be at most %d characters", maxGroupTitle)
+	}
+	if len(req.Participants) == 0 {
+		return errors.New("participants is required")
+	}
+	return normalizeParticipants(req.Participants, defaultRegion)
+}
+
+type UpdateGroupRequest struct {
+	Title       *string `json:"title,omitempty" example:"Клуб выпускников 2024"`
+	Description *string `json:"description,omitempty"`
+	// Icon is a base64 encoded JPEG or PNG; it is cropped to a square
+	Icon string `json:"icon,omitempty"`
+	// AdminsOnly lets only admins send messages
+	AdminsOnly *bool `json:"admins_only,omitempty"`
+}
+
+// Validate checks the settings and decodes the icon
+func (req *UpdateGroupRequest) Validate() (domain.GroupUpdate, error) {
+	update := domain.GroupUpdate{Description: req.Description, AdminsOnly: req.AdminsOnly}
+	if req.Title != nil {
+		title := strings.TrimSpace(*req.Title)
+		if title == "" {
+			return update, errors.New("title must not be empty")
+		}
+		if len([]rune(title)) > maxGroupTitle {
+			return update, fmt.Errorf("title must be at most %d characters", maxGroupTitle)
+		}
+		update.Title = &title
+	}
+	if req.Description != nil && len([]rune(*req.Description)) > maxGroupDescription {
+		return update, fmt.Errorf("description must be at most %d characters", maxGroupDescription)
+	}
+	if req.Icon != "" {
+		icon, err := base64.StdEncoding.DecodeString(req.Icon)
+		if err != nil {
+			return update, errors.New("icon must be base64 encoded")
+		}
+		if len(icon) > maxGroupIcon {
+			return update, fmt.Errorf("icon must be at most %d MB", maxGroupIcon>>20)
+		}
+		if contentType := http.DetectContentType(icon); contentType != "image/jpeg" && contentType != "image/png" {
+			return update, errors.New("icon must be a JPEG or PNG image")
+		}
+		update.Icon = icon
+	}
+	if update.Title == nil && update.Description == nil && update.Icon == nil && update.AdminsOnly == nil {
+		return update, errors.New("nothing to update")
+	}
+	return update, nil
+}
+
+type ChangeParticipantsRequest struct {
+	// Action is add, remove, promote or demote
+	Action       string   `json:"action" example:"add"`
+	Participants []string `json:"participants" example:"+79991234567"`
+}
+
+// Validate checks the action and normalizes every number to E.164
+func (req *ChangeParticipantsRequest) Validate(defaultRegion string) (domain.GroupOperation, error) {
+	var action domain.GroupOperation
+	for _, a := range domain.ParticipantActions {
+		if string(a) == req.Action {
+			action = a
+		}
+	}
+	if action == "" {
+		return "", errors.New("action must be add, remove, promote or demote")
+	}
+	if len(req.Participants) == 0 {
+		return "", errors.New("participants is required")
+	}
+	return action, normalizeParticipants(req.Participants, defaultRegion)
+}
+
+// normalizeParticipants rewrites the numbers of a participant list to E.164
+func normalizeParticipants(numbers []string, defaultRegion string) error {
+	if len(numbers) > maxGroupParticipants {
+		return fmt.Errorf("at most %d participants per request", maxGroupParticipants)
+	}
+	for i, raw := range numbers {
+		number, err := phone.Parse(raw, defaultRegion)
+		if err != nil {
+			return fmt.Errorf("participants[%d]: %v", i, err)
+		}
+		numbers[i] = number.E164()
+	}
+	return nil
+}
+
+// CreateGroup godoc
+// @Summary Создать группу
+// @Description Создаёт группу с указанными участниками. Для каждого номера возвращается результат: ok, invite_required (настройки приватности разрешают только приглашение по ссылке), not_on_whatsapp и т.д. Создание группы учитывается в лимитах отправки
+// @Tags groups
+// @Accept json
+// @Produce json
+// @Param id path string true "ID сессии"
+// @Param request body CreateGroupRequest true "Название и участники"
+// @Success 201 {object} domain.GroupResult
+// @Failure 400 {string} string "Некорректные параметры"
+// @Failure 404 {string} string "Сессия не найдена"
+// @Failure 429 {string} string "Превышен лимит отправки"
+// @Security ApiKeyAuth
+// @Router /session/{id}/groups [post]
+func (h *Handler) CreateGroup(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	sessionID := vars["id"]
+
+	var req CreateGroupRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := req.Validate(h.cfg.DefaultPhoneRegion); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.sessionUseCase.CreateGroup(r.Context(), tenantID(r), sessionID, req.Title, req.Participants)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(result)
+}
+
+// UpdateGroup godoc
+// @Summary Изменить группу
+// @Description Меняет название, описание, иконку группы или включает режим, в котором писать могут только администраторы. Переданные поля меняются, остальные остаются прежними
+// @Tags groups
+// @Accept json
+// @Produce json
+// @Param id path string true "ID сессии"
+// @Param groupId path string true "JID, ссылка-приглашение или название группы"
+// @Param request body UpdateGroupRequest true "Новые настройки"
+// @Success 200 {object} domain.GroupResult
+// @Failure 400 {string} string "Некорректные параметры"
+// @Failure 403 {object} map[string]string "Аккаунт не администратор группы"
+// @Failure 404 {string} string "Сессия или группа не найдены"
+// @Failure 409 {string} string "Несколько чатов с таким названием"
+// @Security ApiKeyAuth
+// @Router /session/{id}/groups/{groupId} [patch]
+func (h *Handler) UpdateGroup(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	sessionID := vars["id"]
+
+	target, err := domain.ParseChatID(vars["groupId"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var req UpdateGroupRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxGroupIcon*2)).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	update, err := req.Validate()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.sessionUseCase.UpdateGroup(r.Context(), tenantID(r), sessionID, target, update)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// ChangeParticipants godoc
+// @Summary Изменить участников группы
+// @Description Добавляет (add) или удаляет (remove) участников, назначает (promote) или снимает (demote) администраторов. Для каждого номера возвращается результат. Добавление учитывается в лимитах отправки
+// @Tags groups
+// @Accept json
+// @Produce json
+// @Param id path string true "ID сессии"
+// @Param groupId path string true "JID, ссылка-приглашение или название группы"
+// @Param request body ChangeParticipantsRequest true "Действие и участники"
+// @Success 200 {object} domain.GroupResult
+// @Failure 400 {string} string "Некорректные параметры"
+// @Failure 403 {object} map[string]string "Аккаунт не администратор группы"
+// @Failure 404 {string} string "Сессия или группа не найдены"
+// @Failure 429 {string} string "Превышен лимит отправки"
+// @Security ApiKeyAuth
+// @Router /session/{id}/groups/{groupId}/participants [post]
+func (h *Handler) ChangeParticipants(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	sessionID := vars["id"]
+
+	target, err := domain.ParseChatID(vars["groupId"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var req ChangeParticipantsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	action, err := req.Validate(h.cfg.DefaultPhoneRegion)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.sessionUseCase.ChangeParticipants(r.Context(), tenantID(r), sessionID, target, action, req.Participants)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// GetInviteLink godoc
+// @Summary Ссылка-приглашение группы
+// @Description Возвращает текущую ссылку-приглашение группы
+// @Tags groups
+// @Produce json
+// @Param id path string true "ID сессии"
+// @Param groupId path string true "JID, ссылка-приглашение или название группы"
+// @Success 200 {object} domain.GroupResult
+// @Failure 403 {object} map[string]string "Аккаунт не администратор группы"
+// @Failure 404 {string} string "Сессия или группа не найдены"
+// @Security ApiKeyAuth
+// @Router /session/{id}/groups/{groupId}/invite [get]
+func (h *Handler) GetInviteLink(w http.ResponseWriter, r *http.Request) {
+	h.inviteLink(w, r, false)
+}
+
+// RevokeInviteLink godoc
+// @Summary Отозвать ссылку-приглашение
+// @Description Отзывает текущую ссылку-приглашение группы и возвращает новую
+// @Tags groups
+// @Produce json
+// @Param id path string true "ID сессии"
+// @Param groupId path string true "JID или название группы"
+// @Success 200 {object} domain.GroupResult
+// @Failure 403 {object} map[string]string "Аккаунт не администратор группы"
+// @Failure 404 {string} string "Сессия или группа не найдены"
+// @Security ApiKeyAuth
+// @Router /session/{id}/groups/{groupId}/invite [delete]
+func (h *Handler) RevokeInviteLink(w http.ResponseWriter, r *http.Request) {
+	h.inviteLink(w, r, true)
+}
+
+func (h *Handler) inviteLink(w http.ResponseWriter, r *http.Request, revoke bool) {
+	vars := mux.Vars(r)
+	sessionID := vars["id"]
+
+	target, err := domain.ParseChatID(vars["groupId"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.sessionUseCase.GroupInviteLink(r.Context(), tenantID(r), sessionID, target, revoke)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
@@ -1,19 +1,38 @@
 package http
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/swaggo/http-swagger"
 	_ "whatsapp-parser/docs"
-	"whatsapp-parser/internal/domain"
+	"whatsapp-parser/internal/config"
 	"whatsapp-parser/internal/delivery/http/middleware"
+	"whatsapp-parser/internal/domain"
+	"whatsapp-parser/pkg/markup"
+	"whatsapp-parser/pkg/phone"
 )
 
 // Handler структура для HTTP обработчиков
 type Handler struct {
-	sessionUseCase domain.SessionUseCase
+	sessionUseCase     domain.SessionUseCase
+	templateUseCase    domain.TemplateUseCase
+	campaignUseCase    domain.CampaignUseCase
+	scheduleUseCase    domain.ScheduleUseCase
+	suppressionUseCase domain.SuppressionUseCase
+	importUseCase      domain.ImportUseCase
+	archiveUseCase     domain.ArchiveUseCase
+	apiKeys            domain.APIKeyRepository
+	cfg                *config.Config
+	idempotent         func(http.Handler) http.Handler
 }
 
 // @title WhatsApp Parser API
@@ -22,15 +41,27 @@ type Handler struct {
 // @host localhost:8081
 // @BasePath /
 // @schemes http
-func NewHandler(sessionUseCase domain.SessionUseCase) *Handler {
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name X-API-Key
+func NewHandler(sessionUseCase domain.SessionUseCase, templateUseCase domain.TemplateUseCase, campaignUseCase domain.CampaignUseCase, scheduleUseCase domain.ScheduleUseCase, suppressionUseCase domain.SuppressionUseCase, importUseCase domain.ImportUseCase, archiveUseCase domain.ArchiveUseCase, apiKeys domain.APIKeyRepository, idempotency domain.IdempotencyRepository, cfg *config.Config) *Handler {
 	return &Handler{
-		sessionUseCase: sessionUseCase,
+		sessionUseCase:     sessionUseCase,
+		templateUseCase:    templateUseCase,
+		campaignUseCase:    campaignUseCase,
+		scheduleUseCase:    scheduleUseCase,
+		suppressionUseCase: suppressionUseCase,
+		importUseCase:      importUseCase,
+		archiveUseCase:     archiveUseCase,
+		apiKeys:            apiKeys,
+		cfg:                cfg,
+		idempotent:         middleware.Idempotency(idempotency, time.Duration(cfg.IdempotencyTTL)),
 	}
 }
 
 func (h *Handler) RegisterRoutes(r *mux.Router) {
 	// Apply CORS middleware
-	r.Use(middleware.CORS)
+	r.Use(middleware.CORS(h.cfg.CORSOrigins))
 
 	// Swagger
 	r.PathPrefix("/swagger/").Handler(httpSwagger.Handler(
@@ -41,14 +72,255 @@ func (h *Handler) RegisterRoutes(r *mux.Router) {
 	))
 
 	// API endpoints
-	r.HandleFunc("/session", h.CreateSession).Methods(http.MethodPost, http.MethodOptions)
-	r.HandleFunc("/session/{id}", h.RestoreSession).Methods(http.MethodPost, http.MethodOptions)
-	r.HandleFunc("/session/{id}/message", h.SendMessage).Methods(http.MethodPost, http.MethodOptions)
+	timeouts := h.cfg.Timeouts
+	r.Handle("/session", h.tenantRoute(domain.ScopeSessionsWrite, timeouts.CreateSession, h.CreateSession)).Methods(http.MethodPost, http.MethodOptions)
+	r.Handle("/session/{id}", h.route(domain.ScopeSessionsWrite, timeouts.RestoreSession, h.RestoreSession)).Methods(http.MethodPost, http.MethodOptions)
+	r.Handle("/session/{id}/message", h.route(domain.ScopeMessagesSend, timeouts.SendMessage, h.idempotent(http.HandlerFunc(h.SendMessage)).ServeHTTP)).Methods(http.MethodPost, http.MethodOptions)
+	r.Handle("/session/{id}/contacts", h.route(domain.ScopeContactsRead, timeouts.CheckContacts, h.ListContacts)).Methods(http.MethodGet, http.MethodOptions)
+	r.Handle("/session/{id}/groups/{groupId}/participants", h.route(domain.ScopeContactsRead, timeouts.CheckContacts, h.ListParticipants)).Methods(http.MethodGet, http.MethodOptions)
+	r.Handle("/session/{id}/messages/{msgId}/{action}", h.route(domain.ScopeMessagesSend, timeouts.SendMessage, h.MessageAction)).Methods(http.MethodPost, http.MethodOptions)
+	r.Handle("/session/{id}/contacts/check", h.route(domain.ScopeContactsRead, timeouts.CheckContacts, h.CheckContacts)).Methods(http.MethodPost, http.MethodOptions)
+	r.Handle("/session/{id}/groups", h.route(domain.ScopeGroupsWrite, timeouts.ManageGroup, h.CreateGroup)).Methods(http.MethodPost, http.MethodOptions)
+	r.Handle("/session/{id}/groups/{groupId}", h.route(domain.ScopeGroupsWrite, timeouts.ManageGroup, h.UpdateGroup)).Methods(http.MethodPatch, http.MethodOptions)
+	r.Handle("/session/{id}/groups/{groupId}/participants", h.route(domain.ScopeGroupsWrite, timeouts.ManageGroup, h.ChangeParticipants)).Methods(http.MethodPost, http.MethodOptions)
+	r.Handle("/session/{id}/groups/{groupId}/invite", h.route(domain.ScopeGroupsWrite, timeouts.ManageGroup, h.GetInviteLink)).Methods(http.MethodGet, http.MethodOptions)
+	r.Handle("/session/{id}/groups/{groupId}/invite", h.route(domain.ScopeGroupsWrite, timeouts.ManageGroup, h.RevokeInviteLink)).Methods(http.MethodDelete, http.MethodOptions)
+	r.Handle("/session/{id}/chats/{chatId}/messages", h.route(domain.ScopeChatsRead, 0, h.ListMessages)).Methods(http.MethodGet, http.MethodOptions)
+	r.Handle("/session/{id}/chats/{chatId}/actions", h.route(domain.ScopeChatsWrite, timeouts.ChatAction, h.ChatAction)).Methods(http.MethodPost, http.MethodOptions)
+	r.Handle("/session/{id}/chats/{chatId}/export", h.route(domain.ScopeChatsRead, timeouts.ExportChat, h.ExportChat)).Methods(http.MethodGet, http.MethodOptions)
+	r.Handle("/session/{id}/scheduled", h.route(domain.ScopeMessagesSend, 0, h.ScheduleMessage)).Methods(http.MethodPost, http.MethodOptions)
+	r.Handle("/session/{id}/scheduled", h.route(domain.ScopeMessagesSend, 0, h.ListScheduled)).Methods(http.MethodGet, http.MethodOptions)
+	r.Handle("/session/{id}/scheduled/{scheduleId}", h.route(domain.ScopeMessagesSend, 0, h.CancelScheduled)).Methods(http.MethodDelete, http.MethodOptions)
+
+	// Message templates
+	r.Handle("/templates", h.tenantRoute(domain.ScopeTemplatesWrite, 0, h.CreateTemplate)).Methods(http.MethodPost, http.MethodOptions)
+	r.Handle("/templates", h.route(domain.ScopeTemplatesRead, 0, h.ListTemplates)).Methods(http.MethodGet, http.MethodOptions)
+	r.Handle("/templates/{templateId}", h.route(domain.ScopeTemplatesRead, 0, h.GetTemplate)).Methods(http.MethodGet, http.MethodOptions)
+	r.Handle("/templates/{templateId}", h.tenantRoute(domain.ScopeTemplatesWrite, 0, h.UpdateTemplate)).Methods(http.MethodPut, http.MethodOptions)
+	r.Handle("/templates/{templateId}", h.tenantRoute(domain.ScopeTemplatesWrite, 0, h.DeleteTemplate)).Methods(http.MethodDelete, http.MethodOptions)
+	r.Handle("/templates/{templateId}/preview", h.route(domain.ScopeTemplatesRead, 0, h.PreviewTemplate)).Methods(http.MethodPost, http.MethodOptions)
+
+	// Bulk campaigns
+	r.Handle("/campaigns", h.route(domain.ScopeCampaignsWrite, 0, h.CreateCampaign)).Methods(http.MethodPost, http.MethodOptions)
+	r.Handle("/campaigns", h.route(domain.ScopeCampaignsRead, 0, h.ListCampaigns)).Methods(http.MethodGet, http.MethodOptions)
+	r.Handle("/campaigns/{campaignId}", h.route(domain.ScopeCampaignsRead, 0, h.GetCampaign)).Methods(http.MethodGet, http.MethodOptions)
+	r.Handle("/campaigns/{campaignId}/pause", h.route(domain.ScopeCampaignsWrite, 0, h.PauseCampaign)).Methods(http.MethodPost, http.MethodOptions)
+	r.Handle("/campaigns/{campaignId}/resume", h.route(domain.ScopeCampaignsWrite, 0, h.ResumeCampaign)).Methods(http.MethodPost, http.MethodOptions)
+	r.Handle("/campaigns/{campaignId}/cancel", h.route(domain.ScopeCampaignsWrite, 0, h.CancelCampaign)).Methods(http.MethodPost, http.MethodOptions)
+	r.Handle("/campaigns/{campaignId}/export", h.route(domain.ScopeCampaignsRead, 0, h.ExportCampaign)).Methods(http.MethodGet, http.MethodOptions)
+
+	// Suppression list
+	r.Handle("/suppressions", h.route(domain.ScopeSuppressionsRead, 0, h.ListSuppressions)).Methods(http.MethodGet, http.MethodOptions)
+	r.Handle("/suppressions", h.tenantRoute(domain.ScopeSuppressionsWrite, 0, h.AddSuppressions)).Methods(http.MethodPost, http.MethodOptions)
+	r.Handle("/suppressions/{phoneNumber}", h.tenantRoute(domain.ScopeSuppressionsWrite, 0, h.RemoveSuppression)).Methods(http.MethodDelete, http.MethodOptions)
+
+	// Full-text search and media of the message archive
+	r.Handle("/search", h.route(domain.ScopeChatsRead, 0, h.SearchMessages)).Methods(http.MethodGet, http.MethodOptions)
+	r.Handle("/media/{hash}", h.route(domain.ScopeChatsRead, 0, h.GetMedia)).Methods(http.MethodGet, http.MethodOptions)
+
+	// Imports of data exported from phones
+	r.Handle("/imports/chat-export", h.route(domain.ScopeImportsWrite, 0, h.ImportChatExport)).Methods(http.MethodPost, http.MethodOptions)
+}
+
+// route wraps a handler function with authentication, the scope check and the endpoint deadline
+func (h *Handler) route(scope domain.Scope, timeout config.Duration, fn http.HandlerFunc) http.Handler {
+	handler := middleware.Timeout(time.Duration(timeout))(fn)
+	if h.cfg.Auth.Disabled {
+		return handler
+	}
+	return middleware.Auth(h.apiKeys)(middleware.RequireScope(scope)(handler))
+}
+
+// tenantRoute is route for tenant-wide writes, which session-bound keys may not make
+func (h *Handler) tenantRoute(scope domain.Scope, timeout config.Duration, fn http.HandlerFunc) http.Handler {
+	handler := middleware.Timeout(time.Duration(timeout))(fn)
+	if h.cfg.Auth.Disabled {
+		return handler
+	}
+	return middleware.Auth(h.apiKeys)(middleware.RequireTenantScope(scope)(handler))
+}
+
+// tenantID resolves the tenant of the request from its API key
+func tenantID(r *http.Request) string {
+	if key := middleware.APIKeyFromContext(r.Context()); key != nil {
+		return key.Tenant()
+	}
+	return domain.DefaultTenantID
+}
+
+// writeError maps use case errors to HTTP status codes
+func writeError(w http.ResponseWriter, err error) {
+	var rateLimit *domain.RateLimitError
+	var groupAdmin *domain.GroupAdminError
+	switch {
+	case errors.As(err, &rateLimit):
+		retryAfter := int(math.Ceil(rateLimit.RetryAfter.Seconds()))
+		w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+		http.Error(w, err.Error(), http.StatusTooManyRequests)
+	case errors.As(err, &groupAdmin):
+		// Clients tell the refused operation apart without parsing the message
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error":     "not_group_admin",
+			"operation": string(groupAdmin.Operation),
+			"group":     groupAdmin.Group,
+			"message":   err.Error(),
+		})
+	case errors.Is(err, domain.ErrSessionNotFound), errors.Is(err, domain.ErrChatNotFound),
+		errors.Is(err, domain.ErrMessageNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, domain.ErrAmbiguousChat), errors.Is(err, domain.ErrChatActionUnavailable),
+		errors.Is(err, domain.ErrMessageActionNotAllowed):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, domain.ErrNotChatMember), errors.Is(err, domain.ErrRecipientSuppressed):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, domain.ErrShuttingDown):
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+	case errors.Is(err, domain.ErrQuotaExceeded):
+		http.Error(w, err.Error(), http.StatusTooManyRequests)
+	case errors.Is(err, domain.ErrTemplateNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, domain.ErrInvalidTemplate), errors.Is(err, domain.ErrMissingVariables):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, domain.ErrCampaignNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, domain.ErrInvalidCampaign), errors.Is(err, domain.ErrInvalidChatExport):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, domain.ErrCampaignState):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, domain.ErrScheduleNotFound), errors.Is(err, domain.ErrSuppressionNotFound),
+		errors.Is(err, domain.ErrMediaNotFound), errors.Is(err, domain.ErrNoMedia):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, domain.ErrMediaQuotaExceeded):
+		http.Error(w, err.Error(), http.StatusInsufficientStorage)
+	case errors.Is(err, domain.ErrInvalidSchedule), errors.Is(err, domain.ErrInvalidSearch):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, domain.ErrInvalidTenant):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, context.DeadlineExceeded):
+		http.Error(w, err.Error(), http.StatusGatewayTimeout)
+	case errors.Is(err, context.Canceled):
+		// The client is gone; the status is only visible in access logs
+		http.Error(w, err.Error(), statusClientClosedRequest)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
 }
 
+// statusClientClosedRequest is the non-standard status used when the client disconnects
+const statusClientClosedRequest = 499
+
 type SendMessageRequest struct {
-	PhoneNumber string `json:"phone_number" example:"1234567890"`
-	Message     string `json:"message" example:"Hello, World!"`
+	PhoneNumber string `json:"phone_number,omitempty" example:"+7 999 123-45-67"`
+	// ChatID is an alternative to PhoneNumber: a JID (…@g.us, …@c.us), an
+	// invite link (https://chat.whatsapp.com/<code> or invite:<code>) or the exact chat title
+	ChatID  string `json:"chat_id,omitempty" example:"120363012345678901@g.us"`
+	Message string `json:"message,omitempty" example:"Hello, World!"`
+	// Formatted is an alternative to Message rendered to WhatsApp markup
+	Formatted *markup.Document `json:"formatted,omitempty"`
+	// ReplyToMessageID quotes a message of the same chat
+	ReplyToMessageID string `json:"reply_to_message_id,omitempty" example:"false_120363012345678901@g.us_3EB0C767D26A1D2B5C55"`
+	// Mentions lists group members to @mention; "@<number>" in the text marks the position
+	Mentions []string `json:"mentions,omitempty" example:"+79991234567"`
+	// TemplateID is an alternative to Message: the template is rendered with Variables
+	TemplateID string                 `json:"template_id,omitempty"`
+	Variables  map[string]interface{} `json:"variables,omitempty"`
+	// Locale selects the template variant; the template default is used otherwise
+	Locale string `json:"locale,omitempty" example:"ru"`
+	// Location, Contacts and Poll are sent instead of a text; only one may be given
+	Location *LocationRequest `json:"location,omitempty"`
+	Contacts []ContactRequest `json:"contacts,omitempty"`
+	Poll     *PollRequest     `json:"poll,omitempty"`
+
+	target domain.ChatTarget
+}
+
+// Validate checks the request, resolves the chat target and renders the text;
+// phone numbers are normalized to E.164
+func (req *SendMessageRequest) Validate(defaultRegion string) error {
+	hasPayload, err := req.validatePayload(defaultRegion)
+	if err != nil {
+		return err
+	}
+	switch {
+	case req.Formatted != nil && req.Message != "":
+		return errors.New("message and formatted are mutually exclusive")
+	case req.Formatted != nil:
+		text, err := req.Formatted.Render()
+		if err != nil {
+			return fmt.Errorf("formatted: %v", err)
+		}
+		req.Message = text
+	}
+	if strings.TrimSpace(req.Message) == "" && len(req.Mentions) == 0 && !hasPayload {
+		return errors.New("message is required")
+	}
+
+	switch {
+	case req.PhoneNumber != "" && req.ChatID != "":
+		return errors.New("phone_number and chat_id are mutually exclusive")
+	case req.ChatID != "":
+		target, err := domain.ParseChatID(req.ChatID)
+		if err != nil {
+			return err
+		}
+		req.target = target
+	default:
+		number, err := phone.Parse(req.PhoneNumber, defaultRegion)
+		if err != nil {
+			return err
+		}
+		req.PhoneNumber = number.E164()
+		req.target = domain.ChatTarget{PhoneNumber: req.PhoneNumber}
+	}
+
+	for i, raw := range req.Mentions {
+		number, err := phone.Parse(raw, defaultRegion)
+		if err != nil {
+			return fmt.Errorf("mentions[%d]: %v", i, err)
+		}
+		req.Mentions[i] = number.E164()
+	}
+	return nil
+}
+
+// OutgoingMessage returns the validated request as a domain message
+func (req *SendMessageRequest) OutgoingMessage() domain.OutgoingMessage {
+	msg := domain.OutgoingMessage{
+		Target:   req.target,
+		Text:     req.Message,
+		ReplyTo:  req.ReplyToMessageID,
+		Mentions: req.Mentions,
+	}
+	req.payload(&msg)
+	return msg
+}
+
+// maxContactChecks limits the batch size of a single contacts check request
+const maxContactChecks = 100
+
+type CheckContactsRequest struct {
+	PhoneNumbers []string `json:"phone_numbers" example:"+79991234567,+77001234567"`
+}
+
+// Validate checks the batch and normalizes every number to E.164
+func (req *CheckContactsRequest) Validate(defaultRegion string) error {
+	if len(req.PhoneNumbers) == 0 {
+		return errors.New("phone_numbers is required")
+	}
+	if len(req.PhoneNumbers) > maxContactChecks {
+		return fmt.Errorf("at most %d phone numbers per request", maxContactChecks)
+	}
+
+	for i, raw := range req.PhoneNumbers {
+		number, err := phone.Parse(raw, defaultRegion)
+		if err != nil {
+			return fmt.Errorf("phone_numbers[%d]: %v", i, err)
+		}
+		req.PhoneNumbers[i] = number.E164()
+	}
+	return nil
 }
 
 // CreateSession godoc
@@ -58,11 +330,13 @@ type SendMessageRequest struct {
 // @Accept json
 // @Produce json
 // @Success 200 {object} map[string]interface{}
+// @Failure 403 {string} string "Ключ привязан к сессиям и не может менять данные всего тенанта"
+// @Security ApiKeyAuth
 // @Router /session [post]
 func (h *Handler) CreateSession(w http.ResponseWriter, r *http.Request) {
-	session, qrCode, err := h.sessionUseCase.CreateSession()
+	session, qrCode, err := h.sessionUseCase.CreateSession(r.Context(), tenantID(r))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
 
@@ -83,13 +357,14 @@ func (h *Handler) CreateSession(w http.ResponseWriter, r *http.Request) {
 // @Produce json
 // @Param id path string true "ID сессии"
 // @Success 200 {string} string "OK"
+// @Security ApiKeyAuth
 // @Router /session/{id} [post]
 func (h *Handler) RestoreSession(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	sessionID := vars["id"]
 
-	if err := h.sessionUseCase.RestoreSession(sessionID); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if err := h.sessionUseCase.RestoreSession(r.Context(), tenantID(r), sessionID); err != nil {
+		writeError(w, err)
 		return
 	}
 
@@ -98,13 +373,20 @@ func (h *Handler) RestoreSession(w http.ResponseWriter, r *http.Request) {
 
 // SendMessage godoc
 // @Summary Отправить сообщение
-// @Description Отправляет сообщение через WhatsApp используя указанную сессию
+// @Description Отправляет сообщение через WhatsApp используя указанную сессию. Получатель задается номером телефона или chat_id (JID, ссылка-приглашение, номер в международном формате или точное название чата; по названию запрещенный номер тоже не получит сообщение). Вместо текста можно отправить геопозицию (location), карточки контактов (contacts, до 10) или опрос (poll: вопрос, от 2 до 12 вариантов, multiple_answers для выбора нескольких)
 // @Tags message
 // @Accept json
 // @Produce json
 // @Param id path string true "ID сессии"
 // @Param message body SendMessageRequest true "Данные сообщения"
-// @Success 200 {string} string "OK"
+// @Param Idempotency-Key header string false "Ключ идемпотентности: повтор запроса с тем же ключом и телом вернет исходный ответ"
+// @Success 200 {object} map[string]string "ID отправленного сообщения"
+// @Failure 400 {string} string "Некорректный номер телефона, пустое сообщение, геопозиция или опрос"
+// @Failure 404 {string} string "Чат или цитируемое сообщение не найдены"
+// @Failure 403 {string} string "Получатель в списке запрета рассылки"
+// @Failure 409 {string} string "Название чата неоднозначно или ключ идемпотентности использован с другим запросом"
+// @Failure 429 {string} string "Превышен лимит отправки"
+// @Security ApiKeyAuth
 // @Router /session/{id}/message [post]
 func (h *Handler) SendMessage(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
@@ -115,11 +397,66 @@ func (h *Handler) SendMessage(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	if err := h.resolveTemplate(r, &req); err != nil {
+		if errors.Is(err, domain.ErrTemplateNotFound) {
+			writeError(w, err)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := req.Validate(h.cfg.DefaultPhoneRegion); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
-	if err := h.sessionUseCase.SendMessage(sessionID, req.PhoneNumber, req.Message); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	messageID, err := h.sessionUseCase.SendMessage(r.Context(), tenantID(r), sessionID, req.OutgoingMessage())
+	if err != nil {
+		writeError(w, err)
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
-} 
\ No newline at end of file
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message_id": messageID,
+	})
+}
+
+// CheckContacts godoc
+// @Summary Проверить наличие WhatsApp у номеров
+// @Description Для каждого номера возвращает статус registered, unregistered или unknown. Результаты кэшируются, проверки в браузере учитываются в лимитах отправки и ждут своей очереди в пределах таймаута
+// @Tags contacts
+// @Accept json
+// @Produce json
+// @Param id path string true "ID сессии"
+// @Param request body CheckContactsRequest true "Номера телефонов"
+// @Success 200 {object} map[string][]domain.ContactCheck
+// @Failure 400 {string} string "Некорректный номер телефона"
+// @Failure 429 {string} string "Превышен лимит отправки"
+// @Security ApiKeyAuth
+// @Router /session/{id}/contacts/check [post]
+func (h *Handler) CheckContacts(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	sessionID := vars["id"]
+
+	var req CheckContactsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := req.Validate(h.cfg.DefaultPhoneRegion); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	results, err := h.sessionUseCase.CheckContacts(r.Context(), tenantID(r), sessionID, req.PhoneNumbers)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"results": results,
+	})
+}
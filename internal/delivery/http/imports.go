@@ -0,0 +1,110 @@
+package http
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"whatsapp-parser/internal/domain"
+	"whatsapp-parser/internal/exporter"
+)
+
+// maxImportUpload limits the size of an uploaded chat export, media included
+const maxImportUpload = 1 << 30
+
+// ImportChatExport godoc
+// @Summary Разобрать экспорт чата
+// @Description Принимает файл «Экспорт чата» с телефона (.txt или .zip с медиафайлами) и возвращает сообщения. Формат даты определяется автоматически, его можно задать явно. С параметром format чат выгружается файлом в формате jsonl, csv, html или sqlite, как при выгрузке чата сессии
+// @Tags imports
+// @Accept multipart/form-data
+// @Produce json,octet-stream
+// @Param file formData file true "Файл экспорта (.txt или .zip)"
+// @Param date_order formData string false "Порядок даты: dmy, mdy или ymd"
+// @Param time_zone formData string false "Часовой пояс телефона, например Europe/Moscow"
+// @Param self_name formData string false "Имя владельца телефона в чате, для отметки собственных сообщений"
+// @Param format formData string false "Выгрузить чат файлом: jsonl, csv, html или sqlite"
+// @Success 200 {object} domain.ChatImport
+// @Failure 400 {string} string "Файл не является экспортом чата WhatsApp"
+// @Security ApiKeyAuth
+// @Router /imports/chat-export [post]
+func (h *Handler) ImportChatExport(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportUpload)
+	if err := r.ParseMultipartForm(32 << 20); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	file, fileHeader, err := r.FormFile("file")
+	if err != nil {
+		http.Error(w, "file is required", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	var format *exporter.Format
+	if name := r.FormValue("format"); name != "" {
+		if format, err = exporter.Lookup(name); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	opts := domain.ChatExportOptions{
+		DateOrder: r.FormValue("date_order"),
+		TimeZone:  r.FormValue("time_zone"),
+		SelfName:  r.FormValue("self_name"),
+	}
+	result, err := h.importUseCase.ImportChatExport(r.Context(), tenantID(r), fileHeader.Filename, file, fileHeader.Size, opts)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if format != nil {
+		exportImport(w, format, result)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// exportImport writes an imported chat as a download in format, the same
+// way ExportChat writes a chat read from a session
+func exportImport(w http.ResponseWriter, format *exporter.Format, chat *domain.ChatImport) {
+	name := chat.Title
+	if name == "" {
+		name = "import"
+	}
+	out := &exportWriter{
+		w:           w,
+		contentType: format.ContentType,
+		fileName:    "chat-" + unsafeFileChars.ReplaceAllString(name, "_") + format.Extension,
+	}
+	export, err := format.New(out)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	err = export.WriteChat(chat.Chat())
+	for _, msg := range chat.Messages {
+		if err != nil {
+			break
+		}
+		err = export.WriteMessage(msg)
+	}
+	if err == nil {
+		err = export.Close()
+	} else {
+		export.Discard()
+	}
+	if err == nil {
+		return
+	}
+	if !out.started {
+		writeError(w, err)
+		return
+	}
+	log.Printf("Failed to export imported chat %q: %v", chat.Title, err)
+}
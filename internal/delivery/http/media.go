@@ -0,0 +1,44 @@
+package http
+
+import (
+	"mime"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"whatsapp-parser/internal/delivery/http/middleware"
+)
+
+// GetMedia godoc
+// @Summary Скачать медиафайл
+// @Description Отдаёт файл из хранилища медиа по SHA-256. Файл доступен, если на него ссылается сообщение из архива сессии, к которой у ключа есть доступ. Поддерживаются запросы Range
+// @Tags chats
+// @Produce octet-stream
+// @Param hash path string true "SHA-256 файла (media_hash сообщения)"
+// @Success 200 {file} file "Медиафайл"
+// @Failure 404 {string} string "Файл не найден"
+// @Security ApiKeyAuth
+// @Router /media/{hash} [get]
+func (h *Handler) GetMedia(w http.ResponseWriter, r *http.Request) {
+	var sessionIDs []string
+	if key := middleware.APIKeyFromContext(r.Context()); key != nil {
+		sessionIDs = key.SessionIDs
+	}
+
+	media, file, err := h.archiveUseCase.GetMedia(r.Context(), tenantID(r), sessionIDs, mux.Vars(r)["hash"])
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	defer file.Close()
+
+	if media.MimeType != "" {
+		w.Header().Set("Content-Type", media.MimeType)
+	}
+	if media.FileName != "" {
+		w.Header().Set("Content-Disposition", mime.FormatMediaType("inline", map[string]string{"filename": media.FileName}))
+	}
+	// Content never changes for a hash
+	w.Header().Set("ETag", `"`+media.Hash+`"`)
+	w.Header().Set("Cache-Control", "private, max-age=31536000, immutable")
+	http.ServeContent(w, r, "", media.CreatedAt, file)
+}
@@ -0,0 +1,137 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"whatsapp-parser/internal/domain"
+	"whatsapp-parser/pkg/markup"
+)
+
+// maxReactionLength bounds a reaction; one emoji may take several code points
+const maxReactionLength = 16
+
+type MessageActionRequest struct {
+	// Emoji is the reaction of react; empty removes the account's reaction
+	Emoji string `json:"emoji,omitempty" example:"👍"`
+	// Text is the new text of edit
+	Text string `json:"text,omitempty" example:"Исправленный текст"`
+	// Formatted is an alternative to Text rendered to WhatsApp markup
+	Formatted *markup.Document `json:"formatted,omitempty"`
+	// To lists the chats of forward: "+" phone numbers, JIDs or exact chat titles
+	To []string `json:"to,omitempty" example:"+79991234567,120363012345678901@g.us"`
+}
+
+// MessageActionResponse confirms a message action
+type MessageActionResponse struct {
+	MessageID string               `json:"message_id"`
+	Action    domain.MessageAction `json:"action"`
+}
+
+// messageAction checks the request body of an action and converts it
+func (h *Handler) messageAction(action string, req *MessageActionRequest) (domain.MessageActionRequest, error) {
+	out := domain.MessageActionRequest{}
+	for _, a := range domain.MessageActions {
+		if string(a) == action {
+			out.Action = a
+		}
+	}
+	switch out.Action {
+	case "":
+		names := make([]string, len(domain.MessageActions))
+		for i, a := range domain.MessageActions {
+			names[i] = string(a)
+		}
+		return out, fmt.Errorf("action must be one of %s", strings.Join(names, ", "))
+
+	case domain.MessageReact:
+		if len([]rune(req.Emoji)) > maxReactionLength {
+			return out, errors.New("emoji must be a single emoji")
+		}
+		out.Emoji = req.Emoji
+
+	case domain.MessageEdit:
+		switch {
+		case req.Formatted != nil && req.Text != "":
+			return out, errors.New("text and formatted are mutually exclusive")
+		case req.Formatted != nil:
+			text, err := req.Formatted.Render()
+			if err != nil {
+				return out, fmt.Errorf("formatted: %v", err)
+			}
+			req.Text = text
+		}
+		if strings.TrimSpace(req.Text) == "" {
+			return out, errors.New("text is required")
+		}
+		out.Text = req.Text
+
+	case domain.MessageForward:
+		if len(req.To) == 0 || len(req.To) > domain.MaxForwards {
+			return out, fmt.Errorf("to must list 1 to %d chats", domain.MaxForwards)
+		}
+		for i, chatID := range req.To {
+			target, err := h.archiveTarget(chatID)
+			if err != nil {
+				return out, fmt.Errorf("to[%d]: %v", i, err)
+			}
+			if target.InviteCode != "" {
+				return out, fmt.Errorf("to[%d]: messages cannot be forwarded to invite links", i)
+			}
+			out.Targets = append(out.Targets, target)
+		}
+	}
+	return out, nil
+}
+
+// MessageAction godoc
+// @Summary Действие с сообщением
+// @Description Выполняет действие с сообщением по его ID из ответа на отправку или из истории: react (реакция emoji, пустая строка снимает реакцию), edit (новый text или formatted; только свои текстовые сообщения в течение 15 минут), delete (удалить у всех; свои сообщения в течение 60 часов или любые для администратора группы), forward (переслать в чаты из to, не больше 5, по одному с паузами между отправками; учитывается в лимитах отправки), star, unstar
+// @Tags messages
+// @Accept json
+// @Produce json
+// @Param id path string true "ID сессии"
+// @Param msgId path string true "ID сообщения"
+// @Param action path string true "react, edit, delete, forward, star или unstar"
+// @Param request body MessageActionRequest false "Параметры действия"
+// @Success 200 {object} MessageActionResponse
+// @Failure 400 {string} string "Некорректные параметры"
+// @Failure 403 {string} string "Получатель в списке отписавшихся"
+// @Failure 404 {string} string "Сессия, чат или сообщение не найдены"
+// @Failure 409 {string} string "WhatsApp не разрешает это действие"
+// @Failure 429 {string} string "Превышен лимит отправки"
+// @Security ApiKeyAuth
+// @Router /session/{id}/messages/{msgId}/{action} [post]
+func (h *Handler) MessageAction(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	sessionID := vars["id"]
+	messageID := vars["msgId"]
+
+	if domain.MessageChatJID(messageID) == "" {
+		http.Error(w, fmt.Sprintf("malformed message ID %q", messageID), http.StatusBadRequest)
+		return
+	}
+	var req MessageActionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	action, err := h.messageAction(vars["action"], &req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.sessionUseCase.MessageAction(r.Context(), tenantID(r), sessionID, messageID, action); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(MessageActionResponse{MessageID: messageID, Action: action.Action})
+}
@@ -0,0 +1,93 @@
+package middleware
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"whatsapp-parser/internal/domain"
+)
+
+type apiKeyContextKey struct{}
+
+// APIKeyFromContext returns the key that authenticated the request, if any
+func APIKeyFromContext(ctx context.Context) *domain.APIKey {
+	key, _ := ctx.Value(apiKeyContextKey{}).(*domain.APIKey)
+	return key
+}
+
+// Auth authenticates requests by the X-API-Key header or an
+// "Authorization: Bearer <key>" header and stores the key in the request context
+func Auth(keys domain.APIKeyRepository) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			raw := r.Header.Get("X-API-Key")
+			if raw == "" {
+				if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+					raw = strings.TrimSpace(strings.TrimPrefix(auth, "Bearer "))
+				}
+			}
+			if raw == "" {
+				http.Error(w, "missing API key", http.StatusUnauthorized)
+				return
+			}
+
+			key, err := keys.GetByHash(domain.HashAPIKey(raw))
+			if err != nil {
+				log.Printf("Failed to look up API key: %v", err)
+				http.Error(w, "failed to verify API key", http.StatusInternalServerError)
+				return
+			}
+			if key == nil {
+				http.Error(w, "invalid API key", http.StatusUnauthorized)
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), apiKeyContextKey{}, key)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// RequireScope rejects requests whose API key lacks the scope or, for routes
+// with an {id} variable, is not bound to that session. Routes without {id}
+// that change data of the whole tenant (creating sessions, templates, the
+// suppression list) use RequireTenantScope instead, since the session binding
+// cannot be checked there.
+func RequireScope(scope domain.Scope) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := APIKeyFromContext(r.Context())
+			if key == nil {
+				http.Error(w, "missing API key", http.StatusUnauthorized)
+				return
+			}
+			if !key.HasScope(scope) {
+				http.Error(w, "API key lacks scope "+string(scope), http.StatusForbidden)
+				return
+			}
+			if sessionID, ok := mux.Vars(r)["id"]; ok && !key.AllowsSession(sessionID) {
+				http.Error(w, "API key is not allowed to access this session", http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireTenantScope is RequireScope for tenant-wide writes: a key bound to
+// sessions is refused even if it has the scope, so it cannot act beyond them
+func RequireTenantScope(scope domain.Scope) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return RequireScope(scope)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if key := APIKeyFromContext(r.Context()); len(key.SessionIDs) > 0 {
+				http.Error(w, "API key is bound to sessions and cannot change tenant-wide data", http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		}))
+	}
+}
@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"whatsapp-parser/internal/domain"
+)
+
+func TestRequireScope(t *testing.T) {
+	unbound := &domain.APIKey{Scopes: []domain.Scope{domain.ScopeSessionsWrite}}
+	bound := &domain.APIKey{Scopes: []domain.Scope{domain.ScopeSessionsWrite}, SessionIDs: []string{"s1"}}
+	noScope := &domain.APIKey{Scopes: []domain.Scope{domain.ScopeChatsRead}}
+
+	tests := []struct {
+		name   string
+		tenant bool
+		key    *domain.APIKey
+		path   string
+		want   int
+	}{
+		{"unbound key on a session", false, unbound, "/session/s2", http.StatusOK},
+		{"bound key on its session", false, bound, "/session/s1", http.StatusOK},
+		{"bound key on another session", false, bound, "/session/s2", http.StatusForbidden},
+		{"missing scope", false, noScope, "/session/s1", http.StatusForbidden},
+		{"missing key", false, nil, "/session/s1", http.StatusUnauthorized},
+		{"unbound key on a tenant-wide write", true, unbound, "/session", http.StatusOK},
+		{"bound key on a tenant-wide write", true, bound, "/session", http.StatusForbidden},
+		{"missing scope on a tenant-wide write", true, noScope, "/session", http.StatusForbidden},
+	}
+
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require := RequireScope
+			if tt.tenant {
+				require = RequireTenantScope
+			}
+			router := mux.NewRouter()
+			router.Handle("/session", require(domain.ScopeSessionsWrite)(ok))
+			router.Handle("/session/{id}", require(domain.ScopeSessionsWrite)(ok))
+
+			r := httptest.NewRequest(http.MethodPost, tt.path, nil)
+			if tt.key != nil {
+				r = r.WithContext(context.WithValue(r.Context(), apiKeyContextKey{}, tt.key))
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, r)
+			if w.Code != tt.want {
+				t.Errorf("POST %s = %d, want %d", tt.path, w.Code, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,169 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"whatsapp-parser/internal/domain"
+)
+
+const (
+	// IdempotencyKeyHeader carries the client-chosen key of a request
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// idempotentReplayedHeader marks responses served from a stored record
+	idempotentReplayedHeader = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLength = 255
+	maxIdempotentBody       = 1 << 20
+)
+
+// responseRecorder passes a response through while keeping a copy of it
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (rec *responseRecorder) WriteHeader(status int) {
+	if rec.status == 0 {
+		rec.status = status
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *responseRecorder) Write(b []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	rec.body.Write(b)
+	return rec.ResponseWriter.Write(b)
+}
+
+// replayable reports whether a response is final for its request. Rate
+// limits, cancelled requests and server errors are not stored, so a retry
+// with the same key runs again.
+func replayable(status int) bool {
+	switch {
+	case status == http.StatusTooManyRequests, status == 499:
+		return false
+	case status >= 500:
+		return false
+	}
+	return true
+}
+
+// Idempotency returns a middleware that stores the response of requests with
+// an Idempotency-Key header for ttl. A retry with the same key and body gets
+// the stored response; a different body, or a retry while the first request
+// is still running, gets 409 Conflict. Keys are scoped to the tenant of the API key.
+func Idempotency(records domain.IdempotencyRepository, ttl time.Duration) func(http.Handler) http.Handler {
+	var mu sync.Mutex
+	running := make(map[string]string) // Request hash by tenant and key
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(IdempotencyKeyHeader)
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(key) > maxIdempotencyKeyLength {
+				http.Error(w, "Idempotency-Key is too long", http.StatusBadRequest)
+				return
+			}
+
+			body, err := io.ReadAll(io.LimitReader(r.Body, maxIdempotentBody+1))
+			if err != nil {
+				http.Error(w, "failed to read request body", http.StatusBadRequest)
+				return
+			}
+			if len(body) > maxIdempotentBody {
+				http.Error(w, "request body is too large", http.StatusRequestEntityTooLarge)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			sum := sha256.New()
+			sum.Write([]byte(r.Method + " " + r.URL.Path + "\n"))
+			sum.Write(body)
+			hash := hex.EncodeToString(sum.Sum(nil))
+
+			tenantID := domain.DefaultTenantID
+			if apiKey := APIKeyFromContext(r.Context()); apiKey != nil {
+				tenantID = apiKey.Tenant()
+			}
+			runningKey := tenantID + "\x00" + key
+
+			mu.Lock()
+			if runningHash, ok := running[runningKey]; ok {
+				mu.Unlock()
+				if runningHash != hash {
+					http.Error(w, "Idempotency-Key was already used with a different request", http.StatusConflict)
+					return
+				}
+				http.Error(w, "a request with this Idempotency-Key is still in progress", http.StatusConflict)
+				return
+			}
+			record, err := records.Get(tenantID, key)
+			if err != nil {
+				mu.Unlock()
+				log.Printf("Failed to look up idempotency key: %v", err)
+				http.Error(w, "failed to look up Idempotency-Key", http.StatusInternalServerError)
+				return
+			}
+			if record == nil {
+				running[runningKey] = hash
+			}
+			mu.Unlock()
+
+			if record != nil {
+				if record.RequestHash != hash {
+					http.Error(w, "Idempotency-Key was already used with a different request", http.StatusConflict)
+					return
+				}
+				if record.ContentType != "" {
+					w.Header().Set("Content-Type", record.ContentType)
+				}
+				w.Header().Set(idempotentReplayedHeader, "true")
+				w.WriteHeader(record.StatusCode)
+				w.Write(record.Body)
+				return
+			}
+
+			defer func() {
+				mu.Lock()
+				delete(running, runningKey)
+				mu.Unlock()
+			}()
+
+			rec := &responseRecorder{ResponseWriter: w}
+			next.ServeHTTP(rec, r)
+			if rec.status == 0 {
+				rec.status = http.StatusOK
+			}
+			if !replayable(rec.status) {
+				return
+			}
+
+			now := time.Now()
+			record = &domain.IdempotencyRecord{
+				Key:         key,
+				RequestHash: hash,
+				StatusCode:  rec.status,
+				ContentType: rec.Header().Get("Content-Type"),
+				Body:        rec.body.Bytes(),
+				CreatedAt:   now,
+				ExpiresAt:   now.Add(ttl),
+			}
+			if err := records.Save(tenantID, record); err != nil {
+				log.Printf("Warning: failed to store idempotency key: %v", err)
+			}
+		})
+	}
+}
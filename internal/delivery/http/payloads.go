@@ -0,0 +1,160 @@
+package http
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"whatsapp-parser/internal/domain"
+	"whatsapp-parser/internal/exporter"
+	"whatsapp-parser/pkg/phone"
+)
+
+const (
+	// maxSharedContacts limits the contact cards of one message
+	maxSharedContacts = 10
+	// maxPollQuestion is the longest poll question WhatsApp accepts
+	maxPollQuestion = 255
+	// maxPollOption is the longest poll option WhatsApp accepts
+	maxPollOption = 100
+	// maxPollOptions is the number of options WhatsApp allows in a poll
+	maxPollOptions = 12
+)
+
+type LocationRequest struct {
+	Latitude  float64 `json:"latitude" example:"55.753930"`
+	Longitude float64 `json:"longitude" example:"37.620795"`
+	Name      string  `json:"name,omitempty" example:"Красная площадь"`
+	Address   string  `json:"address,omitempty" example:"Москва, Россия"`
+	// URL is shown by WhatsApp under the pin
+	URL string `json:"url,omitempty"`
+}
+
+type ContactRequest struct {
+	Name        string `json:"name" example:"Иван Петров"`
+	PhoneNumber string `json:"phone_number" example:"+79991234567"`
+}
+
+type PollRequest struct {
+	Question string   `json:"question" example:"Когда встречаемся?"`
+	Options  []string `json:"options" example:"Суббота,Воскресенье"`
+	// MultipleAnswers lets voters pick several options
+	MultipleAnswers bool `json:"multiple_answers,omitempty"`
+}
+
+// validatePayload checks the location, contacts or poll of a send request and
+// reports whether one is set. They replace the text, so they cannot be
+// combined with it or with what only applies to text.
+func (req *SendMessageRequest) validatePayload(defaultRegion string) (bool, error) {
+	n := 0
+	for _, set := range []bool{req.Location != nil, req.Contacts != nil, req.Poll != nil} {
+		if set {
+			n++
+		}
+	}
+	switch {
+	case n == 0:
+		return false, nil
+	case n > 1:
+		return true, errors.New("location, contacts and poll are mutually exclusive")
+	case req.Message != "" || req.Formatted != nil || req.TemplateID != "" || len(req.Mentions) > 0 || req.ReplyToMessageID != "":
+		return true, errors.New("location, contacts and poll cannot be combined with message, formatted, template_id, mentions or reply_to_message_id")
+	}
+
+	switch {
+	case req.Location != nil:
+		return true, req.Location.validate()
+	case req.Contacts != nil:
+		if len(req.Contacts) == 0 || len(req.Contacts) > maxSharedContacts {
+			return true, fmt.Errorf("contacts must list 1 to %d contacts", maxSharedContacts)
+		}
+		for i := range req.Contacts {
+			if err := req.Contacts[i].validate(defaultRegion); err != nil {
+				return true, fmt.Errorf("contacts[%d]: %v", i, err)
+			}
+		}
+		return true, nil
+	default:
+		return true, req.Poll.validate()
+	}
+}
+
+func (l *LocationRequest) validate() error {
+	if l.Latitude < -90 || l.Latitude > 90 {
+		return errors.New("location: latitude must be between -90 and 90")
+	}
+	if l.Longitude < -180 || l.Longitude > 180 {
+		return errors.New("location: longitude must be between -180 and 180")
+	}
+	l.Name = strings.TrimSpace(l.Name)
+	l.Address = strings.TrimSpace(l.Address)
+	if l.URL != "" {
+		if u, err := url.Parse(l.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return errors.New("location: url must be an http or https URL")
+		}
+	}
+	return nil
+}
+
+func (c *ContactRequest) validate(defaultRegion string) error {
+	c.Name = strings.TrimSpace(c.Name)
+	number, err := phone.Parse(c.PhoneNumber, defaultRegion)
+	if err != nil {
+		return err
+	}
+	c.PhoneNumber = number.E164()
+	if c.Name == "" {
+		c.Name = c.PhoneNumber
+	}
+	return nil
+}
+
+func (p *PollRequest) validate() error {
+	p.Question = strings.TrimSpace(p.Question)
+	if p.Question == "" {
+		return errors.New("poll: question is required")
+	}
+	if len([]rune(p.Question)) > maxPollQuestion {
+		return fmt.Errorf("poll: question must be at most %d characters", maxPollQuestion)
+	}
+	if len(p.Options) < 2 || len(p.Options) > maxPollOptions {
+		return fmt.Errorf("poll: options must list 2 to %d answers", maxPollOptions)
+	}
+	seen := make(map[string]bool, len(p.Options))
+	for i, option := range p.Options {
+		option = strings.TrimSpace(option)
+		switch {
+		case option == "":
+			return fmt.Errorf("poll: options[%d] is empty", i)
+		case len([]rune(option)) > maxPollOption:
+			return fmt.Errorf("poll: options[%d] must be at most %d characters", i, maxPollOption)
+		case seen[option]:
+			return fmt.Errorf("poll: options[%d] repeats %q", i, option)
+		}
+		seen[option] = true
+		p.Options[i] = option
+	}
+	return nil
+}
+
+// payload converts the validated location, contacts or poll into the fields of a domain message
+func (req *SendMessageRequest) payload(msg *domain.OutgoingMessage) {
+	if l := req.Location; l != nil {
+		msg.Location = &domain.Location{Latitude: l.Latitude, Longitude: l.Longitude, Name: l.Name, Address: l.Address, URL: l.URL}
+	}
+	for _, c := range req.Contacts {
+		var card bytes.Buffer
+		// Writing to a buffer cannot fail
+		exporter.WriteContactsVCard(&card, []domain.Contact{{Name: c.Name, PhoneNumber: c.PhoneNumber}})
+		msg.Contacts = append(msg.Contacts, domain.SharedContact{Name: c.Name, PhoneNumber: c.PhoneNumber, VCard: card.String()})
+	}
+	if p := req.Poll; p != nil {
+		poll := &domain.Poll{Question: p.Question, MultipleAnswers: p.MultipleAnswers}
+		for _, option := range p.Options {
+			poll.Options = append(poll.Options, domain.PollOption{Name: option})
+		}
+		msg.Poll = poll
+	}
+}
@@ -0,0 +1,117 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"whatsapp-parser/internal/domain"
+)
+
+// ScheduleMessageRequest is a send request with the time it should go out:
+// either SendAt or a Cron expression evaluated in TimeZone
+type ScheduleMessageRequest struct {
+	SendMessageRequest
+	SendAt *time.Time `json:"send_at,omitempty" example:"2025-01-01T09:00:00+03:00"`
+	// Cron has five fields: minute hour day-of-month month day-of-week
+	Cron     string `json:"cron,omitempty" example:"0 9 * * 1-5"`
+	TimeZone string `json:"time_zone,omitempty" example:"Europe/Moscow"`
+	// CatchUp is "skip", "once" or "all"; the configured default applies if empty
+	CatchUp domain.CatchUpPolicy `json:"catch_up,omitempty" example:"once"`
+}
+
+// ScheduleMessage godoc
+// @Summary Запланировать сообщение
+// @Description Отправляет сообщение в указанное время (send_at) или по cron-выражению в заданном часовом поясе. Пропущенные во время простоя запуски обрабатываются по политике catch_up
+// @Tags scheduled
+// @Accept json
+// @Produce json
+// @Param id path string true "ID сессии"
+// @Param message body ScheduleMessageRequest true "Сообщение и расписание"
+// @Success 201 {object} domain.ScheduledMessage
+// @Failure 400 {string} string "Некорректное сообщение или расписание"
+// @Failure 404 {string} string "Сессия не найдена"
+// @Security ApiKeyAuth
+// @Router /session/{id}/scheduled [post]
+func (h *Handler) ScheduleMessage(w http.ResponseWriter, r *http.Request) {
+	var req ScheduleMessageRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := h.resolveTemplate(r, &req.SendMessageRequest); err != nil {
+		if errors.Is(err, domain.ErrTemplateNotFound) {
+			writeError(w, err)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := req.Validate(h.cfg.DefaultPhoneRegion); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	schedule := &domain.ScheduledMessage{
+		SessionID: mux.Vars(r)["id"],
+		Message:   req.OutgoingMessage(),
+		SendAt:    req.SendAt,
+		Cron:      req.Cron,
+		TimeZone:  req.TimeZone,
+		CatchUp:   req.CatchUp,
+	}
+	if err := h.scheduleUseCase.CreateSchedule(r.Context(), tenantID(r), schedule); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(schedule)
+}
+
+// ListScheduled godoc
+// @Summary Список запланированных сообщений
+// @Tags scheduled
+// @Produce json
+// @Param id path string true "ID сессии"
+// @Success 200 {array} domain.ScheduledMessage
+// @Security ApiKeyAuth
+// @Router /session/{id}/scheduled [get]
+func (h *Handler) ListScheduled(w http.ResponseWriter, r *http.Request) {
+	schedules, err := h.scheduleUseCase.ListSchedules(r.Context(), tenantID(r), mux.Vars(r)["id"])
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if schedules == nil {
+		schedules = []*domain.ScheduledMessage{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(schedules)
+}
+
+// CancelScheduled godoc
+// @Summary Отменить запланированное сообщение
+// @Tags scheduled
+// @Produce json
+// @Param id path string true "ID сессии"
+// @Param scheduleId path string true "ID расписания"
+// @Success 200 {object} domain.ScheduledMessage
+// @Failure 404 {string} string "Расписание не найдено"
+// @Security ApiKeyAuth
+// @Router /session/{id}/scheduled/{scheduleId} [delete]
+func (h *Handler) CancelScheduled(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	schedule, err := h.scheduleUseCase.CancelSchedule(r.Context(), tenantID(r), vars["id"], vars["scheduleId"])
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(schedule)
+}
@@ -0,0 +1,95 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"whatsapp-parser/internal/delivery/http/middleware"
+	"whatsapp-parser/internal/domain"
+)
+
+// SearchMessages godoc
+// @Summary Поиск по архиву сообщений
+// @Description Полнотекстовый поиск по сохранённым сообщениям арендатора, начиная с новых. Все слова и фразы в кавычках должны встречаться в сообщении, * в конце слова ищет по префиксу; регистр и ё/е не различаются. Ключ, привязанный к сессиям, ищет только в них
+// @Tags chats
+// @Produce json
+// @Param q query string true "Запрос, например \"заказ 12345\" или отправ*"
+// @Param session query string false "ID сессии"
+// @Param chat query string false "JID чата или номер телефона"
+// @Param from query string false "Сообщения не раньше этого времени (RFC 3339)"
+// @Param to query string false "Сообщения раньше этого времени (RFC 3339)"
+// @Param limit query int false "Размер страницы (по умолчанию 20, не больше 100)"
+// @Param offset query int false "Сколько результатов пропустить"
+// @Success 200 {object} map[string][]domain.SearchHit
+// @Failure 400 {string} string "Некорректный запрос"
+// @Failure 403 {string} string "Ключ не имеет доступа к сессии"
+// @Security ApiKeyAuth
+// @Router /search [get]
+func (h *Handler) SearchMessages(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	q := domain.SearchQuery{Text: params.Get("q")}
+
+	// Keys bound to sessions only see messages of those sessions
+	key := middleware.APIKeyFromContext(r.Context())
+	if session := params.Get("session"); session != "" {
+		if key != nil && !key.AllowsSession(session) {
+			http.Error(w, fmt.Sprintf("API key is not allowed to access session %s", session), http.StatusForbidden)
+			return
+		}
+		q.SessionIDs = []string{session}
+	} else if key != nil {
+		q.SessionIDs = key.SessionIDs
+	}
+
+	if chat := params.Get("chat"); chat != "" {
+		target, err := h.archiveTarget(chat)
+		switch {
+		case err == nil && target.JID != "":
+			q.ChatJID = target.JID
+		case err == nil && target.PhoneNumber != "":
+			q.ChatJID = strings.TrimPrefix(target.PhoneNumber, "+") + "@c.us"
+		default:
+			http.Error(w, "chat must be a chat JID or a phone number", http.StatusBadRequest)
+			return
+		}
+	}
+
+	for name, dst := range map[string]*time.Time{"from": &q.From, "to": &q.To} {
+		if value := params.Get(name); value != "" {
+			t, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				http.Error(w, name+" must be an RFC 3339 time", http.StatusBadRequest)
+				return
+			}
+			*dst = t
+		}
+	}
+	for name, dst := range map[string]*int{"limit": &q.Limit, "offset": &q.Offset} {
+		if value := params.Get(name); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				http.Error(w, name+" must be a non-negative integer", http.StatusBadRequest)
+				return
+			}
+			*dst = n
+		}
+	}
+
+	hits, err := h.archiveUseCase.Search(r.Context(), tenantID(r), q)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if hits == nil {
+		hits = []domain.SearchHit{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"results": hits,
+	})
+}
@@ -0,0 +1,112 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"whatsapp-parser/pkg/phone"
+)
+
+// maxSuppressionsPerRequest limits the numbers added by one request
+const maxSuppressionsPerRequest = 1000
+
+type AddSuppressionsRequest struct {
+	PhoneNumbers []string `json:"phone_numbers" example:"+79991234567"`
+	Note         string   `json:"note,omitempty" example:"asked by phone"`
+}
+
+// Validate normalizes the numbers to E.164
+func (req *AddSuppressionsRequest) Validate(defaultRegion string) error {
+	if len(req.PhoneNumbers) == 0 {
+		return errors.New("phone_numbers is required")
+	}
+	if len(req.PhoneNumbers) > maxSuppressionsPerRequest {
+		return fmt.Errorf("at most %d phone numbers per request", maxSuppressionsPerRequest)
+	}
+	for i, raw := range req.PhoneNumbers {
+		number, err := phone.Parse(raw, defaultRegion)
+		if err != nil {
+			return fmt.Errorf("phone_numbers[%d]: %v", i, err)
+		}
+		req.PhoneNumbers[i] = number.E164()
+	}
+	return nil
+}
+
+// ListSuppressions godoc
+// @Summary Список запрета рассылки
+// @Description Номера, на которые не отправляются сообщения: ответившие стоп-словом или добавленные через API
+// @Tags suppressions
+// @Produce json
+// @Success 200 {array} domain.Suppression
+// @Security ApiKeyAuth
+// @Router /suppressions [get]
+func (h *Handler) ListSuppressions(w http.ResponseWriter, r *http.Request) {
+	suppressions, err := h.suppressionUseCase.ListSuppressions(r.Context(), tenantID(r))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(suppressions)
+}
+
+// AddSuppressions godoc
+// @Summary Добавить номера в запрет рассылки
+// @Tags suppressions
+// @Accept json
+// @Produce json
+// @Param request body AddSuppressionsRequest true "Номера телефонов"
+// @Success 200 {array} domain.Suppression
+// @Failure 400 {string} string "Некорректный номер телефона"
+// @Failure 403 {string} string "Ключ привязан к сессиям и не может менять данные всего тенанта"
+// @Security ApiKeyAuth
+// @Router /suppressions [post]
+func (h *Handler) AddSuppressions(w http.ResponseWriter, r *http.Request) {
+	var req AddSuppressionsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := req.Validate(h.cfg.DefaultPhoneRegion); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	suppressions, err := h.suppressionUseCase.AddSuppressions(r.Context(), tenantID(r), req.PhoneNumbers, req.Note)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(suppressions)
+}
+
+// RemoveSuppression godoc
+// @Summary Убрать номер из запрета рассылки
+// @Tags suppressions
+// @Param phoneNumber path string true "Номер телефона"
+// @Success 204
+// @Failure 404 {string} string "Номер не в списке"
+// @Failure 403 {string} string "Ключ привязан к сессиям и не может менять данные всего тенанта"
+// @Security ApiKeyAuth
+// @Router /suppressions/{phoneNumber} [delete]
+func (h *Handler) RemoveSuppression(w http.ResponseWriter, r *http.Request) {
+	number, err := phone.Parse(mux.Vars(r)["phoneNumber"], h.cfg.DefaultPhoneRegion)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.suppressionUseCase.RemoveSuppression(r.Context(), tenantID(r), number.E164()); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
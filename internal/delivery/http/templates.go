@@ -0,0 +1,201 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"whatsapp-parser/internal/domain"
+)
+
+type TemplateRequest struct {
+	Name          string            `json:"name" example:"order_shipped"`
+	DefaultLocale string            `json:"default_locale" example:"ru"`
+	Variants      map[string]string `json:"variants"`
+}
+
+type PreviewTemplateRequest struct {
+	Locale    string                 `json:"locale,omitempty" example:"en-US"`
+	Variables map[string]interface{} `json:"variables"`
+}
+
+// resolveTemplate renders the template of a send request into its message
+func (h *Handler) resolveTemplate(r *http.Request, req *SendMessageRequest) error {
+	if req.TemplateID == "" {
+		if len(req.Variables) > 0 {
+			return errors.New("variables require template_id")
+		}
+		return nil
+	}
+	if req.Message != "" || req.Formatted != nil {
+		return errors.New("template_id is mutually exclusive with message and formatted")
+	}
+
+	text, err := h.templateUseCase.RenderTemplate(r.Context(), tenantID(r), req.TemplateID, req.Locale, req.Variables)
+	if err != nil {
+		return err
+	}
+	req.Message = text
+	return nil
+}
+
+// CreateTemplate godoc
+// @Summary Создать шаблон сообщения
+// @Description Создает шаблон с плейсхолдерами text/template ({{.name}}) и вариантами для разных локалей
+// @Tags templates
+// @Accept json
+// @Produce json
+// @Param template body TemplateRequest true "Шаблон"
+// @Success 201 {object} domain.Template
+// @Failure 400 {string} string "Некорректный шаблон"
+// @Failure 403 {string} string "Ключ привязан к сессиям и не может менять данные всего тенанта"
+// @Security ApiKeyAuth
+// @Router /templates [post]
+func (h *Handler) CreateTemplate(w http.ResponseWriter, r *http.Request) {
+	var req TemplateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	template := &domain.Template{
+		Name:          req.Name,
+		DefaultLocale: req.DefaultLocale,
+		Variants:      req.Variants,
+	}
+	if err := h.templateUseCase.CreateTemplate(r.Context(), tenantID(r), template); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(template)
+}
+
+// ListTemplates godoc
+// @Summary Список шаблонов
+// @Tags templates
+// @Produce json
+// @Success 200 {array} domain.Template
+// @Security ApiKeyAuth
+// @Router /templates [get]
+func (h *Handler) ListTemplates(w http.ResponseWriter, r *http.Request) {
+	templates, err := h.templateUseCase.ListTemplates(r.Context(), tenantID(r))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if templates == nil {
+		templates = []*domain.Template{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(templates)
+}
+
+// GetTemplate godoc
+// @Summary Получить шаблон
+// @Tags templates
+// @Produce json
+// @Param templateId path string true "ID шаблона"
+// @Success 200 {object} domain.Template
+// @Failure 404 {string} string "Шаблон не найден"
+// @Security ApiKeyAuth
+// @Router /templates/{templateId} [get]
+func (h *Handler) GetTemplate(w http.ResponseWriter, r *http.Request) {
+	template, err := h.templateUseCase.GetTemplate(r.Context(), tenantID(r), mux.Vars(r)["templateId"])
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(template)
+}
+
+// UpdateTemplate godoc
+// @Summary Изменить шаблон
+// @Tags templates
+// @Accept json
+// @Produce json
+// @Param templateId path string true "ID шаблона"
+// @Param template body TemplateRequest true "Шаблон"
+// @Success 200 {object} domain.Template
+// @Failure 400 {string} string "Некорректный шаблон"
+// @Failure 404 {string} string "Шаблон не найден"
+// @Failure 403 {string} string "Ключ привязан к сессиям и не может менять данные всего тенанта"
+// @Security ApiKeyAuth
+// @Router /templates/{templateId} [put]
+func (h *Handler) UpdateTemplate(w http.ResponseWriter, r *http.Request) {
+	var req TemplateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	template := &domain.Template{
+		ID:            mux.Vars(r)["templateId"],
+		Name:          req.Name,
+		DefaultLocale: req.DefaultLocale,
+		Variants:      req.Variants,
+	}
+	if err := h.templateUseCase.UpdateTemplate(r.Context(), tenantID(r), template); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(template)
+}
+
+// DeleteTemplate godoc
+// @Summary Удалить шаблон
+// @Tags templates
+// @Param templateId path string true "ID шаблона"
+// @Success 204
+// @Failure 404 {string} string "Шаблон не найден"
+// @Failure 403 {string} string "Ключ привязан к сессиям и не может менять данные всего тенанта"
+// @Security ApiKeyAuth
+// @Router /templates/{templateId} [delete]
+func (h *Handler) DeleteTemplate(w http.ResponseWriter, r *http.Request) {
+	if err := h.templateUseCase.DeleteTemplate(r.Context(), tenantID(r), mux.Vars(r)["templateId"]); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// PreviewTemplate godoc
+// @Summary Предпросмотр шаблона
+// @Description Подставляет переменные в вариант шаблона для указанной локали без отправки
+// @Tags templates
+// @Accept json
+// @Produce json
+// @Param templateId path string true "ID шаблона"
+// @Param request body PreviewTemplateRequest true "Локаль и переменные"
+// @Success 200 {object} map[string]string
+// @Failure 400 {string} string "Не хватает переменных"
+// @Failure 404 {string} string "Шаблон не найден"
+// @Security ApiKeyAuth
+// @Router /templates/{templateId}/preview [post]
+func (h *Handler) PreviewTemplate(w http.ResponseWriter, r *http.Request) {
+	var req PreviewTemplateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	text, err := h.templateUseCase.RenderTemplate(r.Context(), tenantID(r), mux.Vars(r)["templateId"], req.Locale, req.Variables)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"text": text,
+	})
+}
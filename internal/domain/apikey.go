@@ -0,0 +1,80 @@
+package domain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+)
+
+// Scope names an operation an API key may perform
+type Scope string
+
+const (
+	ScopeSessionsWrite     Scope = "sessions:write"
+	ScopeMessagesSend      Scope = "messages:send"
+	ScopeChatsRead         Scope = "chats:read"
+	ScopeChatsWrite        Scope = "chats:write"
+	ScopeContactsRead      Scope = "contacts:read"
+	ScopeTemplatesRead     Scope = "templates:read"
+	ScopeTemplatesWrite    Scope = "templates:write"
+	ScopeCampaignsRead     Scope = "campaigns:read"
+	ScopeCampaignsWrite    Scope = "campaigns:write"
+	ScopeSuppressionsRead  Scope = "suppressions:read"
+	ScopeSuppressionsWrite Scope = "suppressions:write"
+	ScopeImportsWrite      Scope = "imports:write"
+	ScopeGroupsWrite       Scope = "groups:write"
+)
+
+// APIKey represents a client credential. Only the SHA-256 hash of the key is stored.
+type APIKey struct {
+	ID         string    `json:"id"`
+	Name       string    `json:"name"`
+	Hash       string    `json:"hash"`
+	TenantID   string    `json:"tenant_id,omitempty"` // Empty means DefaultTenantID
+	Scopes     []Scope   `json:"scopes"`
+	SessionIDs []string  `json:"session_ids,omitempty"` // Empty means every session
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// HasScope reports whether the key grants the scope
+func (k *APIKey) HasScope(scope Scope) bool {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsSession reports whether the key may act on the session
+func (k *APIKey) AllowsSession(sessionID string) bool {
+	if len(k.SessionIDs) == 0 {
+		return true
+	}
+	for _, id := range k.SessionIDs {
+		if id == sessionID {
+			return true
+		}
+	}
+	return false
+}
+
+// Tenant returns the tenant the key acts for
+func (k *APIKey) Tenant() string {
+	if k.TenantID == "" {
+		return DefaultTenantID
+	}
+	return k.TenantID
+}
+
+// HashAPIKey returns the hex encoded SHA-256 of a raw key
+func HashAPIKey(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
+// APIKeyRepository interface for API key persistence
+type APIKeyRepository interface {
+	Save(key *APIKey) error
+	GetByHash(hash string) (*APIKey, error)
+}
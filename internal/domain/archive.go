@@ -0,0 +1,57 @@
+package domain
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// ArchivedChat is a chat in the message archive of a session
+type ArchivedChat struct {
+	Chat
+	TenantID  string `json:"-"`
+	SessionID string `json:"session_id"`
+	// LastMessageAt is the time of the newest archived message, the cursor of the next sync
+	LastMessageAt time.Time  `json:"last_message_at"`
+	SyncedAt      *time.Time `json:"synced_at,omitempty"`
+}
+
+// MessageQuery pages through archived messages, newest first
+type MessageQuery struct {
+	Before string // ID of a message; only older messages are returned
+	Limit  int
+}
+
+// MessageRepository interface for the message archive. Chats and messages are
+// kept per tenant and session; a message is stored once per chat and ID.
+type MessageRepository interface {
+	SaveChat(tenantID, sessionID string, chat Chat) error
+	// SaveMessages stores messages that are not archived yet and returns how many were new
+	SaveMessages(tenantID, sessionID string, msgs []Message) (int, error)
+	GetChat(tenantID, sessionID, jid string) (*ArchivedChat, error) // Returns nil for unknown chats
+	FindChats(tenantID, sessionID, title string) ([]*ArchivedChat, error)
+	// ListMessages returns messages of a chat, newest first
+	ListMessages(tenantID, sessionID, chatJID string, q MessageQuery) ([]Message, error)
+	// ListUnsynced returns chats of all tenants not synced since the given time
+	ListUnsynced(before time.Time) ([]*ArchivedChat, error)
+	MarkSynced(tenantID, sessionID, jid string, at time.Time) error
+	// MediaSessions returns the sessions of a tenant with messages referring to a media hash
+	MediaSessions(tenantID, hash string) ([]string, error)
+	// Search returns messages matching a query, newest first; snippets are left empty
+	Search(tenantID string, q SearchQuery) ([]SearchHit, error)
+	Close() error
+}
+
+// ArchiveUseCase interface for the message archive. Messages are archived as
+// the history reader and the incoming message listener see them, and a
+// background sync catches up each archived chat from its last message.
+type ArchiveUseCase interface {
+	ListMessages(ctx context.Context, tenantID, sessionID string, target ChatTarget, q MessageQuery) (*ArchivedChat, []Message, error)
+	// GetMedia returns stored media referred to by messages of the given sessions
+	// of a tenant (any session when empty) and opens its content
+	GetMedia(ctx context.Context, tenantID string, sessionIDs []string, hash string) (*Media, io.ReadSeekCloser, error)
+	// Search finds archived messages of a tenant by their text
+	Search(ctx context.Context, tenantID string, q SearchQuery) ([]SearchHit, error)
+	Start()
+	Shutdown(ctx context.Context) error
+}
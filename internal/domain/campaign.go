@@ -0,0 +1,120 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+)
+
+var (
+	// ErrCampaignNotFound is returned when a campaign ID is unknown
+	ErrCampaignNotFound = errors.New("campaign not found")
+	// ErrInvalidCampaign is returned for campaigns with a malformed recipient list or settings
+	ErrInvalidCampaign = errors.New("invalid campaign")
+	// ErrCampaignState is returned when an action does not apply to the campaign's current status
+	ErrCampaignState = errors.New("campaign state does not allow this action")
+)
+
+// CampaignStatus is the lifecycle state of a campaign
+type CampaignStatus string
+
+const (
+	CampaignRunning   CampaignStatus = "running"
+	CampaignPaused    CampaignStatus = "paused"
+	CampaignCancelled CampaignStatus = "cancelled"
+	CampaignCompleted CampaignStatus = "completed"
+)
+
+// RecipientStatus is the delivery state of one campaign recipient
+type RecipientStatus string
+
+const (
+	RecipientPending RecipientStatus = "pending"
+	RecipientSent    RecipientStatus = "sent"
+	RecipientFailed  RecipientStatus = "failed"
+	RecipientSkipped RecipientStatus = "skipped" // Not sent because the campaign was cancelled
+)
+
+// CampaignRecipient is one row of a campaign's recipient list
+type CampaignRecipient struct {
+	Row         int               `json:"row"` // Line in the uploaded CSV, header excluded
+	PhoneNumber string            `json:"phone_number"`
+	Variables   map[string]string `json:"variables,omitempty"`
+	Status      RecipientStatus   `json:"status"`
+	SessionID   string            `json:"session_id,omitempty"`
+	MessageID   string            `json:"message_id,omitempty"`
+	SentAt      *time.Time        `json:"sent_at,omitempty"`
+	Error       string            `json:"error,omitempty"`
+}
+
+// Campaign sends one template to many recipients through a pool of sessions
+type Campaign struct {
+	ID         string              `json:"id"`
+	TenantID   string              `json:"tenant_id"`
+	Name       string              `json:"name"`
+	TemplateID string              `json:"template_id"`
+	Locale     string              `json:"locale,omitempty"`
+	SessionIDs []string            `json:"session_ids"`
+	Status     CampaignStatus      `json:"status"`
+	Error      string              `json:"error,omitempty"` // Why the campaign was paused automatically
+	Recipients []CampaignRecipient `json:"recipients"`
+	CreatedAt  time.Time           `json:"created_at"`
+	UpdatedAt  time.Time           `json:"updated_at"`
+	FinishedAt *time.Time          `json:"finished_at,omitempty"`
+}
+
+// CampaignProgress counts campaign recipients by status
+type CampaignProgress struct {
+	Total   int `json:"total"`
+	Pending int `json:"pending"`
+	Sent    int `json:"sent"`
+	Failed  int `json:"failed"`
+	Skipped int `json:"skipped"`
+}
+
+// Progress counts the campaign's recipients by status
+func (c *Campaign) Progress() CampaignProgress {
+	p := CampaignProgress{Total: len(c.Recipients)}
+	for _, r := range c.Recipients {
+		switch r.Status {
+		case RecipientPending:
+			p.Pending++
+		case RecipientSent:
+			p.Sent++
+		case RecipientFailed:
+			p.Failed++
+		case RecipientSkipped:
+			p.Skipped++
+		}
+	}
+	return p
+}
+
+// CampaignRepository interface for campaign persistence
+type CampaignRepository interface {
+	Save(campaign *Campaign) error
+	// SaveRecipient records the current state of one recipient of a saved
+	// campaign without rewriting the whole campaign
+	SaveRecipient(campaign *Campaign, index int) error
+	GetByID(tenantID, id string) (*Campaign, error)
+	List(tenantID string) ([]*Campaign, error)
+	// ListRunning returns running campaigns of all tenants so they can be resumed after a restart
+	ListRunning() ([]*Campaign, error)
+}
+
+// CampaignUseCase interface for bulk sending
+type CampaignUseCase interface {
+	// CreateCampaign reads recipients from CSV with a phone_number column; other
+	// columns become template variables. The campaign starts immediately.
+	CreateCampaign(ctx context.Context, tenantID string, campaign *Campaign, recipients io.Reader) error
+	GetCampaign(ctx context.Context, tenantID, id string) (*Campaign, error)
+	ListCampaigns(ctx context.Context, tenantID string) ([]*Campaign, error)
+	PauseCampaign(ctx context.Context, tenantID, id string) (*Campaign, error)
+	ResumeCampaign(ctx context.Context, tenantID, id string) (*Campaign, error)
+	CancelCampaign(ctx context.Context, tenantID, id string) (*Campaign, error)
+	// ExportCampaign writes the per-recipient status as CSV
+	ExportCampaign(ctx context.Context, tenantID, id string, w io.Writer) error
+	Start() error                       // Resumes campaigns that were running before a restart
+	Shutdown(ctx context.Context) error // Stops sending and saves campaign state
+}
@@ -0,0 +1,136 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"whatsapp-parser/pkg/phone"
+)
+
+var (
+	// ErrChatNotFound is returned when no chat matches a target
+	ErrChatNotFound = errors.New("chat not found")
+	// ErrAmbiguousChat is returned when a chat title matches several chats
+	ErrAmbiguousChat = errors.New("chat title is ambiguous")
+	// ErrNotChatMember is returned when the account has not joined a group
+	ErrNotChatMember = errors.New("account is not a member of the chat")
+	// ErrMessageNotFound is returned when a referenced message is not in the chat
+	ErrMessageNotFound = errors.New("message not found")
+)
+
+// OutgoingMessage is a message to send
+type OutgoingMessage struct {
+	Target ChatTarget `json:"target"`
+	// Text is the message body, already rendered to WhatsApp markup
+	Text string `json:"text"`
+	// ReplyTo is the ID of a message in the same chat to quote
+	ReplyTo string `json:"reply_to,omitempty"`
+	// Mentions lists E.164 numbers of group members to @mention
+	Mentions []string `json:"mentions,omitempty"`
+	// Location, Contacts and Poll are sent instead of Text; at most one is set
+	Location *Location       `json:"location,omitempty"`
+	Contacts []SharedContact `json:"contacts,omitempty"`
+	Poll     *Poll           `json:"poll,omitempty"`
+}
+
+// ChatTarget identifies the chat a message goes to. Exactly one field is set.
+type ChatTarget struct {
+	PhoneNumber string `json:"phone_number,omitempty"` // E.164
+	JID         string `json:"jid,omitempty"`          // e.g. 1203630xxxx@g.us
+	InviteCode  string `json:"invite_code,omitempty"`  // Code of a chat.whatsapp.com link
+	Title       string `json:"title,omitempty"`        // Exact chat title in the sidebar
+}
+
+// Key returns a stable identifier of the target, used e.g. for recipient cooldowns
+func (t ChatTarget) Key() string {
+	switch {
+	case t.PhoneNumber != "":
+		return t.PhoneNumber
+	case t.JID != "":
+		return t.JID
+	case t.InviteCode != "":
+		return "invite:" + t.InviteCode
+	default:
+		return "title:" + t.Title
+	}
+}
+
+// Phone returns the E.164 number of a user chat target, or "" for groups and
+// targets given by invite link or title
+func (t ChatTarget) Phone() string {
+	if t.PhoneNumber != "" {
+		return t.PhoneNumber
+	}
+	return PhoneFromJID(t.JID)
+}
+
+// PhoneFromJID returns the E.164 number of a user JID (…@c.us), or "" for other JIDs
+func PhoneFromJID(jid string) string {
+	if !strings.HasSuffix(jid, "@c.us") {
+		return ""
+	}
+	return "+" + strings.TrimSuffix(jid, "@c.us")
+}
+
+// invitePrefixes are accepted forms of group invite links
+var invitePrefixes = []string{
+	"https://chat.whatsapp.com/",
+	"http://chat.whatsapp.com/",
+	"chat.whatsapp.com/",
+	"invite:",
+}
+
+// ParseChatID interprets a chat_id: a JID ending in @g.us or @c.us, an invite
+// link or "invite:<code>", a number in international format such as
+// "+7 999 123-45-67", or otherwise an exact chat title ("title:" may be used
+// to force a title that looks like one of the other forms)
+func ParseChatID(chatID string) (ChatTarget, error) {
+	id := strings.TrimSpace(chatID)
+	if id == "" {
+		return ChatTarget{}, errors.New("chat_id is empty")
+	}
+
+	if strings.HasPrefix(id, "title:") {
+		return ChatTarget{Title: strings.TrimPrefix(id, "title:")}, nil
+	}
+	if strings.HasSuffix(id, "@g.us") {
+		return ChatTarget{JID: id}, nil
+	}
+	if strings.HasSuffix(id, "@c.us") {
+		// The number ends up in a /send?phone= URL, so only a valid one is kept
+		number, err := phone.Parse("+"+strings.TrimSuffix(id, "@c.us"), "")
+		if err != nil {
+			return ChatTarget{}, fmt.Errorf("chat_id %q: %w", id, err)
+		}
+		return ChatTarget{JID: number.JID()}, nil
+	}
+	for _, prefix := range invitePrefixes {
+		if strings.HasPrefix(id, prefix) {
+			code := strings.Trim(strings.TrimPrefix(id, prefix), "/")
+			if code == "" {
+				return ChatTarget{}, errors.New("invite code is empty")
+			}
+			return ChatTarget{InviteCode: code}, nil
+		}
+	}
+
+	if looksLikeNumber(id) {
+		number, err := phone.Parse(id, "")
+		if err != nil {
+			return ChatTarget{}, fmt.Errorf("chat_id %q: %w", id, err)
+		}
+		return ChatTarget{PhoneNumber: number.E164()}, nil
+	}
+
+	return ChatTarget{Title: id}, nil
+}
+
+// looksLikeNumber reports whether id is written like an international phone
+// number, which WhatsApp also shows as the title of unsaved contacts
+func looksLikeNumber(id string) bool {
+	if !strings.HasPrefix(id, "+") || !strings.ContainsAny(id, "0123456789") {
+		return false
+	}
+	return strings.Trim(id[1:], "0123456789 -().") == ""
+}
@@ -0,0 +1,38 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrChatActionUnavailable is returned when WhatsApp does not offer or does not apply a chat action
+var ErrChatActionUnavailable = errors.New("chat action is not available")
+
+// ChatAction is an operation of the chat list's context menu
+type ChatAction string
+
+const (
+	ChatArchive    ChatAction = "archive"
+	ChatUnarchive  ChatAction = "unarchive"
+	ChatPin        ChatAction = "pin"
+	ChatUnpin      ChatAction = "unpin"
+	ChatMute       ChatAction = "mute"
+	ChatUnmute     ChatAction = "unmute"
+	ChatMarkRead   ChatAction = "mark_read"
+	ChatMarkUnread ChatAction = "mark_unread"
+	ChatClear      ChatAction = "clear"  // Delete all messages but keep the chat
+	ChatDelete     ChatAction = "delete" // Groups must be left first
+)
+
+// ChatActions lists the supported chat actions
+var ChatActions = []ChatAction{
+	ChatArchive, ChatUnarchive, ChatPin, ChatUnpin, ChatMute, ChatUnmute,
+	ChatMarkRead, ChatMarkUnread, ChatClear, ChatDelete,
+}
+
+// ChatActionRequest is a chat action with its parameters
+type ChatActionRequest struct {
+	Action ChatAction
+	// MuteFor is how long to mute; WhatsApp offers 8 hours, a week or for good (0)
+	MuteFor time.Duration
+}
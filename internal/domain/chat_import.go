@@ -0,0 +1,48 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// ErrInvalidChatExport is returned for files that are not WhatsApp chat exports
+var ErrInvalidChatExport = errors.New("invalid chat export")
+
+// ChatExportOptions describe how an exported chat file was produced
+type ChatExportOptions struct {
+	// DateOrder is "dmy", "mdy" or "ymd"; detected from the file when empty
+	DateOrder string `json:"date_order,omitempty"`
+	// TimeZone of the exporting phone (IANA name); UTC when empty
+	TimeZone string `json:"time_zone,omitempty"`
+	// SelfName is the display name of the exporting user, used to mark own messages
+	SelfName string `json:"self_name,omitempty"`
+}
+
+// ChatImport is the content of an exported chat
+type ChatImport struct {
+	// Title is the chat name taken from the export's file name, if known
+	Title    string    `json:"title,omitempty"`
+	Messages []Message `json:"messages"`
+	// Attachments lists media files included in a .zip export
+	Attachments []string `json:"attachments,omitempty"`
+}
+
+// Chat describes the imported chat for writing it out like a chat read from
+// WhatsApp Web. An export does not say whether it is a group, so a chat with
+// more than one other author is taken for one.
+func (c *ChatImport) Chat() Chat {
+	authors := make(map[string]bool)
+	for _, msg := range c.Messages {
+		if !msg.FromMe && msg.Type != "system" {
+			authors[msg.SenderJID+"\x00"+msg.SenderName] = true
+		}
+	}
+	return Chat{Title: c.Title, IsGroup: len(authors) > 1}
+}
+
+// ImportUseCase interface for reading data exported from phones
+type ImportUseCase interface {
+	// ImportChatExport parses a .txt export or a .zip export with media
+	ImportChatExport(ctx context.Context, tenantID, fileName string, file io.ReaderAt, size int64, opts ChatExportOptions) (*ChatImport, error)
+}
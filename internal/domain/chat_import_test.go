@@ -0,0 +1,28 @@
+package domain
+
+import "testing"
+
+func TestChatImportChat(t *testing.T) {
+	tests := []struct {
+		name     string
+		messages []Message
+		group    bool
+	}{
+		{"empty", nil, false},
+		{"one other author", []Message{
+			{SenderName: "John"}, {FromMe: true}, {SenderName: "John"}, {Type: "system", SenderName: "Anna"},
+		}, false},
+		{"several other authors", []Message{
+			{SenderName: "John"}, {SenderName: "Anna"},
+		}, true},
+		{"same name, different numbers", []Message{
+			{SenderName: "John", SenderJID: "79991234567@c.us"}, {SenderName: "John", SenderJID: "79997654321@c.us"},
+		}, true},
+	}
+	for _, tt := range tests {
+		chat := (&ChatImport{Title: "Chat", Messages: tt.messages}).Chat()
+		if chat.Title != "Chat" || chat.IsGroup != tt.group {
+			t.Errorf("%s: Chat() = %+v, want a group: %v", tt.name, chat, tt.group)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+
+	"whatsapp-parser/pkg/phone"
+)
+
+func TestParseChatID(t *testing.T) {
+	tests := map[string]ChatTarget{
+		"120363012345678901@g.us":         {JID: "120363012345678901@g.us"},
+		"79991234567@c.us":                {JID: "79991234567@c.us"},
+		" 79991234567@c.us ":              {JID: "79991234567@c.us"},
+		"https://chat.whatsapp.com/AbC12": {InviteCode: "AbC12"},
+		"invite:AbC12/":                   {InviteCode: "AbC12"},
+		"+7 (999) 123-45-67":              {PhoneNumber: "+79991234567"},
+		"+44 7911 123456":                 {PhoneNumber: "+447911123456"},
+		"title:+7 999 123-45-67":          {Title: "+7 999 123-45-67"},
+		"Семья":                           {Title: "Семья"},
+		"+ плюс":                          {Title: "+ плюс"},
+		"8 999 123 45 67":                 {Title: "8 999 123 45 67"},
+	}
+	for chatID, want := range tests {
+		got, err := ParseChatID(chatID)
+		if err != nil {
+			t.Errorf("ParseChatID(%q): %v", chatID, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("ParseChatID(%q) = %+v, want %+v", chatID, got, want)
+		}
+	}
+}
+
+func TestParseChatIDInvalid(t *testing.T) {
+	for _, chatID := range []string{
+		"+7 999 123",
+		"+7 599 123 45 67",
+		"7999&text=hi@c.us",
+		"+79991234567@c.us",
+		"12345@c.us",
+	} {
+		target, err := ParseChatID(chatID)
+		if err == nil {
+			t.Errorf("ParseChatID(%q) = %+v, want an error", chatID, target)
+			continue
+		}
+		if !errors.Is(err, phone.ErrInvalidNumber) {
+			t.Errorf("ParseChatID(%q) error %v does not wrap ErrInvalidNumber", chatID, err)
+		}
+	}
+	for _, chatID := range []string{"", "  ", "invite:"} {
+		if target, err := ParseChatID(chatID); err == nil {
+			t.Errorf("ParseChatID(%q) = %+v, want an error", chatID, target)
+		}
+	}
+}
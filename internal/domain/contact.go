@@ -0,0 +1,39 @@
+package domain
+
+import "time"
+
+// RegistrationStatus tells whether a phone number has a WhatsApp account
+type RegistrationStatus string
+
+const (
+	RegistrationRegistered   RegistrationStatus = "registered"
+	RegistrationUnregistered RegistrationStatus = "unregistered"
+	RegistrationUnknown      RegistrationStatus = "unknown"
+)
+
+// ContactCheck is the result of checking one phone number
+type ContactCheck struct {
+	PhoneNumber string             `json:"phone_number"`
+	JID         string             `json:"jid,omitempty"`
+	Status      RegistrationStatus `json:"status"`
+	CheckedAt   time.Time          `json:"checked_at"`
+	Cached      bool               `json:"cached"`
+	Error       string             `json:"error,omitempty"`
+}
+
+// ContactCheckRepository interface for cached registration checks
+type ContactCheckRepository interface {
+	Get(tenantID, phoneNumber string) (*ContactCheck, error)
+	Save(tenantID string, check *ContactCheck) error
+}
+
+// Contact is a contact saved in a session's address book
+type Contact struct {
+	JID               string `json:"jid"`
+	PhoneNumber       string `json:"phone_number"` // E.164
+	Name              string `json:"name,omitempty"`
+	PushName          string `json:"push_name,omitempty"` // Name the contact chose for themselves
+	About             string `json:"about,omitempty"`
+	IsBusiness        bool   `json:"is_business"`
+	ProfilePictureURL string `json:"profile_picture_url,omitempty"` // Expires after a while
+}
@@ -0,0 +1,14 @@
+package domain
+
+import "errors"
+
+var (
+	// ErrSessionNotFound is returned when a session ID is unknown
+	ErrSessionNotFound = errors.New("session not found")
+	// ErrShuttingDown is returned for work submitted after shutdown has started
+	ErrShuttingDown = errors.New("service is shutting down")
+	// ErrQuotaExceeded is returned when a tenant quota does not allow the operation
+	ErrQuotaExceeded = errors.New("tenant quota exceeded")
+	// ErrInvalidTenant is returned for tenant IDs that cannot be used
+	ErrInvalidTenant = errors.New("invalid tenant")
+)
@@ -0,0 +1,76 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrNotGroupAdmin is returned for group changes that need admin rights the account lacks
+var ErrNotGroupAdmin = errors.New("account is not an admin of the group")
+
+// GroupAdminError tells which group operation was refused for lack of admin rights
+type GroupAdminError struct {
+	Group     string // JID or other key of the group
+	Operation GroupOperation
+}
+
+func (e *GroupAdminError) Error() string {
+	return fmt.Sprintf("%v: %s needs admin rights in %s", ErrNotGroupAdmin, e.Operation, e.Group)
+}
+
+func (e *GroupAdminError) Is(target error) bool {
+	return target == ErrNotGroupAdmin
+}
+
+// GroupOperation names a change made to a group
+type GroupOperation string
+
+const (
+	GroupUpdateInfo   GroupOperation = "update"      // Title, description, icon or admin-only messaging
+	GroupAddMembers   GroupOperation = "add"         // Add participants
+	GroupRemove       GroupOperation = "remove"      // Remove participants
+	GroupPromote      GroupOperation = "promote"     // Make participants admins
+	GroupDemote       GroupOperation = "demote"      // Take admin rights away
+	GroupInviteLink   GroupOperation = "invite_link" // Read the invite link
+	GroupRevokeInvite GroupOperation = "revoke_invite"
+)
+
+// ParticipantActions are the operations of a participants change request
+var ParticipantActions = []GroupOperation{GroupAddMembers, GroupRemove, GroupPromote, GroupDemote}
+
+// ParticipantStatus is the outcome of a participant change for one member
+type ParticipantStatus string
+
+const (
+	ParticipantOK             ParticipantStatus = "ok"
+	ParticipantInviteRequired ParticipantStatus = "invite_required" // Privacy settings only allow an invite link
+	ParticipantNotOnWhatsApp  ParticipantStatus = "not_on_whatsapp"
+	ParticipantAlreadyMember  ParticipantStatus = "already_member"
+	ParticipantNotMember      ParticipantStatus = "not_member"
+	ParticipantRecentlyLeft   ParticipantStatus = "recently_left" // Left too recently to be added back
+	ParticipantFailed         ParticipantStatus = "failed"
+	ParticipantUnknown        ParticipantStatus = "unknown" // WhatsApp reported no result for the member
+)
+
+// ParticipantChange reports the outcome of a group change for one member
+type ParticipantChange struct {
+	PhoneNumber string            `json:"phone_number"` // E.164
+	Status      ParticipantStatus `json:"status"`
+	Code        int               `json:"code"` // WhatsApp's result code
+}
+
+// GroupUpdate lists the group settings to change; nil fields are left as they are
+type GroupUpdate struct {
+	Title       *string
+	Description *string
+	Icon        []byte // JPEG or PNG
+	AdminsOnly  *bool  // Only admins may send messages
+}
+
+// GroupResult describes a group after a change
+type GroupResult struct {
+	JID          string              `json:"jid"`
+	Title        string              `json:"title,omitempty"`
+	Participants []ParticipantChange `json:"participants,omitempty"`
+	InviteLink   string              `json:"invite_link,omitempty"`
+}
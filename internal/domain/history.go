@@ -0,0 +1,42 @@
+package domain
+
+import "time"
+
+// Chat describes a chat read from WhatsApp Web
+type Chat struct {
+	JID          string        `json:"jid"`
+	Title        string        `json:"title"`
+	IsGroup      bool          `json:"is_group"`
+	Participants []Participant `json:"participants,omitempty"` // Group members
+	// State in the session's chat list, only set when read from the browser
+	Archived     bool       `json:"archived,omitempty"` // Moved to WhatsApp's archived chats
+	Pinned       bool       `json:"pinned,omitempty"`
+	Muted        bool       `json:"muted,omitempty"`
+	MutedUntil   *time.Time `json:"muted_until,omitempty"` // Unset while muted means muted for good
+	UnreadCount  int        `json:"unread_count,omitempty"`
+	MarkedUnread bool       `json:"marked_unread,omitempty"`
+}
+
+// Participant is a member of a group chat
+type Participant struct {
+	JID         string     `json:"jid"`
+	PhoneNumber string     `json:"phone_number,omitempty"` // E.164
+	Name        string     `json:"name,omitempty"`
+	Admin       bool       `json:"admin"`
+	SuperAdmin  bool       `json:"super_admin,omitempty"` // The group's creator
+	JoinedAt    *time.Time `json:"joined_at,omitempty"`   // Only where WhatsApp shows it
+}
+
+// HistoryOptions limit the messages read from a chat
+type HistoryOptions struct {
+	Since      time.Time // Skip older messages; zero reads the whole history
+	Limit      int       // Read at most the newest Limit messages; 0 means no limit
+	Thumbnails bool      // Include base64 previews of media
+	Media      bool      // Download media files into the media store
+}
+
+// ChatWriter receives a chat and then its messages, oldest first
+type ChatWriter interface {
+	WriteChat(chat Chat) error
+	WriteMessage(msg Message) error
+}
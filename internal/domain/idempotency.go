@@ -0,0 +1,20 @@
+package domain
+
+import "time"
+
+// IdempotencyRecord is the stored response of a request sent with an Idempotency-Key header
+type IdempotencyRecord struct {
+	Key         string    `json:"key"`
+	RequestHash string    `json:"request_hash"` // SHA-256 of method, path and body
+	StatusCode  int       `json:"status_code"`
+	ContentType string    `json:"content_type,omitempty"`
+	Body        []byte    `json:"body"`
+	CreatedAt   time.Time `json:"created_at"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// IdempotencyRepository interface for idempotency records, kept per tenant
+type IdempotencyRepository interface {
+	Get(tenantID, key string) (*IdempotencyRecord, error) // Returns nil for unknown and expired keys
+	Save(tenantID string, record *IdempotencyRecord) error
+}
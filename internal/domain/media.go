@@ -0,0 +1,51 @@
+package domain
+
+import (
+	"errors"
+	"io"
+	"time"
+)
+
+var (
+	// ErrMediaNotFound is returned for media hashes that are not stored or not visible to the tenant
+	ErrMediaNotFound = errors.New("media not found")
+	// ErrNoMedia is returned when a message has no downloadable media
+	ErrNoMedia = errors.New("message has no downloadable media")
+	// ErrMediaQuotaExceeded is returned when the media store is full
+	ErrMediaQuotaExceeded = errors.New("media storage quota exceeded")
+)
+
+// Media is a file in the content-addressed media store
+type Media struct {
+	Hash      string    `json:"hash"` // Hex SHA-256 of the content
+	MimeType  string    `json:"mime_type"`
+	Size      int64     `json:"size"`
+	FileName  string    `json:"file_name,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// MediaRepository interface for the media store. Files are kept once per
+// content hash, whichever tenants and messages refer to them.
+type MediaRepository interface {
+	// Save stores the content read from r unless it is already stored. It fails
+	// with ErrMediaQuotaExceeded when the content does not fit in the quota.
+	Save(r io.Reader, mimeType, fileName string) (*Media, error)
+	Get(hash string) (*Media, error) // Returns nil for unknown hashes
+	Open(hash string) (io.ReadSeekCloser, error)
+}
+
+// mediaTypes are WhatsApp message types that carry a file
+var mediaTypes = map[string]bool{
+	"image":    true,
+	"video":    true,
+	"gif":      true,
+	"audio":    true,
+	"ptt":      true,
+	"document": true,
+	"sticker":  true,
+}
+
+// IsMediaType tells whether messages of a WhatsApp type carry a file
+func IsMediaType(messageType string) bool {
+	return mediaTypes[messageType]
+}
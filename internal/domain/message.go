@@ -0,0 +1,87 @@
+package domain
+
+import (
+	"strings"
+	"time"
+)
+
+// Message is a chat message read from WhatsApp Web or from an exported chat
+type Message struct {
+	ID        string `json:"id"`
+	ChatJID   string `json:"chat_jid,omitempty"`
+	SenderJID string `json:"sender_jid,omitempty"` // Author in groups; the chat JID in user chats
+	// SenderName is the display name of the author; exported chats only have names
+	SenderName string    `json:"sender_name,omitempty"`
+	FromMe     bool      `json:"from_me"`
+	Type       string    `json:"type"` // WhatsApp message type, e.g. "chat", "image", "system"
+	Text       string    `json:"text,omitempty"`
+	Attachment string    `json:"attachment,omitempty"` // File name of attached media
+	Thumbnail  string    `json:"thumbnail,omitempty"`  // Base64 JPEG preview of media
+	MediaHash  string    `json:"media_hash,omitempty"` // Downloaded media in the media store
+	Timestamp  time.Time `json:"timestamp"`
+	// Location, Contacts and Poll carry the content of "location", "vcard",
+	// "multi_vcard" and "poll_creation" messages; Text repeats their name
+	// or question, so such messages show up in exports and search
+	Location *Location       `json:"location,omitempty"`
+	Contacts []SharedContact `json:"contacts,omitempty"`
+	Poll     *Poll           `json:"poll,omitempty"`
+}
+
+// Location is a shared location pin
+type Location struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Name      string  `json:"name,omitempty"`
+	Address   string  `json:"address,omitempty"`
+	URL       string  `json:"url,omitempty"`
+}
+
+// SharedContact is a contact card shared in a message
+type SharedContact struct {
+	Name        string `json:"name"`
+	PhoneNumber string `json:"phone_number,omitempty"` // E.164
+	VCard       string `json:"vcard,omitempty"`        // The card as WhatsApp sent it
+}
+
+// Poll is a poll with its options; Votes and Voters are tallies as seen by the
+// account when the poll was read
+type Poll struct {
+	Question        string       `json:"question"`
+	Options         []PollOption `json:"options"`
+	MultipleAnswers bool         `json:"multiple_answers"`
+	Voters          int          `json:"voters"`
+}
+
+// PollOption is an answer of a poll
+type PollOption struct {
+	Name  string `json:"name"`
+	Votes int    `json:"votes"`
+}
+
+// Summary returns the text a location, contact or poll message is shown and
+// searched by, or "" if it has none of them
+func (m *Message) Summary() string {
+	switch {
+	case m.Location != nil:
+		parts := make([]string, 0, 2)
+		for _, s := range []string{m.Location.Name, m.Location.Address} {
+			if s != "" {
+				parts = append(parts, s)
+			}
+		}
+		return strings.Join(parts, ", ")
+	case len(m.Contacts) > 0:
+		names := make([]string, len(m.Contacts))
+		for i, c := range m.Contacts {
+			names[i] = c.Name
+		}
+		return strings.Join(names, ", ")
+	case m.Poll != nil:
+		return m.Poll.Question
+	}
+	return ""
+}
+
+// MessageHandler is called for messages received by a running session. The
+// same message may be delivered again after the session's browser restarts.
+type MessageHandler func(tenantID, sessionID string, msg Message)
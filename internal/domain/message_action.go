@@ -0,0 +1,45 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrMessageActionNotAllowed is returned when WhatsApp does not allow an action on a message
+var ErrMessageActionNotAllowed = errors.New("WhatsApp does not allow this action on the message")
+
+// MaxForwards is the number of chats WhatsApp forwards a message to at once
+const MaxForwards = 5
+
+// MessageAction is an operation on a sent or received message
+type MessageAction string
+
+const (
+	MessageReact   MessageAction = "react"   // Set or, with an empty emoji, remove the account's reaction
+	MessageEdit    MessageAction = "edit"    // Replace the text of the account's message
+	MessageDelete  MessageAction = "delete"  // Delete for everyone
+	MessageForward MessageAction = "forward" // Forward to other chats
+	MessageStar    MessageAction = "star"
+	MessageUnstar  MessageAction = "unstar"
+)
+
+// MessageActions lists the supported message actions
+var MessageActions = []MessageAction{MessageReact, MessageEdit, MessageDelete, MessageForward, MessageStar, MessageUnstar}
+
+// MessageActionRequest is a message action with its parameters
+type MessageActionRequest struct {
+	Action  MessageAction
+	Emoji   string       // Reaction of react
+	Text    string       // New text of edit, already rendered to WhatsApp markup
+	Targets []ChatTarget // Chats to forward to
+}
+
+// MessageChatJID returns the chat JID contained in a serialized message ID
+// ("true_<chat>_<id>" or "false_<chat>_<id>[_<author>]"), or "" if the ID is malformed
+func MessageChatJID(messageID string) string {
+	parts := strings.Split(messageID, "_")
+	if len(parts) < 3 || (parts[0] != "true" && parts[0] != "false") || !strings.Contains(parts[1], "@") {
+		return ""
+	}
+	return parts[1]
+}
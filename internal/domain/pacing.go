@@ -0,0 +1,32 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// PacingState is the send history of a session used to enforce pacing limits
+type PacingState struct {
+	// Sends holds send times of the last 24 hours
+	Sends []time.Time `json:"sends"`
+	// LastByRecipient holds the last send time per recipient
+	LastByRecipient map[string]time.Time `json:"last_by_recipient"`
+	// NextAllowedAt is the earliest time of the next send, including jitter
+	NextAllowedAt time.Time `json:"next_allowed_at"`
+}
+
+// PacingRepository interface for pacing state persistence
+type PacingRepository interface {
+	Get(tenantID, sessionID string) (*PacingState, error) // Returns an empty state for unknown sessions
+	Save(tenantID, sessionID string, state *PacingState) error
+}
+
+// RateLimitError is returned when a send would violate a pacing limit
+type RateLimitError struct {
+	Reason     string
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("rate limited: %s, retry after %s", e.Reason, e.RetryAfter.Round(time.Second))
+}
@@ -0,0 +1,77 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	// ErrScheduleNotFound is returned when a scheduled message ID is unknown
+	ErrScheduleNotFound = errors.New("scheduled message not found")
+	// ErrInvalidSchedule is returned for schedules with a bad time, cron expression or time zone
+	ErrInvalidSchedule = errors.New("invalid schedule")
+)
+
+// ScheduleStatus is the lifecycle state of a scheduled message
+type ScheduleStatus string
+
+const (
+	ScheduleActive    ScheduleStatus = "active"
+	ScheduleCompleted ScheduleStatus = "completed" // A one-off message was sent or failed
+	ScheduleCancelled ScheduleStatus = "cancelled"
+)
+
+// CatchUpPolicy decides what happens to runs missed while the service was down
+type CatchUpPolicy string
+
+const (
+	CatchUpSkip CatchUpPolicy = "skip" // Drop missed runs and wait for the next one
+	CatchUpOnce CatchUpPolicy = "once" // Send once for any number of missed runs
+	CatchUpAll  CatchUpPolicy = "all"  // Send every missed run, one after another
+)
+
+// ValidCatchUpPolicy reports whether p is a known policy
+func ValidCatchUpPolicy(p CatchUpPolicy) bool {
+	return p == CatchUpSkip || p == CatchUpOnce || p == CatchUpAll
+}
+
+// ScheduledMessage is a message sent at a fixed time (SendAt) or repeatedly on
+// a cron expression evaluated in TimeZone
+type ScheduledMessage struct {
+	ID        string          `json:"id"`
+	TenantID  string          `json:"tenant_id"`
+	SessionID string          `json:"session_id"`
+	Message   OutgoingMessage `json:"message"`
+	SendAt    *time.Time      `json:"send_at,omitempty"`
+	Cron      string          `json:"cron,omitempty"` // Five fields: minute hour day month weekday
+	TimeZone  string          `json:"time_zone,omitempty"`
+	CatchUp   CatchUpPolicy   `json:"catch_up"`
+	Status    ScheduleStatus  `json:"status"`
+	NextRunAt *time.Time      `json:"next_run_at,omitempty"`
+	LastRunAt *time.Time      `json:"last_run_at,omitempty"`
+	Runs      int             `json:"runs"`
+	// LastMessageID and LastError describe the outcome of the latest run
+	LastMessageID string    `json:"last_message_id,omitempty"`
+	LastError     string    `json:"last_error,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// ScheduleRepository interface for scheduled message persistence
+type ScheduleRepository interface {
+	Save(schedule *ScheduledMessage) error
+	GetByID(tenantID, id string) (*ScheduledMessage, error)
+	List(tenantID, sessionID string) ([]*ScheduledMessage, error)
+	// ListActive returns active schedules of all tenants for the scheduler loop
+	ListActive() ([]*ScheduledMessage, error)
+}
+
+// ScheduleUseCase interface for scheduled and recurring messages
+type ScheduleUseCase interface {
+	CreateSchedule(ctx context.Context, tenantID string, schedule *ScheduledMessage) error
+	ListSchedules(ctx context.Context, tenantID, sessionID string) ([]*ScheduledMessage, error)
+	CancelSchedule(ctx context.Context, tenantID, sessionID, id string) (*ScheduledMessage, error)
+	Start() error                       // Loads active schedules and starts the scheduler loop
+	Shutdown(ctx context.Context) error // Stops the loop and waits for sends in progress
+}
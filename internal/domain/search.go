@@ -0,0 +1,30 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrInvalidSearch is returned for search queries that cannot be run
+var ErrInvalidSearch = errors.New("invalid search query")
+
+// SearchQuery selects archived messages of a tenant by their text
+type SearchQuery struct {
+	// Text holds words and "quoted phrases", all of which must occur; a
+	// trailing * matches words by prefix
+	Text       string
+	SessionIDs []string  // Search only these sessions; empty means every session
+	ChatJID    string    // Search only this chat
+	From, To   time.Time // Optional bounds of the message time, To exclusive
+	Limit      int
+	Offset     int
+}
+
+// SearchHit is a message that matched a search
+type SearchHit struct {
+	SessionID string  `json:"session_id"`
+	ChatTitle string  `json:"chat_title,omitempty"`
+	Message   Message `json:"message"`
+	// Snippet is the HTML-escaped text around the match with matching words in <mark> tags
+	Snippet string `json:"snippet"`
+}
@@ -1,10 +1,14 @@
 package domain
 
-import "time"
+import (
+	"context"
+	"time"
+)
 
 // Session represents a WhatsApp Web session
 type Session struct {
 	ID        string    `json:"id"`
+	TenantID  string    `json:"tenant_id"`
 	Cookies   []Cookie  `json:"cookies"`
 	Storage   []Storage `json:"storage"`
 	CreatedAt time.Time `json:"created_at"`
@@ -28,16 +32,52 @@ type Storage struct {
 	Value string `json:"value"`
 }
 
-// SessionRepository interface for session persistence
+// SessionRepository interface for session persistence. Sessions are stored
+// and looked up within their tenant.
 type SessionRepository interface {
 	Save(session *Session) error
-	GetByID(id string) (*Session, error)
-	Delete(id string) error
+	GetByID(tenantID, id string) (*Session, error)
+	Delete(tenantID, id string) error
 }
 
 // SessionUseCase interface for session business logic
 type SessionUseCase interface {
-	CreateSession() (*Session, string, error) // Returns session, QR code URL, and error
-	RestoreSession(id string) error
-	SendMessage(sessionID string, phoneNumber string, message string) error
-} 
\ No newline at end of file
+	CreateSession(ctx context.Context, tenantID string) (*Session, string, error) // Returns session, QR code URL, and error
+	RestoreSession(ctx context.Context, tenantID, id string) error
+	SendMessage(ctx context.Context, tenantID, sessionID string, msg OutgoingMessage) (string, error) // Returns the sent message ID if known
+	// CheckContacts reports which E.164 numbers have WhatsApp, using cached results where fresh
+	CheckContacts(ctx context.Context, tenantID, sessionID string, phoneNumbers []string) ([]ContactCheck, error)
+	// ReadChatHistory streams a chat and its messages, oldest first, to w
+	ReadChatHistory(ctx context.Context, tenantID, sessionID string, target ChatTarget, opts HistoryOptions, w ChatWriter) error
+	// ListContacts returns the address book of a session; about texts are fetched if withAbout is set
+	ListContacts(ctx context.Context, tenantID, sessionID string, withAbout bool) ([]Contact, error)
+	// GroupInfo returns a group chat with its participants
+	GroupInfo(ctx context.Context, tenantID, sessionID string, target ChatTarget) (*Chat, error)
+	// CreateGroup creates a group with the given E.164 numbers as members
+	CreateGroup(ctx context.Context, tenantID, sessionID, title string, phoneNumbers []string) (*GroupResult, error)
+	// UpdateGroup changes the title, description, icon or messaging setting of a group
+	UpdateGroup(ctx context.Context, tenantID, sessionID string, target ChatTarget, update GroupUpdate) (*GroupResult, error)
+	// ChangeParticipants adds, removes, promotes or demotes members of a group
+	ChangeParticipants(ctx context.Context, tenantID, sessionID string, target ChatTarget, action GroupOperation, phoneNumbers []string) (*GroupResult, error)
+	// GroupInviteLink returns the invite link of a group, replacing the current one if revoke is set
+	GroupInviteLink(ctx context.Context, tenantID, sessionID string, target ChatTarget, revoke bool) (*GroupResult, error)
+	// ChatAction archives, pins, mutes, marks, clears or deletes a chat and returns its
+	// state afterwards; nil once deleted
+	ChatAction(ctx context.Context, tenantID, sessionID string, target ChatTarget, req ChatActionRequest) (*Chat, error)
+	// MessageAction reacts to, edits, deletes, forwards or stars a message by its serialized ID
+	MessageAction(ctx context.Context, tenantID, sessionID, messageID string, req MessageActionRequest) error
+	// ChatActivity returns the time of the latest activity of each chat in a
+	// session's chat list by JID, without opening the chats
+	ChatActivity(ctx context.Context, tenantID, sessionID string) (map[string]time.Time, error)
+	// Running tells whether the browser of a session is started
+	Running(sessionID string) bool
+	// Subscribe registers a handler for messages received by any running session
+	Subscribe(handler MessageHandler)
+	Shutdown(ctx context.Context) error // Drains in-flight work, persists session state and closes browsers
+}
+
+// MessageSender queues messages per session and sends them one at a time,
+// waiting out pacing limits instead of failing with a RateLimitError
+type MessageSender interface {
+	Send(ctx context.Context, tenantID, sessionID string, msg OutgoingMessage) (string, error)
+}
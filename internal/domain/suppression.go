@@ -0,0 +1,49 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	// ErrRecipientSuppressed is returned when sending to a number on the suppression list
+	ErrRecipientSuppressed = errors.New("recipient is on the suppression list")
+	// ErrSuppressionNotFound is returned when removing a number that is not suppressed
+	ErrSuppressionNotFound = errors.New("suppression not found")
+)
+
+// SuppressionReason tells how a number got on the suppression list
+type SuppressionReason string
+
+const (
+	SuppressionOptOut SuppressionReason = "opt_out" // The recipient replied with an opt-out keyword
+	SuppressionManual SuppressionReason = "manual"  // Added through the API
+)
+
+// Suppression blocks all outgoing sends of a tenant to a phone number
+type Suppression struct {
+	PhoneNumber string            `json:"phone_number"` // E.164
+	Reason      SuppressionReason `json:"reason"`
+	Note        string            `json:"note,omitempty"`
+	// SessionID and MessageID identify the opt-out message
+	SessionID string    `json:"session_id,omitempty"`
+	MessageID string    `json:"message_id,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// SuppressionRepository interface for the per-tenant suppression list
+type SuppressionRepository interface {
+	Get(tenantID, phoneNumber string) (*Suppression, error)
+	List(tenantID string) ([]*Suppression, error)
+	Save(tenantID string, suppression *Suppression) error
+	Delete(tenantID, phoneNumber string) (bool, error) // Reports whether the number was listed
+}
+
+// SuppressionUseCase interface for managing the suppression list
+type SuppressionUseCase interface {
+	// AddSuppressions lists E.164 numbers; numbers already listed keep their entry
+	AddSuppressions(ctx context.Context, tenantID string, phoneNumbers []string, note string) ([]*Suppression, error)
+	ListSuppressions(ctx context.Context, tenantID string) ([]*Suppression, error)
+	RemoveSuppression(ctx context.Context, tenantID, phoneNumber string) error
+}
@@ -0,0 +1,47 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	// ErrTemplateNotFound is returned when a template ID is unknown
+	ErrTemplateNotFound = errors.New("template not found")
+	// ErrInvalidTemplate is returned for templates that do not parse or lack a body
+	ErrInvalidTemplate = errors.New("invalid template")
+	// ErrMissingVariables is returned when rendering without all referenced variables
+	ErrMissingVariables = errors.New("missing template variables")
+)
+
+// Template is a reusable message with Go text/template placeholders such as
+// {{.name}}, with one body per locale
+type Template struct {
+	ID            string            `json:"id"`
+	TenantID      string            `json:"tenant_id"`
+	Name          string            `json:"name"`
+	DefaultLocale string            `json:"default_locale"`
+	Variants      map[string]string `json:"variants"` // Body by locale, e.g. "ru", "en-US"
+	CreatedAt     time.Time         `json:"created_at"`
+	UpdatedAt     time.Time         `json:"updated_at"`
+}
+
+// TemplateRepository interface for template persistence
+type TemplateRepository interface {
+	Save(template *Template) error
+	GetByID(tenantID, id string) (*Template, error)
+	List(tenantID string) ([]*Template, error)
+	Delete(tenantID, id string) error
+}
+
+// TemplateUseCase interface for template management and rendering
+type TemplateUseCase interface {
+	CreateTemplate(ctx context.Context, tenantID string, template *Template) error
+	UpdateTemplate(ctx context.Context, tenantID string, template *Template) error
+	GetTemplate(ctx context.Context, tenantID, id string) (*Template, error)
+	ListTemplates(ctx context.Context, tenantID string) ([]*Template, error)
+	DeleteTemplate(ctx context.Context, tenantID, id string) error
+	// RenderTemplate fills the variant best matching locale; every referenced variable must be given
+	RenderTemplate(ctx context.Context, tenantID, id, locale string, variables map[string]interface{}) (string, error)
+}
@@ -0,0 +1,35 @@
+package domain
+
+import "regexp"
+
+// DefaultTenantID is used for sessions created without a tenant, e.g. when
+// authentication is disabled or the API key has no tenant
+const DefaultTenantID = "default"
+
+var tenantIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// Tenant represents a department whose sessions, storage and browser profiles
+// are isolated from other tenants. Zero quotas mean unlimited.
+type Tenant struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	MaxBrowsers int    `json:"max_browsers"`
+	DailySends  int    `json:"daily_sends"`
+}
+
+// ValidTenantID reports whether id is safe to use as a directory name
+func ValidTenantID(id string) bool {
+	return tenantIDPattern.MatchString(id)
+}
+
+// UsageRepository interface for per-tenant usage counters
+type UsageRepository interface {
+	// ReserveSends adds n sends to the tenant's counter for day (YYYY-MM-DD)
+	// and returns the new total. With a positive limit the counter is checked
+	// and raised in one step, failing with ErrQuotaExceeded rather than going
+	// over the limit.
+	ReserveSends(tenantID, day string, n, limit int) (int, error)
+	// ReleaseSends takes back n reserved sends that did not go out
+	ReleaseSends(tenantID, day string, n int) error
+	GetSends(tenantID, day string) (int, error)
+}
@@ -0,0 +1,126 @@
+package exporter
+
+import (
+	"bufio"
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"whatsapp-parser/internal/domain"
+)
+
+var (
+	contactsCSVHeader     = []string{"jid", "phone_number", "name", "push_name", "about", "is_business", "profile_picture_url"}
+	participantsCSVHeader = []string{"jid", "phone_number", "name", "admin", "super_admin", "joined_at"}
+)
+
+// vcardEscaper escapes text values of vCard 3.0 properties
+var vcardEscaper = strings.NewReplacer(`\`, `\\`, ",", `\,`, ";", `\;`, "\r\n", `\n`, "\n", `\n`)
+
+// WriteContactsCSV writes contacts as CSV, one row per contact
+func WriteContactsCSV(w io.Writer, contacts []domain.Contact) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(contactsCSVHeader); err != nil {
+		return err
+	}
+	for _, c := range contacts {
+		if err := cw.Write([]string{
+			c.JID,
+			c.PhoneNumber,
+			c.Name,
+			c.PushName,
+			c.About,
+			strconv.FormatBool(c.IsBusiness),
+			c.ProfilePictureURL,
+		}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteParticipantsCSV writes group participants as CSV, one row per member
+func WriteParticipantsCSV(w io.Writer, participants []domain.Participant) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(participantsCSVHeader); err != nil {
+		return err
+	}
+	for _, p := range participants {
+		joined := ""
+		if p.JoinedAt != nil {
+			joined = p.JoinedAt.UTC().Format(time.RFC3339)
+		}
+		if err := cw.Write([]string{
+			p.JID,
+			p.PhoneNumber,
+			p.Name,
+			strconv.FormatBool(p.Admin),
+			strconv.FormatBool(p.SuperAdmin),
+			joined,
+		}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteContactsVCard writes contacts as vCard 3.0 cards that phones import
+// into their address book
+func WriteContactsVCard(w io.Writer, contacts []domain.Contact) error {
+	bw := bufio.NewWriter(w)
+	for _, c := range contacts {
+		name := c.Name
+		if name == "" {
+			name = c.PushName
+		}
+		writeVCard(bw, vcard{name: name, phone: c.PhoneNumber, note: c.About, photo: c.ProfilePictureURL})
+	}
+	return bw.Flush()
+}
+
+// WriteParticipantsVCard writes group participants as vCard 3.0 cards. Members
+// whose number WhatsApp hides are skipped, since a card needs one.
+func WriteParticipantsVCard(w io.Writer, participants []domain.Participant) error {
+	bw := bufio.NewWriter(w)
+	for _, p := range participants {
+		if p.PhoneNumber == "" {
+			continue
+		}
+		writeVCard(bw, vcard{name: p.Name, phone: p.PhoneNumber})
+	}
+	return bw.Flush()
+}
+
+// vcard holds the properties written for one card
+type vcard struct {
+	name  string
+	phone string // E.164
+	note  string
+	photo string
+}
+
+// writeVCard writes one card; errors surface from the writer's Flush
+func writeVCard(w *bufio.Writer, card vcard) {
+	name := card.name
+	if name == "" {
+		name = card.phone
+	}
+	w.WriteString("BEGIN:VCARD\r\nVERSION:3.0\r\n")
+	w.WriteString("FN:" + vcardEscaper.Replace(name) + "\r\n")
+	w.WriteString("N:" + vcardEscaper.Replace(name) + ";;;;\r\n")
+	if card.phone != "" {
+		// waid lets WhatsApp link the card to the account
+		w.WriteString("TEL;type=CELL;waid=" + strings.TrimPrefix(card.phone, "+") + ":" + card.phone + "\r\n")
+	}
+	if card.note != "" {
+		w.WriteString("NOTE:" + vcardEscaper.Replace(card.note) + "\r\n")
+	}
+	if card.photo != "" {
+		w.WriteString("PHOTO;VALUE=URI:" + card.photo + "\r\n")
+	}
+	w.WriteString("END:VCARD\r\n")
+}
@@ -0,0 +1,51 @@
+package exporter
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"time"
+
+	"whatsapp-parser/internal/domain"
+)
+
+var csvHeader = []string{"id", "chat_jid", "timestamp", "from_me", "sender_jid", "sender_name", "type", "text", "attachment", "media_hash"}
+
+// csvExporter writes one row per message
+type csvExporter struct {
+	w *csv.Writer
+}
+
+func newCSV(w io.Writer) (Exporter, error) {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return nil, err
+	}
+	return &csvExporter{w: cw}, nil
+}
+
+func (e *csvExporter) WriteChat(domain.Chat) error {
+	return nil
+}
+
+func (e *csvExporter) WriteMessage(msg domain.Message) error {
+	return e.w.Write([]string{
+		msg.ID,
+		msg.ChatJID,
+		msg.Timestamp.UTC().Format(time.RFC3339),
+		strconv.FormatBool(msg.FromMe),
+		msg.SenderJID,
+		msg.SenderName,
+		msg.Type,
+		msg.Text,
+		msg.Attachment,
+		msg.MediaHash,
+	})
+}
+
+func (e *csvExporter) Close() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+func (e *csvExporter) Discard() {}
@@ -0,0 +1,79 @@
+// Package exporter writes chat histories as JSON Lines, CSV, HTML or SQLite.
+// Exporters receive messages one at a time and write them out as they arrive,
+// so a chat is never held in memory as a whole.
+package exporter
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"whatsapp-parser/internal/domain"
+)
+
+// ErrUnknownFormat is returned for export formats that are not supported
+var ErrUnknownFormat = errors.New("unknown export format")
+
+// Exporter writes a chat in one file format. Either Close, which finishes the
+// file, or Discard, which drops a failed export, must be called at the end.
+type Exporter interface {
+	domain.ChatWriter
+	Close() error
+	Discard()
+}
+
+// Format describes an export file format
+type Format struct {
+	Name        string
+	ContentType string
+	Extension   string
+	// Thumbnails tells whether the format embeds media previews
+	Thumbnails bool
+	open       func(w io.Writer) (Exporter, error)
+}
+
+// New starts an export written to w
+func (f *Format) New(w io.Writer) (Exporter, error) {
+	return f.open(w)
+}
+
+var formats = []*Format{
+	{Name: "jsonl", ContentType: "application/x-ndjson", Extension: ".jsonl", open: newJSONL},
+	{Name: "csv", ContentType: "text/csv; charset=utf-8", Extension: ".csv", open: newCSV},
+	{Name: "html", ContentType: "text/html; charset=utf-8", Extension: ".html", Thumbnails: true, open: newHTML},
+	{Name: "sqlite", ContentType: "application/vnd.sqlite3", Extension: ".sqlite", open: newSQLite},
+}
+
+// Lookup returns the format with the given name, case-insensitively
+func Lookup(name string) (*Format, error) {
+	for _, f := range formats {
+		if strings.EqualFold(f.Name, name) {
+			return f, nil
+		}
+	}
+	return nil, fmt.Errorf("%w %q, expected one of %s", ErrUnknownFormat, name, strings.Join(Names(), ", "))
+}
+
+// Names lists the supported format names
+func Names() []string {
+	names := make([]string, len(formats))
+	for i, f := range formats {
+		names[i] = f.Name
+	}
+	return names
+}
+
+// senderLabel returns a readable name for the author of a message
+func senderLabel(msg domain.Message) string {
+	switch {
+	case msg.FromMe:
+		return "You"
+	case msg.SenderName != "":
+		return msg.SenderName
+	}
+	if number := domain.PhoneFromJID(msg.SenderJID); number != "" {
+		return number
+	}
+	return msg.SenderJID
+}
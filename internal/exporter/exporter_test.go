@@ -0,0 +1,240 @@
+package exporter
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"whatsapp-parser/internal/domain"
+)
+
+var (
+	testChat = domain.Chat{
+		JID:     "120363012345678901@g.us",
+		Title:   `Team <b>"ops"</b>`,
+		IsGroup: true,
+		Participants: []domain.Participant{
+			{JID: "79991234567@c.us", Name: "Anna <admin>", Admin: true},
+			{JID: "79997654321@c.us"},
+		},
+	}
+	testMessages = []domain.Message{
+		{
+			ID:         "m1",
+			ChatJID:    testChat.JID,
+			SenderJID:  "79991234567@c.us",
+			SenderName: `Anna "A", <img src=x onerror=alert(1)>`,
+			Type:       "chat",
+			Text:       "line one, with comma\nline \"two\"\r\n<script>alert('x')</script>",
+			Timestamp:  time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC),
+		},
+		{
+			ID:         "m2",
+			ChatJID:    testChat.JID,
+			FromMe:     true,
+			Type:       "image",
+			Attachment: `photo "1".jpg`,
+			Thumbnail:  `"><script>alert(1)</script>`,
+			Timestamp:  time.Date(2024, 3, 1, 9, 31, 0, 0, time.UTC),
+		},
+		{
+			ID:        "m3",
+			ChatJID:   testChat.JID,
+			SenderJID: "79997654321@c.us",
+			Type:      "poll_creation",
+			Text:      "Lunch?",
+			Poll:      &domain.Poll{Question: "Lunch?", Options: []domain.PollOption{{Name: "<yes>", Votes: 2}}, Voters: 2},
+			Timestamp: time.Date(2024, 3, 2, 12, 0, 0, 0, time.UTC),
+		},
+	}
+)
+
+// export writes the test chat in the named format
+func export(t *testing.T, name string) []byte {
+	t.Helper()
+	format, err := Lookup(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	e, err := format.New(&buf)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if err := e.WriteChat(testChat); err != nil {
+		t.Fatalf("WriteChat: %v", err)
+	}
+	for _, msg := range testMessages {
+		if err := e.WriteMessage(msg); err != nil {
+			t.Fatalf("WriteMessage(%s): %v", msg.ID, err)
+		}
+	}
+	if err := e.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestLookup(t *testing.T) {
+	for _, name := range []string{"jsonl", "CSV", "Html", "sqlite"} {
+		if _, err := Lookup(name); err != nil {
+			t.Errorf("Lookup(%q): %v", name, err)
+		}
+	}
+	if _, err := Lookup("xlsx"); err == nil {
+		t.Error("Lookup(xlsx) succeeded")
+	}
+}
+
+func TestCSV(t *testing.T) {
+	rows, err := csv.NewReader(bytes.NewReader(export(t, "csv"))).ReadAll()
+	if err != nil {
+		t.Fatalf("exported CSV does not parse: %v", err)
+	}
+	if len(rows) != len(testMessages)+1 {
+		t.Fatalf("got %d rows, want a header and %d messages", len(rows), len(testMessages))
+	}
+	if !reflect.DeepEqual(rows[0], csvHeader) {
+		t.Errorf("header = %q", rows[0])
+	}
+
+	// Commas, quotes and line breaks survive a round trip; csv.Reader turns \r\n into \n
+	want := []string{"m1", testChat.JID, "2024-03-01T09:30:00Z", "false", "79991234567@c.us",
+		testMessages[0].SenderName, "chat", strings.Replace(testMessages[0].Text, "\r\n", "\n", 1), "", ""}
+	if !reflect.DeepEqual(rows[1], want) {
+		t.Errorf("row 1 =\n%q\nwant\n%q", rows[1], want)
+	}
+	if rows[2][3] != "true" || rows[2][8] != testMessages[1].Attachment {
+		t.Errorf("row 2 = %q", rows[2])
+	}
+}
+
+func TestHTML(t *testing.T) {
+	out := string(export(t, "html"))
+
+	for _, raw := range []string{`<b>"ops"`, "<script>alert", "<img", "<admin>", "<yes>"} {
+		if strings.Contains(out, raw) {
+			t.Errorf("transcript contains unescaped %s", raw)
+		}
+	}
+	for _, escaped := range []string{
+		"<title>Team &lt;b&gt;&#34;ops&#34;&lt;/b&gt;</title>",
+		"Anna &lt;admin&gt; – 79991234567@c.us (admin)",
+		`Anna &#34;A&#34;, &lt;img src=x onerror=alert(1)&gt;`,
+		"&lt;script&gt;alert(&#39;x&#39;)&lt;/script&gt;",
+		"[image: photo &#34;1&#34;.jpg]",
+		"&lt;yes&gt; <b>2</b>",
+	} {
+		if !strings.Contains(out, escaped) {
+			t.Errorf("transcript lacks %s", escaped)
+		}
+	}
+	// A thumbnail that is not base64 is not used as an image source
+	if strings.Contains(out, "data:image/jpeg") {
+		t.Error("invalid thumbnail was embedded")
+	}
+	if n := strings.Count(out, `<div class="day">`); n != 2 {
+		t.Errorf("got %d day separators, want 2", n)
+	}
+	if !strings.HasSuffix(strings.TrimSpace(out), "</html>") {
+		t.Error("transcript is not finished")
+	}
+}
+
+func TestHTMLWithoutChat(t *testing.T) {
+	var buf bytes.Buffer
+	e, err := newHTML(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if out := buf.String(); !strings.Contains(out, "<html") || !strings.Contains(out, "</html>") {
+		t.Errorf("empty transcript is not a document:\n%s", out)
+	}
+}
+
+func TestSQLite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "chat.sqlite")
+	if err := os.WriteFile(path, export(t, "sqlite"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	columns := map[string][]string{
+		"chats":        {"jid", "title", "is_group"},
+		"participants": {"chat_jid", "jid", "name", "admin"},
+		"messages":     {"id", "chat_jid", "timestamp", "from_me", "sender_jid", "sender_name", "type", "text", "attachment", "media_hash", "payload"},
+	}
+	for table, want := range columns {
+		rows, err := db.Query(`SELECT name FROM pragma_table_info(?)`, table)
+		if err != nil {
+			t.Fatalf("table_info(%s): %v", table, err)
+		}
+		var got []string
+		for rows.Next() {
+			var name string
+			if err := rows.Scan(&name); err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, name)
+		}
+		rows.Close()
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("columns of %s = %q, want %q", table, got, want)
+		}
+	}
+
+	var title string
+	var isGroup bool
+	if err := db.QueryRow(`SELECT title, is_group FROM chats WHERE jid = ?`, testChat.JID).Scan(&title, &isGroup); err != nil {
+		t.Fatalf("chat row: %v", err)
+	}
+	if title != testChat.Title || !isGroup {
+		t.Errorf("chat = %q, group %v", title, isGroup)
+	}
+
+	var participants int
+	if err := db.QueryRow(`SELECT count(*) FROM participants WHERE chat_jid = ?`, testChat.JID).Scan(&participants); err != nil {
+		t.Fatal(err)
+	}
+	if participants != len(testChat.Participants) {
+		t.Errorf("got %d participants, want %d", participants, len(testChat.Participants))
+	}
+
+	rows, err := db.Query(`SELECT id, timestamp, from_me, text, payload FROM messages ORDER BY timestamp`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	i := 0
+	for ; rows.Next(); i++ {
+		var id, text, payload string
+		var ts int64
+		var fromMe bool
+		if err := rows.Scan(&id, &ts, &fromMe, &text, &payload); err != nil {
+			t.Fatal(err)
+		}
+		msg := testMessages[i]
+		if id != msg.ID || ts != msg.Timestamp.Unix() || fromMe != msg.FromMe || text != msg.Text {
+			t.Errorf("message %d = %s %d %v %q", i, id, ts, fromMe, text)
+		}
+		if hasPayload := payload != ""; hasPayload != (msg.Poll != nil) {
+			t.Errorf("message %s payload = %q", id, payload)
+		}
+	}
+	if i != len(testMessages) {
+		t.Errorf("got %d messages, want %d", i, len(testMessages))
+	}
+}
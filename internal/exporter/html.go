@@ -0,0 +1,178 @@
+package exporter
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"io"
+	"time"
+
+	"whatsapp-parser/internal/domain"
+)
+
+// htmlTemplates render a self-contained transcript: the header is written with
+// the chat, a block per message as it arrives and the footer on Close
+var htmlTemplates = template.Must(template.New("html").Parse(`{{define "header"}}<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+body{margin:0;font:14px/1.4 -apple-system,"Segoe UI",Roboto,sans-serif;background:#efeae2;color:#111b21}
+header{position:sticky;top:0;background:#008069;color:#fff;padding:12px 16px;z-index:1}
+header h1{margin:0;font-size:18px}
+header details{font-size:12px;margin-top:4px}
+header input{margin-top:8px;width:100%;max-width:400px;padding:6px;border:0;border-radius:4px}
+main{max-width:900px;margin:0 auto;padding:12px}
+.day{text-align:center;margin:12px 0}
+.day span{background:#fff;border-radius:6px;padding:4px 10px;font-size:12px;color:#54656f}
+.msg{max-width:70%;margin:4px 0;padding:6px 8px;border-radius:8px;background:#fff;box-shadow:0 1px .5px rgba(0,0,0,.13);clear:both;float:left;word-wrap:break-word}
+.msg.me{float:right;background:#d9fdd3}
+.msg.system{float:none;margin:8px auto;max-width:80%;text-align:center;background:#ffeecd;font-size:12px}
+.sender{font-weight:600;color:#1f7aad;font-size:13px}
+.text{white-space:pre-wrap}
+.media{color:#54656f;font-style:italic}
+.msg img{display:block;max-width:240px;border-radius:6px;margin-bottom:4px}
+.poll{margin:4px 0;padding-left:18px}
+.time{float:right;margin-left:8px;font-size:11px;color:#667781}
+.clear{clear:both}
+.hidden{display:none}
+</style>
+</head>
+<body>
+<header>
+<h1>{{.Title}}</h1>
+<div>{{.JID}}</div>
+{{- if .Participants}}
+<details><summary>{{len .Participants}} participants</summary><ul>
+{{- range .Participants}}<li>{{if .Name}}{{.Name}} – {{end}}{{.JID}}{{if .Admin}} (admin){{end}}</li>{{end}}
+</ul></details>
+{{- end}}
+<input id="filter" type="search" placeholder="Filter messages">
+</header>
+<main>
+{{end}}
+
+{{define "day"}}<div class="day"><span>{{.}}</span></div>
+{{end}}
+
+{{define "message"}}<div class="msg{{if .FromMe}} me{{end}}{{if eq .Type "system"}} system{{end}}" id="{{.ID}}">
+{{- if .ShowSender}}<div class="sender">{{.Sender}}</div>{{end}}
+{{- if .Thumbnail}}<img src="{{.Thumbnail}}" alt="{{.Type}}">{{end}}
+{{- if and .Media (not .Thumbnail)}}<div class="media">[{{.Type}}{{if .Attachment}}: {{.Attachment}}{{end}}]</div>{{end}}
+{{- if .Text}}<span class="text">{{.Text}}</span>{{end}}
+{{- if .Map}}<div><a href="{{.Map}}" rel="noopener" target="_blank">{{.Coordinates}}</a></div>{{end}}
+{{- with .Poll}}<ul class="poll">{{range .Options}}<li>{{.Name}} <b>{{.Votes}}</b></li>{{end}}</ul><div class="media">{{.Voters}} voted</div>{{end}}
+<time class="time" datetime="{{.Time}}">{{.Clock}}</time></div>
+{{end}}
+
+{{define "footer"}}<div class="clear"></div>
+</main>
+<script>
+document.querySelectorAll("time").forEach(function (t) {
+  var d = new Date(t.getAttribute("datetime"));
+  t.title = d.toLocaleString();
+  t.textContent = d.toLocaleTimeString([], {hour: "2-digit", minute: "2-digit"});
+});
+document.getElementById("filter").addEventListener("input", function (e) {
+  var q = e.target.value.toLowerCase();
+  document.querySelectorAll(".msg").forEach(function (m) {
+    m.classList.toggle("hidden", q !== "" && m.textContent.toLowerCase().indexOf(q) < 0);
+  });
+});
+</script>
+</body>
+</html>
+{{end}}`))
+
+// htmlMessage is the view of a message in the transcript
+type htmlMessage struct {
+	ID         string
+	Type       string
+	FromMe     bool
+	ShowSender bool
+	Sender     string
+	Text       string
+	Attachment string
+	Media      bool
+	Thumbnail  template.URL
+	// Map links a location to a map showing Coordinates
+	Map         string
+	Coordinates string
+	Poll        *domain.Poll
+	Time        string
+	Clock       string
+}
+
+// htmlExporter writes a transcript that can be opened in a browser without
+// any other files; media previews are embedded as data URIs
+type htmlExporter struct {
+	buf     *bufio.Writer
+	group   bool
+	day     string
+	started bool
+}
+
+func newHTML(w io.Writer) (Exporter, error) {
+	return &htmlExporter{buf: bufio.NewWriter(w)}, nil
+}
+
+func (e *htmlExporter) WriteChat(chat domain.Chat) error {
+	if chat.Title == "" {
+		chat.Title = chat.JID
+	}
+	e.group = chat.IsGroup
+	e.started = true
+	return htmlTemplates.ExecuteTemplate(e.buf, "header", chat)
+}
+
+func (e *htmlExporter) WriteMessage(msg domain.Message) error {
+	ts := msg.Timestamp.UTC()
+	if day := ts.Format("2 January 2006"); day != e.day {
+		e.day = day
+		if err := htmlTemplates.ExecuteTemplate(e.buf, "day", day); err != nil {
+			return err
+		}
+	}
+
+	view := htmlMessage{
+		ID:         msg.ID,
+		Type:       msg.Type,
+		FromMe:     msg.FromMe,
+		ShowSender: e.group && !msg.FromMe && msg.Type != "system",
+		Sender:     senderLabel(msg),
+		Text:       msg.Text,
+		Attachment: msg.Attachment,
+		Media:      msg.Type != "chat" && msg.Type != "system",
+		Poll:       msg.Poll,
+		Time:       ts.Format(time.RFC3339),
+		Clock:      ts.Format("15:04"),
+	}
+	if l := msg.Location; l != nil {
+		view.Coordinates = fmt.Sprintf("%.6f, %.6f", l.Latitude, l.Longitude)
+		view.Map = fmt.Sprintf("https://maps.google.com/?q=%f,%f", l.Latitude, l.Longitude)
+	}
+	// Only well-formed base64 is trusted as an image source
+	if msg.Thumbnail != "" {
+		if _, err := base64.StdEncoding.DecodeString(msg.Thumbnail); err == nil {
+			view.Thumbnail = template.URL("data:image/jpeg;base64," + msg.Thumbnail)
+		}
+	}
+	return htmlTemplates.ExecuteTemplate(e.buf, "message", view)
+}
+
+func (e *htmlExporter) Close() error {
+	if !e.started {
+		if err := e.WriteChat(domain.Chat{}); err != nil {
+			return err
+		}
+	}
+	if err := htmlTemplates.ExecuteTemplate(e.buf, "footer", nil); err != nil {
+		return err
+	}
+	return e.buf.Flush()
+}
+
+func (e *htmlExporter) Discard() {}
@@ -0,0 +1,38 @@
+package exporter
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+
+	"whatsapp-parser/internal/domain"
+)
+
+// jsonlExporter writes the chat on the first line and one message per line after it
+type jsonlExporter struct {
+	buf *bufio.Writer
+	enc *json.Encoder
+}
+
+func newJSONL(w io.Writer) (Exporter, error) {
+	buf := bufio.NewWriter(w)
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	return &jsonlExporter{buf: buf, enc: enc}, nil
+}
+
+func (e *jsonlExporter) WriteChat(chat domain.Chat) error {
+	return e.enc.Encode(struct {
+		Chat domain.Chat `json:"chat"`
+	}{chat})
+}
+
+func (e *jsonlExporter) WriteMessage(msg domain.Message) error {
+	return e.enc.Encode(msg)
+}
+
+func (e *jsonlExporter) Close() error {
+	return e.buf.Flush()
+}
+
+func (e *jsonlExporter) Discard() {}
@@ -0,0 +1,163 @@
+package exporter
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"whatsapp-parser/internal/domain"
+
+	_ "modernc.org/sqlite" // Registers the "sqlite" driver
+)
+
+const sqliteSchema = `
+CREATE TABLE chats (
+	jid TEXT PRIMARY KEY,
+	title TEXT NOT NULL,
+	is_group INTEGER NOT NULL
+);
+CREATE TABLE participants (
+	chat_jid TEXT NOT NULL REFERENCES chats(jid),
+	jid TEXT NOT NULL,
+	name TEXT NOT NULL,
+	admin INTEGER NOT NULL,
+	PRIMARY KEY (chat_jid, jid)
+);
+CREATE TABLE messages (
+	id TEXT PRIMARY KEY,
+	chat_jid TEXT NOT NULL REFERENCES chats(jid),
+	timestamp INTEGER NOT NULL,
+	from_me INTEGER NOT NULL,
+	sender_jid TEXT NOT NULL,
+	sender_name TEXT NOT NULL,
+	type TEXT NOT NULL,
+	text TEXT NOT NULL,
+	attachment TEXT NOT NULL,
+	media_hash TEXT NOT NULL,
+	payload TEXT NOT NULL
+);
+CREATE INDEX messages_chat_time ON messages (chat_jid, timestamp);
+`
+
+// sqliteExporter builds the database in a temporary file, inserting messages
+// as they arrive, and copies the file to the output on Close
+type sqliteExporter struct {
+	out    io.Writer
+	path   string
+	db     *sql.DB
+	tx     *sql.Tx
+	insert *sql.Stmt
+}
+
+func newSQLite(w io.Writer) (Exporter, error) {
+	file, err := os.CreateTemp("", "chat-export-*.sqlite")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create database file: %v", err)
+	}
+	path := file.Name()
+	file.Close()
+
+	e := &sqliteExporter{out: w, path: path}
+	if err := e.open(); err != nil {
+		e.cleanup()
+		return nil, err
+	}
+	return e, nil
+}
+
+func (e *sqliteExporter) open() error {
+	db, err := sql.Open("sqlite", e.path)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %v", err)
+	}
+	e.db = db
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		return fmt.Errorf("failed to create tables: %v", err)
+	}
+	if e.tx, err = db.Begin(); err != nil {
+		return fmt.Errorf("failed to start transaction: %v", err)
+	}
+	e.insert, err = e.tx.Prepare(`INSERT OR REPLACE INTO messages
+		(id, chat_jid, timestamp, from_me, sender_jid, sender_name, type, text, attachment, media_hash, payload)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare insert: %v", err)
+	}
+	return nil
+}
+
+func (e *sqliteExporter) WriteChat(chat domain.Chat) error {
+	if _, err := e.tx.Exec(`INSERT OR REPLACE INTO chats (jid, title, is_group) VALUES (?, ?, ?)`,
+		chat.JID, chat.Title, chat.IsGroup); err != nil {
+		return fmt.Errorf("failed to insert chat: %v", err)
+	}
+	for _, p := range chat.Participants {
+		if _, err := e.tx.Exec(`INSERT OR REPLACE INTO participants (chat_jid, jid, name, admin) VALUES (?, ?, ?, ?)`,
+			chat.JID, p.JID, p.Name, p.Admin); err != nil {
+			return fmt.Errorf("failed to insert participant: %v", err)
+		}
+	}
+	return nil
+}
+
+func (e *sqliteExporter) WriteMessage(msg domain.Message) error {
+	// Locations, contacts and polls are kept as the JSON of their message fields
+	var payload []byte
+	if msg.Location != nil || len(msg.Contacts) > 0 || msg.Poll != nil {
+		var err error
+		payload, err = json.Marshal(struct {
+			Location *domain.Location       `json:"location,omitempty"`
+			Contacts []domain.SharedContact `json:"contacts,omitempty"`
+			Poll     *domain.Poll           `json:"poll,omitempty"`
+		}{msg.Location, msg.Contacts, msg.Poll})
+		if err != nil {
+			return fmt.Errorf("failed to encode message payload: %v", err)
+		}
+	}
+	if _, err := e.insert.Exec(msg.ID, msg.ChatJID, msg.Timestamp.Unix(), msg.FromMe,
+		msg.SenderJID, msg.SenderName, msg.Type, msg.Text, msg.Attachment, msg.MediaHash, string(payload)); err != nil {
+		return fmt.Errorf("failed to insert message: %v", err)
+	}
+	return nil
+}
+
+func (e *sqliteExporter) Close() error {
+	defer e.cleanup()
+
+	if err := e.insert.Close(); err != nil {
+		return fmt.Errorf("failed to finish inserts: %v", err)
+	}
+	if err := e.tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit database: %v", err)
+	}
+	if err := e.db.Close(); err != nil {
+		return fmt.Errorf("failed to close database: %v", err)
+	}
+	e.db = nil
+
+	file, err := os.Open(e.path)
+	if err != nil {
+		return fmt.Errorf("failed to open database file: %v", err)
+	}
+	defer file.Close()
+	if _, err := io.Copy(e.out, file); err != nil {
+		return fmt.Errorf("failed to write database: %v", err)
+	}
+	return nil
+}
+
+func (e *sqliteExporter) Discard() {
+	e.cleanup()
+}
+
+// cleanup closes the database if still open and removes the temporary file
+func (e *sqliteExporter) cleanup() {
+	if e.db != nil {
+		e.db.Close()
+		e.db = nil
+	}
+	os.Remove(e.path)
+	os.Remove(e.path + "-journal")
+}
@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"whatsapp-parser/internal/domain"
+)
+
+type apiKeyRepository struct {
+	filePath string
+	static   []domain.APIKey
+	mu       sync.RWMutex
+}
+
+// NewAPIKeyRepository creates an API key repository backed by api_keys.json in
+// storagePath. Keys from static (usually the config file) are always accepted
+// and are never written to disk.
+func NewAPIKeyRepository(storagePath string, static []domain.APIKey) (domain.APIKeyRepository, error) {
+	if err := os.MkdirAll(storagePath, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create storage directory: %v", err)
+	}
+
+	return &apiKeyRepository{
+		filePath: filepath.Join(storagePath, "api_keys.json"),
+		static:   static,
+	}, nil
+}
+
+func (r *apiKeyRepository) Save(key *domain.APIKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	keys, err := r.load()
+	if err != nil {
+		return err
+	}
+
+	replaced := false
+	for i := range keys {
+		if keys[i].ID == key.ID {
+			keys[i] = *key
+			replaced = true
+		}
+	}
+	if !replaced {
+		keys = append(keys, *key)
+	}
+
+	data, err := json.MarshalIndent(keys, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal API keys: %v", err)
+	}
+	if err := os.WriteFile(r.filePath, data, 0600); err != nil {
+		return fmt.Errorf("failed to write API keys file: %v", err)
+	}
+
+	return nil
+}
+
+func (r *apiKeyRepository) GetByHash(hash string) (*domain.APIKey, error) {
+	for i := range r.static {
+		if r.static[i].Hash == hash {
+			key := r.static[i]
+			return &key, nil
+		}
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	keys, err := r.load()
+	if err != nil {
+		return nil, err
+	}
+	for i := range keys {
+		if keys[i].Hash == hash {
+			return &keys[i], nil
+		}
+	}
+
+	return nil, nil
+}
+
+// load reads all stored keys; callers must hold r.mu
+func (r *apiKeyRepository) load() ([]domain.APIKey, error) {
+	data, err := os.ReadFile(r.filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read API keys file: %v", err)
+	}
+
+	var keys []domain.APIKey
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal API keys: %v", err)
+	}
+	return keys, nil
+}
@@ -0,0 +1,257 @@
+package repository
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"whatsapp-parser/internal/domain"
+)
+
+type campaignRepository struct {
+	storagePath string
+	mu          sync.RWMutex
+}
+
+// NewCampaignRepository creates a campaign repository storing one file per
+// campaign, recipients included, in a directory per tenant. Outcomes of single
+// recipients are appended to a progress log next to it, which is folded into
+// the campaign file on the next Save.
+func NewCampaignRepository(storagePath string) (domain.CampaignRepository, error) {
+	if err := os.MkdirAll(storagePath, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create storage directory: %v", err)
+	}
+
+	return &campaignRepository{
+		storagePath: storagePath,
+	}, nil
+}
+
+func (r *campaignRepository) tenantDir(tenantID string) (string, error) {
+	if !domain.ValidTenantID(tenantID) {
+		return "", domain.ErrInvalidTenant
+	}
+	return filepath.Join(r.storagePath, tenantID), nil
+}
+
+func (r *campaignRepository) campaignPath(tenantID, id string) (string, error) {
+	dir, err := r.tenantDir(tenantID)
+	if err != nil {
+		return "", err
+	}
+	if id == "" || strings.ContainsAny(id, `/\.`) {
+		return "", fmt.Errorf("invalid campaign ID %q", id)
+	}
+	return filepath.Join(dir, id+".json"), nil
+}
+
+func (r *campaignRepository) Save(campaign *domain.Campaign) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	filePath, err := r.campaignPath(campaign.TenantID, campaign.ID)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(campaign)
+	if err != nil {
+		return fmt.Errorf("failed to marshal campaign: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return fmt.Errorf("failed to create tenant directory: %v", err)
+	}
+
+	// Write through a temporary file so a crash never leaves a truncated campaign
+	tmpPath := filePath + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write campaign file: %v", err)
+	}
+	if err := os.Rename(tmpPath, filePath); err != nil {
+		return fmt.Errorf("failed to replace campaign file: %v", err)
+	}
+	// The file now holds everything the log recorded
+	if err := os.Remove(progressPath(filePath)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove campaign progress log: %v", err)
+	}
+
+	return nil
+}
+
+// progressEntry is a line of a campaign's progress log
+type progressEntry struct {
+	Index     int                      `json:"index"`
+	Recipient domain.CampaignRecipient `json:"recipient"`
+	UpdatedAt time.Time                `json:"updated_at"`
+}
+
+func progressPath(campaignPath string) string {
+	return strings.TrimSuffix(campaignPath, ".json") + ".progress"
+}
+
+func (r *campaignRepository) SaveRecipient(campaign *domain.Campaign, index int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	filePath, err := r.campaignPath(campaign.TenantID, campaign.ID)
+	if err != nil {
+		return err
+	}
+	if index < 0 || index >= len(campaign.Recipients) {
+		return fmt.Errorf("campaign %s has no recipient %d", campaign.ID, index)
+	}
+
+	line, err := json.Marshal(progressEntry{Index: index, Recipient: campaign.Recipients[index], UpdatedAt: campaign.UpdatedAt})
+	if err != nil {
+		return fmt.Errorf("failed to marshal campaign progress: %v", err)
+	}
+	f, err := os.OpenFile(progressPath(filePath), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open campaign progress log: %v", err)
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write campaign progress log: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write campaign progress log: %v", err)
+	}
+	return nil
+}
+
+func (r *campaignRepository) GetByID(tenantID, id string) (*domain.Campaign, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	filePath, err := r.campaignPath(tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+	return readCampaign(filePath)
+}
+
+func (r *campaignRepository) List(tenantID string) ([]*domain.Campaign, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	dir, err := r.tenantDir(tenantID)
+	if err != nil {
+		return nil, err
+	}
+	campaigns, err := readCampaigns(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(campaigns, func(i, j int) bool {
+		return campaigns[i].CreatedAt.After(campaigns[j].CreatedAt)
+	})
+	return campaigns, nil
+}
+
+func (r *campaignRepository) ListRunning() ([]*domain.Campaign, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	entries, err := os.ReadDir(r.storagePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read storage directory: %v", err)
+	}
+
+	var running []*domain.Campaign
+	for _, entry := range entries {
+		if !entry.IsDir() || !domain.ValidTenantID(entry.Name()) {
+			continue
+		}
+		campaigns, err := readCampaigns(filepath.Join(r.storagePath, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		for _, campaign := range campaigns {
+			if campaign.Status == domain.CampaignRunning {
+				running = append(running, campaign)
+			}
+		}
+	}
+	return running, nil
+}
+
+func readCampaigns(dir string) ([]*domain.Campaign, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read campaigns directory: %v", err)
+	}
+
+	campaigns := make([]*domain.Campaign, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		campaign, err := readCampaign(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		if campaign != nil {
+			campaigns = append(campaigns, campaign)
+		}
+	}
+	return campaigns, nil
+}
+
+func readCampaign(filePath string) (*domain.Campaign, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read campaign file: %v", err)
+	}
+
+	var campaign domain.Campaign
+	if err := json.Unmarshal(data, &campaign); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal campaign: %v", err)
+	}
+	if err := applyProgress(&campaign, progressPath(filePath)); err != nil {
+		return nil, err
+	}
+	return &campaign, nil
+}
+
+// applyProgress replays the progress log of a campaign over its file. A last
+// line cut short by a crash is ignored.
+func applyProgress(campaign *domain.Campaign, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read campaign progress log: %v", err)
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(data)+1)
+	for scanner.Scan() {
+		var entry progressEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			break
+		}
+		if entry.Index < 0 || entry.Index >= len(campaign.Recipients) {
+			continue
+		}
+		campaign.Recipients[entry.Index] = entry.Recipient
+		if entry.UpdatedAt.After(campaign.UpdatedAt) {
+			campaign.UpdatedAt = entry.UpdatedAt
+		}
+	}
+	return nil
+}
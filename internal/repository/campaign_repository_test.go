@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"whatsapp-parser/internal/domain"
+)
+
+func TestCampaignProgressLog(t *testing.T) {
+	dir := t.TempDir()
+	repo, err := NewCampaignRepository(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	created := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	c := &domain.Campaign{
+		ID:        "c1",
+		TenantID:  "acme",
+		Status:    domain.CampaignRunning,
+		CreatedAt: created,
+		UpdatedAt: created,
+		Recipients: []domain.CampaignRecipient{
+			{Row: 1, PhoneNumber: "+79991234567", Status: domain.RecipientPending},
+			{Row: 2, PhoneNumber: "+79997654321", Status: domain.RecipientPending},
+		},
+	}
+	if err := repo.Save(c); err != nil {
+		t.Fatal(err)
+	}
+
+	c.Recipients[1].Status = domain.RecipientSent
+	c.Recipients[1].MessageID = "m2"
+	c.UpdatedAt = created.Add(time.Minute)
+	if err := repo.SaveRecipient(c, 1); err != nil {
+		t.Fatalf("SaveRecipient: %v", err)
+	}
+	c.Recipients[0].Status = domain.RecipientFailed
+	c.Recipients[0].Error = "boom"
+	c.UpdatedAt = created.Add(2 * time.Minute)
+	if err := repo.SaveRecipient(c, 0); err != nil {
+		t.Fatalf("SaveRecipient: %v", err)
+	}
+
+	progress := filepath.Join(dir, "acme", "c1.progress")
+	// A line cut short by a crash is ignored
+	f, err := os.OpenFile(progress, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"index":1,"recipient":{"status":"fai`)
+	f.Close()
+
+	check := func(got *domain.Campaign) {
+		t.Helper()
+		if got == nil {
+			t.Fatal("campaign not found")
+		}
+		r0, r1 := got.Recipients[0], got.Recipients[1]
+		if r0.Status != domain.RecipientFailed || r0.Error != "boom" || r1.Status != domain.RecipientSent || r1.MessageID != "m2" {
+			t.Errorf("recipients = %+v", got.Recipients)
+		}
+		if !got.UpdatedAt.Equal(c.UpdatedAt) {
+			t.Errorf("UpdatedAt = %s, want %s", got.UpdatedAt, c.UpdatedAt)
+		}
+	}
+	got, err := repo.GetByID("acme", "c1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	check(got)
+	running, err := repo.ListRunning()
+	if err != nil || len(running) != 1 {
+		t.Fatalf("ListRunning = %d campaigns, %v", len(running), err)
+	}
+	check(running[0])
+
+	// Saving the campaign folds the log into its file
+	if err := repo.Save(got); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(progress); !os.IsNotExist(err) {
+		t.Errorf("progress log still exists after Save: %v", err)
+	}
+	got, err = repo.GetByID("acme", "c1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	check(got)
+
+	if err := repo.SaveRecipient(c, 2); err == nil {
+		t.Error("SaveRecipient of a missing recipient succeeded")
+	}
+}
@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"whatsapp-parser/internal/domain"
+)
+
+type contactCheckRepository struct {
+	storagePath string
+	mu          sync.Mutex
+	cache       map[string]map[string]domain.ContactCheck // Loaded files by tenant
+}
+
+// NewContactCheckRepository creates a repository keeping registration checks
+// in one file per tenant
+func NewContactCheckRepository(storagePath string) (domain.ContactCheckRepository, error) {
+	if err := os.MkdirAll(storagePath, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create storage directory: %v", err)
+	}
+
+	return &contactCheckRepository{
+		storagePath: storagePath,
+		cache:       make(map[string]map[string]domain.ContactCheck),
+	}, nil
+}
+
+func (r *contactCheckRepository) Get(tenantID, phoneNumber string) (*domain.ContactCheck, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	checks, err := r.load(tenantID)
+	if err != nil {
+		return nil, err
+	}
+	check, ok := checks[phoneNumber]
+	if !ok {
+		return nil, nil
+	}
+	return &check, nil
+}
+
+func (r *contactCheckRepository) Save(tenantID string, check *domain.ContactCheck) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	checks, err := r.load(tenantID)
+	if err != nil {
+		return err
+	}
+	checks[check.PhoneNumber] = *check
+
+	data, err := json.Marshal(checks)
+	if err != nil {
+		return fmt.Errorf("failed to marshal contact checks: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(r.storagePath, tenantID+".json"), data, 0644); err != nil {
+		return fmt.Errorf("failed to write contact checks file: %v", err)
+	}
+
+	return nil
+}
+
+// load returns the checks of a tenant, reading the file once; callers must hold r.mu
+func (r *contactCheckRepository) load(tenantID string) (map[string]domain.ContactCheck, error) {
+	if !domain.ValidTenantID(tenantID) {
+		return nil, domain.ErrInvalidTenant
+	}
+	if checks, ok := r.cache[tenantID]; ok {
+		return checks, nil
+	}
+
+	checks := make(map[string]domain.ContactCheck)
+	data, err := os.ReadFile(filepath.Join(r.storagePath, tenantID+".json"))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read contact checks file: %v", err)
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, &checks); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal contact checks: %v", err)
+		}
+	}
+
+	r.cache[tenantID] = checks
+	return checks, nil
+}
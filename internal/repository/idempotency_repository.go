@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"whatsapp-parser/internal/domain"
+)
+
+type idempotencyRepository struct {
+	storagePath string
+	mu          sync.Mutex
+	cache       map[string]map[string]domain.IdempotencyRecord // Loaded files by tenant
+}
+
+// NewIdempotencyRepository creates a repository keeping the idempotency
+// records of each tenant in one file. Expired records are dropped on write.
+func NewIdempotencyRepository(storagePath string) (domain.IdempotencyRepository, error) {
+	if err := os.MkdirAll(storagePath, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create storage directory: %v", err)
+	}
+
+	return &idempotencyRepository{
+		storagePath: storagePath,
+		cache:       make(map[string]map[string]domain.IdempotencyRecord),
+	}, nil
+}
+
+func (r *idempotencyRepository) Get(tenantID, key string) (*domain.IdempotencyRecord, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	records, err := r.load(tenantID)
+	if err != nil {
+		return nil, err
+	}
+	record, ok := records[key]
+	if !ok || time.Now().After(record.ExpiresAt) {
+		return nil, nil
+	}
+	return &record, nil
+}
+
+func (r *idempotencyRepository) Save(tenantID string, record *domain.IdempotencyRecord) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	records, err := r.load(tenantID)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	for key, existing := range records {
+		if now.After(existing.ExpiresAt) {
+			delete(records, key)
+		}
+	}
+	records[record.Key] = *record
+
+	data, err := json.Marshal(records)
+	if err != nil {
+		return fmt.Errorf("failed to marshal idempotency records: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(r.storagePath, tenantID+".json"), data, 0644); err != nil {
+		return fmt.Errorf("failed to write idempotency file: %v", err)
+	}
+
+	return nil
+}
+
+// load returns the records of a tenant, reading the file once; callers must hold r.mu
+func (r *idempotencyRepository) load(tenantID string) (map[string]domain.IdempotencyRecord, error) {
+	if !domain.ValidTenantID(tenantID) {
+		return nil, domain.ErrInvalidTenant
+	}
+	if records, ok := r.cache[tenantID]; ok {
+		return records, nil
+	}
+
+	records := make(map[string]domain.IdempotencyRecord)
+	data, err := os.ReadFile(filepath.Join(r.storagePath, tenantID+".json"))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read idempotency file: %v", err)
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, &records); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal idempotency records: %v", err)
+		}
+	}
+
+	r.cache[tenantID] = records
+	return records, nil
+}
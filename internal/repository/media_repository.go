@@ -0,0 +1,173 @@
+package repository
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"time"
+
+	"whatsapp-parser/internal/domain"
+)
+
+// mediaHashPattern matches hex SHA-256 hashes, the names of stored files
+var mediaHashPattern = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+type mediaRepository struct {
+	storagePath string
+	quota       int64 // Bytes; 0 means unlimited
+
+	mu    sync.Mutex
+	usage int64 // Bytes of stored files
+}
+
+// NewMediaRepository creates a content-addressed media store. Each file is kept
+// as <hash[:2]>/<hash> with its metadata in <hash>.json next to it, and the
+// stored files together may not exceed quota bytes (0 for no limit).
+func NewMediaRepository(storagePath string, quota int64) (domain.MediaRepository, error) {
+	if err := os.MkdirAll(storagePath, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create storage directory: %v", err)
+	}
+
+	r := &mediaRepository{storagePath: storagePath, quota: quota}
+	err := filepath.WalkDir(storagePath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !mediaHashPattern.MatchString(d.Name()) {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		r.usage += info.Size()
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to measure media storage: %v", err)
+	}
+	return r, nil
+}
+
+func (r *mediaRepository) Save(src io.Reader, mimeType, fileName string) (*domain.Media, error) {
+	tmp, err := os.CreateTemp(r.storagePath, "download-*.tmp")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create media file: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	// Stop reading as soon as the content cannot fit any more
+	r.mu.Lock()
+	room := r.quota - r.usage
+	r.mu.Unlock()
+	reader := src
+	if r.quota > 0 {
+		if room <= 0 {
+			return nil, domain.ErrMediaQuotaExceeded
+		}
+		reader = io.LimitReader(src, room+1)
+	}
+
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, hash), reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to write media file: %v", err)
+	}
+	if r.quota > 0 && size > room {
+		return nil, domain.ErrMediaQuotaExceeded
+	}
+	if err := tmp.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write media file: %v", err)
+	}
+
+	media := &domain.Media{
+		Hash:      hex.EncodeToString(hash.Sum(nil)),
+		MimeType:  mimeType,
+		Size:      size,
+		FileName:  fileName,
+		CreatedAt: time.Now(),
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if existing, err := r.get(media.Hash); err != nil || existing != nil {
+		return existing, err
+	}
+	if r.quota > 0 && r.usage+size > r.quota {
+		return nil, domain.ErrMediaQuotaExceeded
+	}
+
+	filePath := r.mediaPath(media.Hash)
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create media directory: %v", err)
+	}
+	data, err := json.MarshalIndent(media, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal media: %v", err)
+	}
+	// Metadata is written first; a file without metadata is never served
+	if err := os.WriteFile(filePath+".json", data, 0644); err != nil {
+		return nil, fmt.Errorf("failed to write media metadata: %v", err)
+	}
+	if err := os.Rename(tmp.Name(), filePath); err != nil {
+		return nil, fmt.Errorf("failed to store media file: %v", err)
+	}
+	r.usage += size
+	return media, nil
+}
+
+func (r *mediaRepository) Get(hash string) (*domain.Media, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.get(hash)
+}
+
+func (r *mediaRepository) get(hash string) (*domain.Media, error) {
+	if !mediaHashPattern.MatchString(hash) {
+		return nil, nil
+	}
+	filePath := r.mediaPath(hash)
+	if _, err := os.Stat(filePath); err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to check media file: %v", err)
+	}
+	data, err := os.ReadFile(filePath + ".json")
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read media metadata: %v", err)
+	}
+
+	var media domain.Media
+	if err := json.Unmarshal(data, &media); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal media: %v", err)
+	}
+	return &media, nil
+}
+
+func (r *mediaRepository) Open(hash string) (io.ReadSeekCloser, error) {
+	if !mediaHashPattern.MatchString(hash) {
+		return nil, domain.ErrMediaNotFound
+	}
+	file, err := os.Open(r.mediaPath(hash))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, domain.ErrMediaNotFound
+		}
+		return nil, fmt.Errorf("failed to open media file: %v", err)
+	}
+	return file, nil
+}
+
+func (r *mediaRepository) mediaPath(hash string) string {
+	return filepath.Join(r.storagePath, hash[:2], hash)
+}
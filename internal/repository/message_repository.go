@@ -0,0 +1,493 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"whatsapp-parser/internal/domain"
+	"whatsapp-parser/internal/search"
+
+	_ "modernc.org/sqlite" // Registers the "sqlite" driver
+)
+
+const messageSchema = `
+CREATE TABLE IF NOT EXISTS chats (
+	tenant_id TEXT NOT NULL,
+	session_id TEXT NOT NULL,
+	jid TEXT NOT NULL,
+	title TEXT NOT NULL DEFAULT '',
+	is_group INTEGER NOT NULL DEFAULT 0,
+	participants TEXT NOT NULL DEFAULT '[]',
+	last_message_at INTEGER NOT NULL DEFAULT 0,
+	synced_at INTEGER,
+	PRIMARY KEY (tenant_id, session_id, jid)
+);
+CREATE TABLE IF NOT EXISTS messages (
+	tenant_id TEXT NOT NULL,
+	session_id TEXT NOT NULL,
+	chat_jid TEXT NOT NULL,
+	id TEXT NOT NULL,
+	timestamp INTEGER NOT NULL,
+	from_me INTEGER NOT NULL,
+	sender_jid TEXT NOT NULL,
+	sender_name TEXT NOT NULL,
+	type TEXT NOT NULL,
+	text TEXT NOT NULL,
+	attachment TEXT NOT NULL,
+	media_hash TEXT NOT NULL DEFAULT '',
+	payload TEXT NOT NULL DEFAULT '',
+	PRIMARY KEY (tenant_id, session_id, chat_jid, id)
+);
+CREATE INDEX IF NOT EXISTS messages_by_time ON messages (tenant_id, session_id, chat_jid, timestamp, id);
+`
+
+// messageMigrations bring archives created by earlier versions up to date
+var messageMigrations = []struct {
+	column string
+	stmt   string
+}{
+	{"media_hash", `ALTER TABLE messages ADD COLUMN media_hash TEXT NOT NULL DEFAULT ''`},
+	{"payload", `ALTER TABLE messages ADD COLUMN payload TEXT NOT NULL DEFAULT ''`},
+}
+
+// messageIndexSchema creates the full-text index. It holds search.IndexText of
+// each message, so the tokenizer only has to split on spaces.
+const messageIndexSchema = `
+CREATE VIRTUAL TABLE messages_fts USING fts5(
+	text,
+	tenant_id UNINDEXED,
+	session_id UNINDEXED,
+	chat_jid UNINDEXED,
+	id UNINDEXED,
+	tokenize = 'unicode61 remove_diacritics 0'
+);
+`
+
+const chatColumns = `tenant_id, session_id, jid, title, is_group, participants, last_message_at, synced_at`
+
+// messagePayload is the JSON kept in the payload column for location, contact
+// and poll messages
+type messagePayload struct {
+	Location *domain.Location       `json:"location,omitempty"`
+	Contacts []domain.SharedContact `json:"contacts,omitempty"`
+	Poll     *domain.Poll           `json:"poll,omitempty"`
+}
+
+// encodePayload returns the payload column of m, "" for plain messages
+func encodePayload(m domain.Message) (string, error) {
+	if m.Location == nil && len(m.Contacts) == 0 && m.Poll == nil {
+		return "", nil
+	}
+	data, err := json.Marshal(messagePayload{Location: m.Location, Contacts: m.Contacts, Poll: m.Poll})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode message payload: %v", err)
+	}
+	return string(data), nil
+}
+
+// decodePayload fills m from its payload column
+func decodePayload(m *domain.Message, payload string) error {
+	if payload == "" {
+		return nil
+	}
+	var p messagePayload
+	if err := json.Unmarshal([]byte(payload), &p); err != nil {
+		return fmt.Errorf("failed to decode message payload: %v", err)
+	}
+	m.Location, m.Contacts, m.Poll = p.Location, p.Contacts, p.Poll
+	return nil
+}
+
+type messageRepository struct {
+	db *sql.DB
+}
+
+// NewMessageRepository opens the SQLite message archive at path, creating it if needed
+func NewMessageRepository(path string) (domain.MessageRepository, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create storage directory: %v", err)
+	}
+
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open message archive: %v", err)
+	}
+	// A single connection serializes writers instead of failing with SQLITE_BUSY
+	db.SetMaxOpenConns(1)
+	for _, stmt := range []string{"PRAGMA journal_mode = WAL", "PRAGMA synchronous = NORMAL", messageSchema} {
+		if _, err := db.Exec(stmt); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("failed to prepare message archive: %v", err)
+		}
+	}
+	r := &messageRepository{db: db}
+	if err := r.migrate(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	if err := r.createIndex(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+// migrate adds columns missing from an existing messages table
+func (r *messageRepository) migrate() error {
+	rows, err := r.db.Query(`SELECT name FROM pragma_table_info('messages')`)
+	if err != nil {
+		return fmt.Errorf("failed to read archive schema: %v", err)
+	}
+	columns := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to read archive schema: %v", err)
+		}
+		columns[name] = true
+	}
+	rows.Close()
+
+	for _, m := range messageMigrations {
+		if columns[m.column] {
+			continue
+		}
+		if _, err := r.db.Exec(m.stmt); err != nil {
+			return fmt.Errorf("failed to migrate message archive: %v", err)
+		}
+	}
+	if _, err := r.db.Exec(`CREATE INDEX IF NOT EXISTS messages_by_media ON messages (tenant_id, media_hash) WHERE media_hash != ''`); err != nil {
+		return fmt.Errorf("failed to migrate message archive: %v", err)
+	}
+	return nil
+}
+
+// createIndex creates the full-text index if it is missing and fills it with
+// messages archived before it existed
+func (r *messageRepository) createIndex() error {
+	var exists int
+	err := r.db.QueryRow(`SELECT count(*) FROM sqlite_master WHERE name = 'messages_fts'`).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("failed to check search index: %v", err)
+	}
+	if exists > 0 {
+		return nil
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %v", err)
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(messageIndexSchema); err != nil {
+		return fmt.Errorf("failed to create search index: %v", err)
+	}
+
+	rows, err := tx.Query(`SELECT tenant_id, session_id, chat_jid, id, text FROM messages WHERE text != ''`)
+	if err != nil {
+		return fmt.Errorf("failed to read archived messages: %v", err)
+	}
+	var pending [][5]string
+	for rows.Next() {
+		var m [5]string
+		if err := rows.Scan(&m[0], &m[1], &m[2], &m[3], &m[4]); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to read archived message: %v", err)
+		}
+		pending = append(pending, m)
+	}
+	rows.Close()
+	for _, m := range pending {
+		if _, err := tx.Exec(`INSERT INTO messages_fts (text, tenant_id, session_id, chat_jid, id) VALUES (?, ?, ?, ?, ?)`,
+			search.IndexText(m[4]), m[0], m[1], m[2], m[3]); err != nil {
+			return fmt.Errorf("failed to index message: %v", err)
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *messageRepository) SaveChat(tenantID, sessionID string, chat domain.Chat) error {
+	participants, err := json.Marshal(chat.Participants)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Exec(`INSERT INTO chats (tenant_id, session_id, jid, title, is_group, participants)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (tenant_id, session_id, jid) DO UPDATE SET
+			title = excluded.title, is_group = excluded.is_group, participants = excluded.participants`,
+		tenantID, sessionID, chat.JID, chat.Title, chat.IsGroup, string(participants))
+	if err != nil {
+		return fmt.Errorf("failed to save chat: %v", err)
+	}
+	return nil
+}
+
+func (r *messageRepository) SaveMessages(tenantID, sessionID string, msgs []domain.Message) (int, error) {
+	if len(msgs) == 0 {
+		return 0, nil
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to start transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	insert, err := tx.Prepare(`INSERT INTO messages
+		(tenant_id, session_id, chat_jid, id, timestamp, from_me, sender_jid, sender_name, type, text, attachment, media_hash, payload)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT DO NOTHING`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prepare insert: %v", err)
+	}
+	defer insert.Close()
+	// Media may be downloaded after its message was archived
+	setMedia, err := tx.Prepare(`UPDATE messages SET media_hash = ?
+		WHERE tenant_id = ? AND session_id = ? AND chat_jid = ? AND id = ?`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prepare media update: %v", err)
+	}
+	defer setMedia.Close()
+	// Poll votes keep coming in; a poll read again brings the latest tally
+	setPayload, err := tx.Prepare(`UPDATE messages SET payload = ?
+		WHERE tenant_id = ? AND session_id = ? AND chat_jid = ? AND id = ?`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prepare poll update: %v", err)
+	}
+	defer setPayload.Close()
+	// Messages may arrive before their chat was read; the cursor only moves forward
+	touch, err := tx.Prepare(`INSERT INTO chats (tenant_id, session_id, jid, is_group, last_message_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (tenant_id, session_id, jid) DO UPDATE SET
+			last_message_at = max(last_message_at, excluded.last_message_at)`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prepare chat update: %v", err)
+	}
+	defer touch.Close()
+	index, err := tx.Prepare(`INSERT INTO messages_fts (text, tenant_id, session_id, chat_jid, id) VALUES (?, ?, ?, ?, ?)`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prepare index insert: %v", err)
+	}
+	defer index.Close()
+
+	added := 0
+	for _, m := range msgs {
+		payload, err := encodePayload(m)
+		if err != nil {
+			return 0, err
+		}
+		result, err := insert.Exec(tenantID, sessionID, m.ChatJID, m.ID, m.Timestamp.Unix(), m.FromMe,
+			m.SenderJID, m.SenderName, m.Type, m.Text, m.Attachment, m.MediaHash, payload)
+		if err != nil {
+			return 0, fmt.Errorf("failed to save message: %v", err)
+		}
+		n, _ := result.RowsAffected()
+		if n == 0 && m.MediaHash != "" {
+			if _, err := setMedia.Exec(m.MediaHash, tenantID, sessionID, m.ChatJID, m.ID); err != nil {
+				return 0, fmt.Errorf("failed to save message media: %v", err)
+			}
+		}
+		if n == 0 && m.Poll != nil {
+			if _, err := setPayload.Exec(payload, tenantID, sessionID, m.ChatJID, m.ID); err != nil {
+				return 0, fmt.Errorf("failed to save poll votes: %v", err)
+			}
+		}
+		if n > 0 {
+			added++
+			if text := search.IndexText(m.Text); text != "" {
+				if _, err := index.Exec(text, tenantID, sessionID, m.ChatJID, m.ID); err != nil {
+					return 0, fmt.Errorf("failed to index message: %v", err)
+				}
+			}
+		}
+		if _, err := touch.Exec(tenantID, sessionID, m.ChatJID, strings.HasSuffix(m.ChatJID, "@g.us"), m.Timestamp.Unix()); err != nil {
+			return 0, fmt.Errorf("failed to update chat: %v", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit messages: %v", err)
+	}
+	return added, nil
+}
+
+func (r *messageRepository) GetChat(tenantID, sessionID, jid string) (*domain.ArchivedChat, error) {
+	chats, err := r.queryChats(`SELECT `+chatColumns+` FROM chats WHERE tenant_id = ? AND session_id = ? AND jid = ?`,
+		tenantID, sessionID, jid)
+	if err != nil || len(chats) == 0 {
+		return nil, err
+	}
+	return chats[0], nil
+}
+
+func (r *messageRepository) FindChats(tenantID, sessionID, title string) ([]*domain.ArchivedChat, error) {
+	return r.queryChats(`SELECT `+chatColumns+` FROM chats WHERE tenant_id = ? AND session_id = ? AND title = ?`,
+		tenantID, sessionID, title)
+}
+
+func (r *messageRepository) ListUnsynced(before time.Time) ([]*domain.ArchivedChat, error) {
+	return r.queryChats(`SELECT `+chatColumns+` FROM chats
+		WHERE synced_at IS NULL OR synced_at < ? ORDER BY synced_at`, before.Unix())
+}
+
+func (r *messageRepository) MarkSynced(tenantID, sessionID, jid string, at time.Time) error {
+	_, err := r.db.Exec(`UPDATE chats SET synced_at = ? WHERE tenant_id = ? AND session_id = ? AND jid = ?`,
+		at.Unix(), tenantID, sessionID, jid)
+	if err != nil {
+		return fmt.Errorf("failed to mark chat synced: %v", err)
+	}
+	return nil
+}
+
+func (r *messageRepository) ListMessages(tenantID, sessionID, chatJID string, q domain.MessageQuery) ([]domain.Message, error) {
+	query := `SELECT chat_jid, id, timestamp, from_me, sender_jid, sender_name, type, text, attachment, media_hash, payload
+		FROM messages WHERE tenant_id = ? AND session_id = ? AND chat_jid = ?`
+	args := []interface{}{tenantID, sessionID, chatJID}
+	if q.Before != "" {
+		query += ` AND (timestamp, id) < (SELECT timestamp, id FROM messages
+			WHERE tenant_id = ? AND session_id = ? AND chat_jid = ? AND id = ?)`
+		args = append(args, tenantID, sessionID, chatJID, q.Before)
+	}
+	query += ` ORDER BY timestamp DESC, id DESC LIMIT ?`
+	args = append(args, q.Limit)
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list messages: %v", err)
+	}
+	defer rows.Close()
+
+	var messages []domain.Message
+	for rows.Next() {
+		var m domain.Message
+		var ts int64
+		var payload string
+		if err := rows.Scan(&m.ChatJID, &m.ID, &ts, &m.FromMe, &m.SenderJID, &m.SenderName, &m.Type, &m.Text, &m.Attachment, &m.MediaHash, &payload); err != nil {
+			return nil, fmt.Errorf("failed to read message: %v", err)
+		}
+		if err := decodePayload(&m, payload); err != nil {
+			return nil, err
+		}
+		m.Timestamp = time.Unix(ts, 0)
+		messages = append(messages, m)
+	}
+	return messages, rows.Err()
+}
+
+func (r *messageRepository) MediaSessions(tenantID, hash string) ([]string, error) {
+	rows, err := r.db.Query(`SELECT DISTINCT session_id FROM messages WHERE tenant_id = ? AND media_hash = ?`, tenantID, hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up media: %v", err)
+	}
+	defer rows.Close()
+
+	var sessions []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to look up media: %v", err)
+		}
+		sessions = append(sessions, id)
+	}
+	return sessions, rows.Err()
+}
+
+func (r *messageRepository) Search(tenantID string, q domain.SearchQuery) ([]domain.SearchHit, error) {
+	parsed, err := search.Parse(q.Text)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `SELECT m.session_id, coalesce(c.title, ''), m.chat_jid, m.id, m.timestamp, m.from_me,
+			m.sender_jid, m.sender_name, m.type, m.text, m.attachment, m.media_hash, m.payload
+		FROM messages_fts f
+		JOIN messages m ON m.tenant_id = f.tenant_id AND m.session_id = f.session_id
+			AND m.chat_jid = f.chat_jid AND m.id = f.id
+		LEFT JOIN chats c ON c.tenant_id = m.tenant_id AND c.session_id = m.session_id AND c.jid = m.chat_jid
+		WHERE messages_fts MATCH ? AND f.tenant_id = ?`
+	args := []interface{}{parsed.FTS(), tenantID}
+	if len(q.SessionIDs) > 0 {
+		query += ` AND f.session_id IN (?` + strings.Repeat(`, ?`, len(q.SessionIDs)-1) + `)`
+		for _, id := range q.SessionIDs {
+			args = append(args, id)
+		}
+	}
+	if q.ChatJID != "" {
+		query += ` AND f.chat_jid = ?`
+		args = append(args, q.ChatJID)
+	}
+	if !q.From.IsZero() {
+		query += ` AND m.timestamp >= ?`
+		args = append(args, q.From.Unix())
+	}
+	if !q.To.IsZero() {
+		query += ` AND m.timestamp < ?`
+		args = append(args, q.To.Unix())
+	}
+	query += ` ORDER BY m.timestamp DESC, m.id DESC LIMIT ? OFFSET ?`
+	args = append(args, q.Limit, q.Offset)
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search messages: %v", err)
+	}
+	defer rows.Close()
+
+	var hits []domain.SearchHit
+	for rows.Next() {
+		var h domain.SearchHit
+		var ts int64
+		var payload string
+		m := &h.Message
+		if err := rows.Scan(&h.SessionID, &h.ChatTitle, &m.ChatJID, &m.ID, &ts, &m.FromMe,
+			&m.SenderJID, &m.SenderName, &m.Type, &m.Text, &m.Attachment, &m.MediaHash, &payload); err != nil {
+			return nil, fmt.Errorf("failed to read message: %v", err)
+		}
+		if err := decodePayload(m, payload); err != nil {
+			return nil, err
+		}
+		m.Timestamp = time.Unix(ts, 0)
+		hits = append(hits, h)
+	}
+	return hits, rows.Err()
+}
+
+func (r *messageRepository) Close() error {
+	return r.db.Close()
+}
+
+func (r *messageRepository) queryChats(query string, args ...interface{}) ([]*domain.ArchivedChat, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list chats: %v", err)
+	}
+	defer rows.Close()
+
+	var chats []*domain.ArchivedChat
+	for rows.Next() {
+		var c domain.ArchivedChat
+		var participants string
+		var lastMessageAt int64
+		var syncedAt sql.NullInt64
+		if err := rows.Scan(&c.TenantID, &c.SessionID, &c.JID, &c.Title, &c.IsGroup, &participants, &lastMessageAt, &syncedAt); err != nil {
+			return nil, fmt.Errorf("failed to read chat: %v", err)
+		}
+		if err := json.Unmarshal([]byte(participants), &c.Participants); err != nil {
+			return nil, fmt.Errorf("failed to decode participants of chat %s: %v", c.JID, err)
+		}
+		if lastMessageAt > 0 {
+			c.LastMessageAt = time.Unix(lastMessageAt, 0)
+		}
+		if syncedAt.Valid {
+			t := time.Unix(syncedAt.Int64, 0)
+			c.SyncedAt = &t
+		}
+		chats = append(chats, &c)
+	}
+	return chats, rows.Err()
+}
@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"whatsapp-parser/internal/domain"
+)
+
+type pacingRepository struct {
+	storagePath string
+	mu          sync.RWMutex
+}
+
+// NewPacingRepository creates a pacing repository storing one file per session
+// in a directory per tenant
+func NewPacingRepository(storagePath string) (domain.PacingRepository, error) {
+	if err := os.MkdirAll(storagePath, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create storage directory: %v", err)
+	}
+
+	return &pacingRepository{
+		storagePath: storagePath,
+	}, nil
+}
+
+func (r *pacingRepository) statePath(tenantID, sessionID string) (string, error) {
+	if !domain.ValidTenantID(tenantID) {
+		return "", domain.ErrInvalidTenant
+	}
+	return filepath.Join(r.storagePath, tenantID, filepath.Base(sessionID)+".json"), nil
+}
+
+func (r *pacingRepository) Get(tenantID, sessionID string) (*domain.PacingState, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	filePath, err := r.statePath(tenantID, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	state := &domain.PacingState{}
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return state, nil
+		}
+		return nil, fmt.Errorf("failed to read pacing file: %v", err)
+	}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal pacing state: %v", err)
+	}
+
+	return state, nil
+}
+
+func (r *pacingRepository) Save(tenantID, sessionID string, state *domain.PacingState) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	filePath, err := r.statePath(tenantID, sessionID)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("failed to marshal pacing state: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return fmt.Errorf("failed to create tenant directory: %v", err)
+	}
+	if err := os.WriteFile(filePath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write pacing file: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,175 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"whatsapp-parser/internal/domain"
+)
+
+type scheduleRepository struct {
+	storagePath string
+	mu          sync.RWMutex
+}
+
+// NewScheduleRepository creates a repository storing one file per scheduled
+// message in a directory per tenant
+func NewScheduleRepository(storagePath string) (domain.ScheduleRepository, error) {
+	if err := os.MkdirAll(storagePath, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create storage directory: %v", err)
+	}
+
+	return &scheduleRepository{
+		storagePath: storagePath,
+	}, nil
+}
+
+func (r *scheduleRepository) tenantDir(tenantID string) (string, error) {
+	if !domain.ValidTenantID(tenantID) {
+		return "", domain.ErrInvalidTenant
+	}
+	return filepath.Join(r.storagePath, tenantID), nil
+}
+
+func (r *scheduleRepository) schedulePath(tenantID, id string) (string, error) {
+	dir, err := r.tenantDir(tenantID)
+	if err != nil {
+		return "", err
+	}
+	if id == "" || strings.ContainsAny(id, `/\.`) {
+		return "", fmt.Errorf("invalid schedule ID %q", id)
+	}
+	return filepath.Join(dir, id+".json"), nil
+}
+
+func (r *scheduleRepository) Save(schedule *domain.ScheduledMessage) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	filePath, err := r.schedulePath(schedule.TenantID, schedule.ID)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(schedule)
+	if err != nil {
+		return fmt.Errorf("failed to marshal schedule: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return fmt.Errorf("failed to create tenant directory: %v", err)
+	}
+	if err := os.WriteFile(filePath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write schedule file: %v", err)
+	}
+
+	return nil
+}
+
+func (r *scheduleRepository) GetByID(tenantID, id string) (*domain.ScheduledMessage, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	filePath, err := r.schedulePath(tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+	return readSchedule(filePath)
+}
+
+func (r *scheduleRepository) List(tenantID, sessionID string) ([]*domain.ScheduledMessage, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	dir, err := r.tenantDir(tenantID)
+	if err != nil {
+		return nil, err
+	}
+	schedules, err := readSchedules(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	filtered := schedules[:0]
+	for _, s := range schedules {
+		if s.SessionID == sessionID {
+			filtered = append(filtered, s)
+		}
+	}
+	sort.Slice(filtered, func(i, j int) bool {
+		return filtered[i].CreatedAt.Before(filtered[j].CreatedAt)
+	})
+	return filtered, nil
+}
+
+func (r *scheduleRepository) ListActive() ([]*domain.ScheduledMessage, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	entries, err := os.ReadDir(r.storagePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read storage directory: %v", err)
+	}
+
+	var active []*domain.ScheduledMessage
+	for _, entry := range entries {
+		if !entry.IsDir() || !domain.ValidTenantID(entry.Name()) {
+			continue
+		}
+		schedules, err := readSchedules(filepath.Join(r.storagePath, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range schedules {
+			if s.Status == domain.ScheduleActive {
+				active = append(active, s)
+			}
+		}
+	}
+	return active, nil
+}
+
+func readSchedules(dir string) ([]*domain.ScheduledMessage, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read schedules directory: %v", err)
+	}
+
+	schedules := make([]*domain.ScheduledMessage, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		schedule, err := readSchedule(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		if schedule != nil {
+			schedules = append(schedules, schedule)
+		}
+	}
+	return schedules, nil
+}
+
+func readSchedule(filePath string) (*domain.ScheduledMessage, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read schedule file: %v", err)
+	}
+
+	var schedule domain.ScheduledMessage
+	if err := json.Unmarshal(data, &schedule); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal schedule: %v", err)
+	}
+	return &schedule, nil
+}
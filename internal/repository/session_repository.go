@@ -3,8 +3,10 @@ package repository
 import (
 	"encoding/json"
 	"fmt"
+	"log"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 
 	"whatsapp-parser/internal/domain"
@@ -15,27 +17,76 @@ type sessionRepository struct {
 	mu          sync.RWMutex
 }
 
-// NewSessionRepository creates a new session repository
+// NewSessionRepository creates a new session repository. Sessions are stored
+// in one subdirectory per tenant; files from before tenants existed are moved
+// into the default tenant directory.
 func NewSessionRepository(storagePath string) (domain.SessionRepository, error) {
 	if err := os.MkdirAll(storagePath, 0755); err != nil {
 		return nil, fmt.Errorf("failed to create storage directory: %v", err)
 	}
 
-	return &sessionRepository{
+	r := &sessionRepository{
 		storagePath: storagePath,
-	}, nil
+	}
+	if err := r.migrateLegacy(); err != nil {
+		return nil, fmt.Errorf("failed to migrate legacy sessions: %v", err)
+	}
+
+	return r, nil
+}
+
+// migrateLegacy moves session files stored directly in storagePath into the default tenant
+func (r *sessionRepository) migrateLegacy() error {
+	entries, err := os.ReadDir(r.storagePath)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		dir := filepath.Join(r.storagePath, domain.DefaultTenantID)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+		from := filepath.Join(r.storagePath, entry.Name())
+		if err := os.Rename(from, filepath.Join(dir, entry.Name())); err != nil {
+			return err
+		}
+		log.Printf("Moved legacy session %s to tenant %s", entry.Name(), domain.DefaultTenantID)
+	}
+	return nil
+}
+
+// sessionPath returns the file of a session within its tenant directory
+func (r *sessionRepository) sessionPath(tenantID, id string) (string, error) {
+	if !domain.ValidTenantID(tenantID) {
+		return "", domain.ErrInvalidTenant
+	}
+	if id == "" || strings.ContainsAny(id, `/\.`) {
+		return "", fmt.Errorf("invalid session ID %q", id)
+	}
+	return filepath.Join(r.storagePath, tenantID, id+".json"), nil
 }
 
 func (r *sessionRepository) Save(session *domain.Session) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
+	filePath, err := r.sessionPath(session.TenantID, session.ID)
+	if err != nil {
+		return err
+	}
+
 	data, err := json.Marshal(session)
 	if err != nil {
 		return fmt.Errorf("failed to marshal session: %v", err)
 	}
 
-	filePath := filepath.Join(r.storagePath, session.ID+".json")
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return fmt.Errorf("failed to create tenant directory: %v", err)
+	}
 	if err := os.WriteFile(filePath, data, 0644); err != nil {
 		return fmt.Errorf("failed to write session file: %v", err)
 	}
@@ -43,11 +94,14 @@ func (r *sessionRepository) Save(session *domain.Session) error {
 	return nil
 }
 
-func (r *sessionRepository) GetByID(id string) (*domain.Session, error) {
+func (r *sessionRepository) GetByID(tenantID, id string) (*domain.Session, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
-	filePath := filepath.Join(r.storagePath, id+".json")
+	filePath, err := r.sessionPath(tenantID, id)
+	if err != nil {
+		return nil, err
+	}
 	data, err := os.ReadFile(filePath)
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -60,15 +114,20 @@ func (r *sessionRepository) GetByID(id string) (*domain.Session, error) {
 	if err := json.Unmarshal(data, &session); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal session: %v", err)
 	}
+	// Sessions saved before tenants existed have no tenant ID
+	session.TenantID = tenantID
 
 	return &session, nil
 }
 
-func (r *sessionRepository) Delete(id string) error {
+func (r *sessionRepository) Delete(tenantID, id string) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	filePath := filepath.Join(r.storagePath, id+".json")
+	filePath, err := r.sessionPath(tenantID, id)
+	if err != nil {
+		return err
+	}
 	if err := os.Remove(filePath); err != nil {
 		if os.IsNotExist(err) {
 			return nil
@@ -77,4 +136,4 @@ func (r *sessionRepository) Delete(id string) error {
 	}
 
 	return nil
-} 
\ No newline at end of file
+}
@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"whatsapp-parser/internal/domain"
+)
+
+type suppressionRepository struct {
+	storagePath string
+	mu          sync.Mutex
+	cache       map[string]map[string]domain.Suppression // Loaded files by tenant
+}
+
+// NewSuppressionRepository creates a repository keeping the suppression list
+// of each tenant in one file
+func NewSuppressionRepository(storagePath string) (domain.SuppressionRepository, error) {
+	if err := os.MkdirAll(storagePath, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create storage directory: %v", err)
+	}
+
+	return &suppressionRepository{
+		storagePath: storagePath,
+		cache:       make(map[string]map[string]domain.Suppression),
+	}, nil
+}
+
+func (r *suppressionRepository) Get(tenantID, phoneNumber string) (*domain.Suppression, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	suppressions, err := r.load(tenantID)
+	if err != nil {
+		return nil, err
+	}
+	s, ok := suppressions[phoneNumber]
+	if !ok {
+		return nil, nil
+	}
+	return &s, nil
+}
+
+func (r *suppressionRepository) List(tenantID string) ([]*domain.Suppression, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	suppressions, err := r.load(tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]*domain.Suppression, 0, len(suppressions))
+	for _, s := range suppressions {
+		s := s
+		list = append(list, &s)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].CreatedAt.Before(list[j].CreatedAt)
+	})
+	return list, nil
+}
+
+func (r *suppressionRepository) Save(tenantID string, suppression *domain.Suppression) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	suppressions, err := r.load(tenantID)
+	if err != nil {
+		return err
+	}
+	suppressions[suppression.PhoneNumber] = *suppression
+	return r.write(tenantID, suppressions)
+}
+
+func (r *suppressionRepository) Delete(tenantID, phoneNumber string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	suppressions, err := r.load(tenantID)
+	if err != nil {
+		return false, err
+	}
+	if _, ok := suppressions[phoneNumber]; !ok {
+		return false, nil
+	}
+	delete(suppressions, phoneNumber)
+	return true, r.write(tenantID, suppressions)
+}
+
+// write stores the suppressions of a tenant; callers must hold r.mu
+func (r *suppressionRepository) write(tenantID string, suppressions map[string]domain.Suppression) error {
+	data, err := json.Marshal(suppressions)
+	if err != nil {
+		return fmt.Errorf("failed to marshal suppressions: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(r.storagePath, tenantID+".json"), data, 0644); err != nil {
+		return fmt.Errorf("failed to write suppressions file: %v", err)
+	}
+	return nil
+}
+
+// load returns the suppressions of a tenant, reading the file once; callers must hold r.mu
+func (r *suppressionRepository) load(tenantID string) (map[string]domain.Suppression, error) {
+	if !domain.ValidTenantID(tenantID) {
+		return nil, domain.ErrInvalidTenant
+	}
+	if suppressions, ok := r.cache[tenantID]; ok {
+		return suppressions, nil
+	}
+
+	suppressions := make(map[string]domain.Suppression)
+	data, err := os.ReadFile(filepath.Join(r.storagePath, tenantID+".json"))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read suppressions file: %v", err)
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, &suppressions); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal suppressions: %v", err)
+		}
+	}
+
+	r.cache[tenantID] = suppressions
+	return suppressions, nil
+}
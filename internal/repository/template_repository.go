@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"whatsapp-parser/internal/domain"
+)
+
+type templateRepository struct {
+	storagePath string
+	mu          sync.RWMutex
+}
+
+// NewTemplateRepository creates a template repository storing one file per
+// template in a directory per tenant
+func NewTemplateRepository(storagePath string) (domain.TemplateRepository, error) {
+	if err := os.MkdirAll(storagePath, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create storage directory: %v", err)
+	}
+
+	return &templateRepository{
+		storagePath: storagePath,
+	}, nil
+}
+
+func (r *templateRepository) tenantDir(tenantID string) (string, error) {
+	if !domain.ValidTenantID(tenantID) {
+		return "", domain.ErrInvalidTenant
+	}
+	return filepath.Join(r.storagePath, tenantID), nil
+}
+
+func (r *templateRepository) templatePath(tenantID, id string) (string, error) {
+	dir, err := r.tenantDir(tenantID)
+	if err != nil {
+		return "", err
+	}
+	if id == "" || strings.ContainsAny(id, `/\.`) {
+		return "", fmt.Errorf("invalid template ID %q", id)
+	}
+	return filepath.Join(dir, id+".json"), nil
+}
+
+func (r *templateRepository) Save(template *domain.Template) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	filePath, err := r.templatePath(template.TenantID, template.ID)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(template)
+	if err != nil {
+		return fmt.Errorf("failed to marshal template: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return fmt.Errorf("failed to create tenant directory: %v", err)
+	}
+	if err := os.WriteFile(filePath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write template file: %v", err)
+	}
+
+	return nil
+}
+
+func (r *templateRepository) GetByID(tenantID, id string) (*domain.Template, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	filePath, err := r.templatePath(tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+	return readTemplate(filePath)
+}
+
+func (r *templateRepository) List(tenantID string) ([]*domain.Template, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	dir, err := r.tenantDir(tenantID)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read templates directory: %v", err)
+	}
+
+	templates := make([]*domain.Template, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		template, err := readTemplate(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		if template != nil {
+			templates = append(templates, template)
+		}
+	}
+
+	sort.Slice(templates, func(i, j int) bool {
+		return templates[i].Name < templates[j].Name
+	})
+	return templates, nil
+}
+
+func (r *templateRepository) Delete(tenantID, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	filePath, err := r.templatePath(tenantID, id)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(filePath); err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to delete template file: %v", err)
+	}
+
+	return nil
+}
+
+func readTemplate(filePath string) (*domain.Template, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read template file: %v", err)
+	}
+
+	var template domain.Template
+	if err := json.Unmarshal(data, &template); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal template: %v", err)
+	}
+	return &template, nil
+}
@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"whatsapp-parser/internal/domain"
+)
+
+type usageRepository struct {
+	storagePath string
+	mu          sync.Mutex
+}
+
+// usageRetention is how long daily counters are kept
+const usageRetention = 31 * 24 * time.Hour
+
+// NewUsageRepository creates a usage repository keeping one usage.json per tenant directory
+func NewUsageRepository(storagePath string) (domain.UsageRepository, error) {
+	if err := os.MkdirAll(storagePath, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create storage directory: %v", err)
+	}
+
+	return &usageRepository{
+		storagePath: storagePath,
+	}, nil
+}
+
+func (r *usageRepository) ReserveSends(tenantID, day string, n, limit int) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	usage, err := r.load(tenantID)
+	if err != nil {
+		return 0, err
+	}
+	if limit > 0 && usage[day]+n > limit {
+		return usage[day], fmt.Errorf("%w: %d of %d daily sends used", domain.ErrQuotaExceeded, usage[day], limit)
+	}
+	usage[day] += n
+
+	// Drop counters nobody will ask for again
+	if current, err := time.Parse("2006-01-02", day); err == nil {
+		cutoff := current.Add(-usageRetention).Format("2006-01-02")
+		for d := range usage {
+			if d < cutoff {
+				delete(usage, d)
+			}
+		}
+	}
+
+	if err := r.save(tenantID, usage); err != nil {
+		return 0, err
+	}
+	return usage[day], nil
+}
+
+func (r *usageRepository) ReleaseSends(tenantID, day string, n int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	usage, err := r.load(tenantID)
+	if err != nil {
+		return err
+	}
+	if _, ok := usage[day]; !ok {
+		return nil
+	}
+	usage[day] -= n
+	if usage[day] < 0 {
+		usage[day] = 0
+	}
+	return r.save(tenantID, usage)
+}
+
+func (r *usageRepository) GetSends(tenantID, day string) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	usage, err := r.load(tenantID)
+	if err != nil {
+		return 0, err
+	}
+	return usage[day], nil
+}
+
+// save writes send counters by day; callers must hold r.mu
+func (r *usageRepository) save(tenantID string, usage map[string]int) error {
+	filePath := filepath.Join(r.storagePath, tenantID, "usage.json")
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return fmt.Errorf("failed to create tenant directory: %v", err)
+	}
+	data, err := json.Marshal(usage)
+	if err != nil {
+		return fmt.Errorf("failed to marshal usage: %v", err)
+	}
+	if err := os.WriteFile(filePath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write usage file: %v", err)
+	}
+	return nil
+}
+
+// load reads send counters by day; callers must hold r.mu
+func (r *usageRepository) load(tenantID string) (map[string]int, error) {
+	if !domain.ValidTenantID(tenantID) {
+		return nil, domain.ErrInvalidTenant
+	}
+
+	usage := make(map[string]int)
+	data, err := os.ReadFile(filepath.Join(r.storagePath, tenantID, "usage.json"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return usage, nil
+		}
+		return nil, fmt.Errorf("failed to read usage file: %v", err)
+	}
+	if err := json.Unmarshal(data, &usage); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal usage: %v", err)
+	}
+	return usage, nil
+}
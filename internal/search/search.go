@@ -0,0 +1,197 @@
+// Package search tokenizes message text for the full-text index, parses
+// search queries and highlights matches. Words are runs of letters and digits
+// in any script; they are lower-cased and "ё" is folded into "е", so Russian
+// text matches however it was typed.
+package search
+
+import (
+	"fmt"
+	"html"
+	"strings"
+	"unicode"
+
+	"whatsapp-parser/internal/domain"
+)
+
+// Token is a word of a text with its byte offsets
+type Token struct {
+	Word       string // Normalized form
+	Start, End int
+}
+
+// Tokenize splits text into normalized words
+func Tokenize(text string) []Token {
+	var tokens []Token
+	start := -1
+	for i, r := range text {
+		if isWordRune(r) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 {
+			tokens = append(tokens, Token{Word: normalize(text[start:i]), Start: start, End: i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		tokens = append(tokens, Token{Word: normalize(text[start:]), Start: start, End: len(text)})
+	}
+	return tokens
+}
+
+// IndexText returns the normalized words of text separated by spaces, the form
+// stored in the full-text index
+func IndexText(text string) string {
+	tokens := Tokenize(text)
+	words := make([]string, len(tokens))
+	for i, t := range tokens {
+		words[i] = t.Word
+	}
+	return strings.Join(words, " ")
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r)
+}
+
+// normalize lower-cases a word and folds letters that are used interchangeably
+func normalize(word string) string {
+	return strings.Map(func(r rune) rune {
+		r = unicode.ToLower(r)
+		if r == 'ё' {
+			return 'е'
+		}
+		return r
+	}, word)
+}
+
+// Clause is a word or phrase of a query; all clauses must match
+type Clause struct {
+	Words  []string
+	Prefix bool // The last word matches any word starting with it
+}
+
+// Query is a parsed search query
+type Query []Clause
+
+// Parse reads a query of words and "quoted phrases". A trailing * makes a word
+// or phrase match as a prefix, e.g. заказ* or "order 123"*.
+func Parse(text string) (Query, error) {
+	var query Query
+	rest := strings.TrimSpace(text)
+	for rest != "" {
+		var part string
+		if rest[0] == '"' {
+			end := strings.IndexByte(rest[1:], '"')
+			if end < 0 {
+				return nil, fmt.Errorf("%w: unterminated phrase", domain.ErrInvalidSearch)
+			}
+			part, rest = rest[1:end+1], rest[end+2:]
+		} else {
+			end := strings.IndexFunc(rest, unicode.IsSpace)
+			if end < 0 {
+				end = len(rest)
+			}
+			part, rest = rest[:end], rest[end:]
+		}
+
+		prefix := strings.HasPrefix(rest, "*") || strings.HasSuffix(part, "*")
+		rest = strings.TrimSpace(strings.TrimPrefix(rest, "*"))
+
+		tokens := Tokenize(part)
+		if len(tokens) == 0 {
+			continue
+		}
+		clause := Clause{Prefix: prefix}
+		for _, t := range tokens {
+			clause.Words = append(clause.Words, t.Word)
+		}
+		query = append(query, clause)
+	}
+	if len(query) == 0 {
+		return nil, fmt.Errorf("%w: no words to search for", domain.ErrInvalidSearch)
+	}
+	return query, nil
+}
+
+// FTS returns the query in SQLite FTS5 syntax for an index of IndexText values
+func (q Query) FTS() string {
+	clauses := make([]string, len(q))
+	for i, c := range q {
+		clauses[i] = `"` + strings.Join(c.Words, " ") + `"`
+		if c.Prefix {
+			clauses[i] += "*"
+		}
+	}
+	return strings.Join(clauses, " AND ")
+}
+
+// matches tells whether a normalized word is one of the query's words
+func (q Query) matches(word string) bool {
+	for _, c := range q {
+		for i, w := range c.Words {
+			if word == w || c.Prefix && i == len(c.Words)-1 && strings.HasPrefix(word, w) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+const (
+	// snippetContext is the number of words shown before the first match
+	snippetContext = 6
+	// snippetWords is the number of words in a snippet
+	snippetWords = 24
+)
+
+// Snippet returns the part of text around the first match, HTML-escaped, with
+// matching words wrapped in <mark> tags
+func Snippet(text string, q Query) string {
+	tokens := Tokenize(text)
+	first := -1
+	for i, t := range tokens {
+		if q.matches(t.Word) {
+			first = i
+			break
+		}
+	}
+	if len(tokens) == 0 {
+		return html.EscapeString(text)
+	}
+
+	from := 0
+	if first > snippetContext {
+		from = first - snippetContext
+	}
+	to := from + snippetWords
+	if to > len(tokens) {
+		to = len(tokens)
+	}
+
+	var b strings.Builder
+	start := tokens[from].Start
+	if from > 0 {
+		b.WriteString("…")
+	} else {
+		start = 0
+	}
+	pos := start
+	for _, t := range tokens[from:to] {
+		b.WriteString(html.EscapeString(text[pos:t.Start]))
+		if q.matches(t.Word) {
+			b.WriteString("<mark>" + html.EscapeString(text[t.Start:t.End]) + "</mark>")
+		} else {
+			b.WriteString(html.EscapeString(text[t.Start:t.End]))
+		}
+		pos = t.End
+	}
+	if to < len(tokens) {
+		b.WriteString("…")
+	} else {
+		b.WriteString(html.EscapeString(text[pos:]))
+	}
+	return b.String()
+}
@@ -0,0 +1,212 @@
+package search
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"whatsapp-parser/internal/domain"
+)
+
+func TestTokenize(t *testing.T) {
+	text := "Ёжик, ЗАКАЗ №12345 — готов!"
+	words := []string{"ежик", "заказ", "12345", "готов"}
+	originals := []string{"Ёжик", "ЗАКАЗ", "12345", "готов"}
+
+	tokens := Tokenize(text)
+	if len(tokens) != len(words) {
+		t.Fatalf("Tokenize(%q) = %+v, want words %q", text, tokens, words)
+	}
+	for i, token := range tokens {
+		if token.Word != words[i] {
+			t.Errorf("word %d = %q, want %q", i, token.Word, words[i])
+		}
+		if cut := text[token.Start:token.End]; cut != originals[i] {
+			t.Errorf("offsets %d:%d of word %d cut %q, want %q", token.Start, token.End, i, cut, originals[i])
+		}
+	}
+}
+
+func TestIndexText(t *testing.T) {
+	tests := map[string]string{
+		"Ещё ОДИН заказ!":    "еще один заказ",
+		"Ёлка и ёж":          "елка и еж",
+		"order #123, café":   "order 123 café",
+		"  ...  ":            "",
+		"Привет,мир":         "привет мир",
+		"straße Δέλτα 東京タワー": "straße δέλτα 東京タワー",
+	}
+	for text, want := range tests {
+		if got := IndexText(text); got != want {
+			t.Errorf("IndexText(%q) = %q, want %q", text, got, want)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		query string
+		want  Query
+		fts   string
+	}{
+		{
+			query: "заказ 12345",
+			want:  Query{{Words: []string{"заказ"}}, {Words: []string{"12345"}}},
+			fts:   `"заказ" AND "12345"`,
+		},
+		{
+			query: `"order 123" shipped`,
+			want:  Query{{Words: []string{"order", "123"}}, {Words: []string{"shipped"}}},
+			fts:   `"order 123" AND "shipped"`,
+		},
+		{
+			query: "отправ*",
+			want:  Query{{Words: []string{"отправ"}, Prefix: true}},
+			fts:   `"отправ"*`,
+		},
+		{
+			query: `"новый заказ"* Ёлка`,
+			want:  Query{{Words: []string{"новый", "заказ"}, Prefix: true}, {Words: []string{"елка"}}},
+			fts:   `"новый заказ"* AND "елка"`,
+		},
+		{
+			query: `"до встречи*"`,
+			want:  Query{{Words: []string{"до", "встречи"}, Prefix: true}},
+			fts:   `"до встречи"*`,
+		},
+		{
+			query: `  "Hello,   World!"   `,
+			want:  Query{{Words: []string{"hello", "world"}}},
+			fts:   `"hello world"`,
+		},
+		{
+			query: `"" * заказ`,
+			want:  Query{{Words: []string{"заказ"}}},
+			fts:   `"заказ"`,
+		},
+		{
+			// Quotes and operators never reach FTS5 syntax
+			query: `a"b OR NEAR(c)`,
+			want:  Query{{Words: []string{"a", "b"}}, {Words: []string{"or"}}, {Words: []string{"near", "c"}}},
+			fts:   `"a b" AND "or" AND "near c"`,
+		},
+	}
+
+	for _, tt := range tests {
+		got, err := Parse(tt.query)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.query, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Parse(%q) = %+v, want %+v", tt.query, got, tt.want)
+		}
+		if fts := got.FTS(); fts != tt.fts {
+			t.Errorf("Parse(%q).FTS() = %s, want %s", tt.query, fts, tt.fts)
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, query := range []string{"", "   ", "*", `"!!!"`, "— …", `"unterminated phrase`, `заказ "12345`} {
+		q, err := Parse(query)
+		if err == nil {
+			t.Errorf("Parse(%q) = %+v, want an error", query, q)
+			continue
+		}
+		if !errors.Is(err, domain.ErrInvalidSearch) {
+			t.Errorf("Parse(%q) error %v does not wrap ErrInvalidSearch", query, err)
+		}
+	}
+	if _, err := Parse(`"unterminated`); err == nil || !strings.Contains(err.Error(), "unterminated") {
+		t.Errorf("Parse of an unterminated quote: %v, want an unterminated phrase error", err)
+	}
+}
+
+func TestSnippet(t *testing.T) {
+	long := strings.Repeat("слово ", 10) + "заказ готов " + strings.Repeat("текст ", 20) + "конец"
+
+	tests := []struct {
+		name, text, query, want string
+	}{
+		{
+			name:  "word",
+			text:  "Ваш заказ готов",
+			query: "заказ",
+			want:  "Ваш <mark>заказ</mark> готов",
+		},
+		{
+			name:  "ё matches е",
+			text:  "Купили ёлку и Ёлочные игрушки",
+			query: "елку ёлочные",
+			want:  "Купили <mark>ёлку</mark> и <mark>Ёлочные</mark> игрушки",
+		},
+		{
+			name:  "prefix",
+			text:  "Отправлено, отправим, отправитель, правка",
+			query: "отправ*",
+			want:  "<mark>Отправлено</mark>, <mark>отправим</mark>, <mark>отправитель</mark>, правка",
+		},
+		{
+			name:  "phrase prefix only extends the last word",
+			text:  "order 1234 and orders 12",
+			query: `"order 12"*`,
+			want:  "<mark>order</mark> <mark>1234</mark> and orders <mark>12</mark>",
+		},
+		{
+			name:  "html is escaped",
+			text:  `<script>alert("x")</script> & заказ`,
+			query: "заказ",
+			want:  `&lt;script&gt;alert(&#34;x&#34;)&lt;/script&gt; &amp; <mark>заказ</mark>`,
+		},
+		{
+			name:  "matched word is escaped too",
+			text:  "a<b",
+			query: "b",
+			want:  "a&lt;<mark>b</mark>",
+		},
+		{
+			name:  "no words",
+			text:  "<>&",
+			query: "x",
+			want:  "&lt;&gt;&amp;",
+		},
+		{
+			name:  "ellipses around a match deep in the text",
+			text:  long,
+			query: "заказ",
+			want:  "…слово слово слово слово слово слово <mark>заказ</mark> готов" + strings.Repeat(" текст", 16) + "…",
+		},
+		{
+			name:  "no leading ellipsis near the start",
+			text:  "Заказ готов. " + strings.Repeat("текст ", 30),
+			query: "заказ",
+			want:  "<mark>Заказ</mark> готов. " + strings.TrimSuffix(strings.Repeat("текст ", 22), " ") + "…",
+		},
+		{
+			name:  "no trailing ellipsis at the end",
+			text:  strings.Repeat("текст ", 30) + "конец!",
+			query: "конец",
+			want:  "…" + strings.Repeat("текст ", 6) + "<mark>конец</mark>!",
+		},
+		{
+			name:  "no match shows the start",
+			text:  "Короткий текст без совпадений",
+			query: "заказ",
+			want:  "Короткий текст без совпадений",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := Parse(tt.query)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.query, err)
+			}
+			if got := Snippet(tt.text, q); got != tt.want {
+				t.Errorf("Snippet =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
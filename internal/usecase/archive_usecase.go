@@ -0,0 +1,255 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"whatsapp-parser/internal/config"
+	"whatsapp-parser/internal/domain"
+	"whatsapp-parser/internal/search"
+)
+
+const (
+	defaultMessagePage = 50
+	maxMessagePage     = 500
+	defaultSearchPage  = 20
+	maxSearchPage      = 100
+)
+
+type archiveUseCase struct {
+	messages domain.MessageRepository
+	media    domain.MediaRepository
+	sessions domain.SessionUseCase
+	cfg      *config.Config
+
+	ctx    context.Context // Cancelled on shutdown
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewArchiveUseCase creates a use case serving archived messages and keeping
+// archived chats of running sessions up to date
+func NewArchiveUseCase(messages domain.MessageRepository, media domain.MediaRepository, sessions domain.SessionUseCase, cfg *config.Config) domain.ArchiveUseCase {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &archiveUseCase{
+		messages: messages,
+		media:    media,
+		sessions: sessions,
+		cfg:      cfg,
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+func (u *archiveUseCase) ListMessages(ctx context.Context, tenantID, sessionID string, target domain.ChatTarget, q domain.MessageQuery) (*domain.ArchivedChat, []domain.Message, error) {
+	chat, err := u.findChat(tenantID, sessionID, target)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if q.Limit <= 0 {
+		q.Limit = defaultMessagePage
+	}
+	if q.Limit > maxMessagePage {
+		q.Limit = maxMessagePage
+	}
+	messages, err := u.messages.ListMessages(tenantID, sessionID, chat.JID, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	return chat, messages, nil
+}
+
+func (u *archiveUseCase) Search(ctx context.Context, tenantID string, q domain.SearchQuery) ([]domain.SearchHit, error) {
+	query, err := search.Parse(q.Text)
+	if err != nil {
+		return nil, err
+	}
+	if !q.From.IsZero() && !q.To.IsZero() && !q.From.Before(q.To) {
+		return nil, fmt.Errorf("%w: from must be before to", domain.ErrInvalidSearch)
+	}
+	if q.Limit <= 0 {
+		q.Limit = defaultSearchPage
+	}
+	if q.Limit > maxSearchPage {
+		q.Limit = maxSearchPage
+	}
+	if q.Offset < 0 {
+		q.Offset = 0
+	}
+
+	hits, err := u.messages.Search(tenantID, q)
+	if err != nil {
+		return nil, err
+	}
+	for i := range hits {
+		hits[i].Snippet = search.Snippet(hits[i].Message.Text, query)
+	}
+	return hits, nil
+}
+
+func (u *archiveUseCase) GetMedia(ctx context.Context, tenantID string, sessionIDs []string, hash string) (*domain.Media, io.ReadSeekCloser, error) {
+	// Media is shared between tenants, so it is only served to tenants whose
+	// archived messages refer to it
+	owners, err := u.messages.MediaSessions(tenantID, hash)
+	if err != nil {
+		return nil, nil, err
+	}
+	visible := len(owners) > 0 && len(sessionIDs) == 0
+	for _, owner := range owners {
+		for _, id := range sessionIDs {
+			visible = visible || owner == id
+		}
+	}
+	if !visible {
+		return nil, nil, fmt.Errorf("%w: %s", domain.ErrMediaNotFound, hash)
+	}
+
+	media, err := u.media.Get(hash)
+	if err != nil {
+		return nil, nil, err
+	}
+	if media == nil {
+		return nil, nil, fmt.Errorf("%w: %s", domain.ErrMediaNotFound, hash)
+	}
+	file, err := u.media.Open(hash)
+	if err != nil {
+		return nil, nil, err
+	}
+	return media, file, nil
+}
+
+// findChat looks a target up among the archived chats of a session
+func (u *archiveUseCase) findChat(tenantID, sessionID string, target domain.ChatTarget) (*domain.ArchivedChat, error) {
+	var chat *domain.ArchivedChat
+	var err error
+	switch {
+	case target.JID != "":
+		chat, err = u.messages.GetChat(tenantID, sessionID, target.JID)
+	case target.PhoneNumber != "":
+		chat, err = u.messages.GetChat(tenantID, sessionID, strings.TrimPrefix(target.PhoneNumber, "+")+"@c.us")
+	case target.Title != "":
+		var chats []*domain.ArchivedChat
+		chats, err = u.messages.FindChats(tenantID, sessionID, target.Title)
+		if len(chats) > 1 {
+			return nil, fmt.Errorf("%w: %d archived chats are titled %q", domain.ErrAmbiguousChat, len(chats), target.Title)
+		}
+		if len(chats) == 1 {
+			chat = chats[0]
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if chat == nil {
+		return nil, fmt.Errorf("%w: %s is not archived", domain.ErrChatNotFound, target.Key())
+	}
+	return chat, nil
+}
+
+// Start runs the background sync unless it is disabled
+func (u *archiveUseCase) Start() {
+	interval := time.Duration(u.cfg.ArchiveSync)
+	if interval <= 0 {
+		return
+	}
+	u.wg.Add(1)
+	go u.loop(interval)
+}
+
+// loop catches up archived chats every interval until shutdown
+func (u *archiveUseCase) loop(interval time.Duration) {
+	defer u.wg.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-u.ctx.Done():
+			return
+		case now := <-ticker.C:
+			u.sync(now)
+		}
+	}
+}
+
+// sync reads the archived chats of running sessions that had activity after
+// their newest archived message, from that message on; ReadChatHistory
+// archives what it reads. Activity is taken from the chat list, so quiet chats
+// are never opened, and the browser is only held while one chat is read.
+// Chats of sessions whose browser is not running wait for a later round.
+func (u *archiveUseCase) sync(now time.Time) {
+	chats, err := u.messages.ListUnsynced(now)
+	if err != nil {
+		log.Printf("Warning: failed to list chats to sync: %v", err)
+		return
+	}
+
+	// Chat activity of each session, read once per round; nil if unavailable
+	activity := make(map[string]map[string]time.Time)
+	for _, chat := range chats {
+		if u.ctx.Err() != nil {
+			return
+		}
+		if !u.sessions.Running(chat.SessionID) {
+			continue
+		}
+
+		key := chat.TenantID + "/" + chat.SessionID
+		latest, ok := activity[key]
+		if !ok {
+			ctx, cancel := context.WithTimeout(u.ctx, time.Duration(u.cfg.Timeouts.ExportChat))
+			latest, err = u.sessions.ChatActivity(ctx, chat.TenantID, chat.SessionID)
+			cancel()
+			if err != nil {
+				log.Printf("Warning: failed to read chat activity of session %s: %v", chat.SessionID, err)
+			}
+			activity[key] = latest
+		}
+		// Chats missing from the chat list cannot be read either
+		if last, ok := latest[chat.JID]; !ok || !last.After(chat.LastMessageAt) {
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(u.ctx, time.Duration(u.cfg.Timeouts.ExportChat))
+		target := domain.ChatTarget{JID: chat.JID}
+		err := u.sessions.ReadChatHistory(ctx, chat.TenantID, chat.SessionID, target, domain.HistoryOptions{Since: chat.LastMessageAt, Media: u.cfg.ArchiveMedia}, discardChat{})
+		cancel()
+		if err != nil {
+			log.Printf("Warning: failed to sync chat %s of session %s: %v", chat.JID, chat.SessionID, err)
+			continue
+		}
+		if err := u.messages.MarkSynced(chat.TenantID, chat.SessionID, chat.JID, time.Now()); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+	}
+}
+
+func (u *archiveUseCase) Shutdown(ctx context.Context) error {
+	u.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		u.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("archive sync did not stop in time: %w", ctx.Err())
+	}
+}
+
+// discardChat is a ChatWriter that drops everything
+type discardChat struct{}
+
+func (discardChat) WriteChat(domain.Chat) error       { return nil }
+func (discardChat) WriteMessage(domain.Message) error { return nil }
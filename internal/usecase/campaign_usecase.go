@@ -0,0 +1,565 @@
+package usecase
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"whatsapp-parser/internal/config"
+	"whatsapp-parser/internal/domain"
+	"whatsapp-parser/pkg/phone"
+
+	"github.com/google/uuid"
+)
+
+// maxCampaignRecipients caps the rows of one uploaded CSV
+const maxCampaignRecipients = 100000
+
+// campaignCompactEvery is how many recipient outcomes are logged before the
+// whole campaign is saved again, which keeps its progress log short
+const campaignCompactEvery = 1000
+
+// campaignRun is a campaign being sent. Its campaign is only touched under
+// campaignUseCase.mu while the run is active.
+type campaignRun struct {
+	campaign *domain.Campaign
+	claimed  map[int]bool // Recipients currently being sent, by index
+	logged   int          // Outcomes logged since the campaign was last saved
+	cancel   context.CancelFunc
+	done     chan struct{}
+}
+
+type campaignUseCase struct {
+	repo      domain.CampaignRepository
+	sessions  domain.SessionRepository
+	templates domain.TemplateUseCase
+	sender    domain.MessageSender
+	cfg       *config.Config
+
+	mu      sync.Mutex
+	closing bool
+	runs    map[string]*campaignRun // Keyed by campaign ID
+}
+
+// NewCampaignUseCase creates a campaign use case sending through sender
+func NewCampaignUseCase(repo domain.CampaignRepository, sessions domain.SessionRepository, templates domain.TemplateUseCase, sender domain.MessageSender, cfg *config.Config) domain.CampaignUseCase {
+	return &campaignUseCase{
+		repo:      repo,
+		sessions:  sessions,
+		templates: templates,
+		sender:    sender,
+		cfg:       cfg,
+		runs:      make(map[string]*campaignRun),
+	}
+}
+
+func (u *campaignUseCase) CreateCampaign(ctx context.Context, tenantID string, c *domain.Campaign, recipients io.Reader) error {
+	if !domain.ValidTenantID(tenantID) {
+		return domain.ErrInvalidTenant
+	}
+	if len(c.SessionIDs) == 0 {
+		return fmt.Errorf("%w: at least one session is required", domain.ErrInvalidCampaign)
+	}
+	for _, sessionID := range c.SessionIDs {
+		session, err := u.sessions.GetByID(tenantID, sessionID)
+		if err != nil {
+			return fmt.Errorf("failed to get session: %w", err)
+		}
+		if session == nil {
+			return fmt.Errorf("%w: %s", domain.ErrSessionNotFound, sessionID)
+		}
+	}
+
+	t, err := u.templates.GetTemplate(ctx, tenantID, c.TemplateID)
+	if err != nil {
+		return err
+	}
+	body, ok := selectVariant(t, c.Locale)
+	if !ok {
+		return fmt.Errorf("%w: template %s has no variant for locale %q", domain.ErrInvalidTemplate, t.ID, c.Locale)
+	}
+
+	rows, err := readRecipients(recipients, u.cfg.DefaultPhoneRegion)
+	if err != nil {
+		return err
+	}
+	// Rows that cannot be rendered fail up front instead of midway through the campaign
+	for i := range rows {
+		if rows[i].Status != domain.RecipientPending {
+			continue
+		}
+		if _, err := renderBody(body, recipientVariables(rows[i])); err != nil {
+			rows[i].Status = domain.RecipientFailed
+			rows[i].Error = err.Error()
+		}
+	}
+
+	c.ID = uuid.New().String()
+	c.TenantID = tenantID
+	c.Status = domain.CampaignRunning
+	c.Recipients = rows
+	c.CreatedAt = time.Now()
+	c.UpdatedAt = c.CreatedAt
+
+	if err := u.repo.Save(c); err != nil {
+		return fmt.Errorf("failed to save campaign: %w", err)
+	}
+	return u.startRun(c)
+}
+
+// readRecipients parses a recipient CSV. The header must contain phone_number
+// (or phone); other columns become variables. Invalid and duplicate numbers
+// are kept as failed rows so the export accounts for every line.
+func readRecipients(r io.Reader, defaultRegion string) ([]domain.CampaignRecipient, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if err == io.EOF {
+			return nil, fmt.Errorf("%w: recipient list is empty", domain.ErrInvalidCampaign)
+		}
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidCampaign, err)
+	}
+
+	phoneColumn := -1
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		header[i] = name
+		if phoneColumn < 0 && (name == "phone_number" || name == "phone") {
+			phoneColumn = i
+		}
+	}
+	if phoneColumn < 0 {
+		return nil, fmt.Errorf("%w: CSV header has no phone_number column", domain.ErrInvalidCampaign)
+	}
+
+	var rows []domain.CampaignRecipient
+	firstRow := make(map[string]int)
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", domain.ErrInvalidCampaign, err)
+		}
+		if len(rows) >= maxCampaignRecipients {
+			return nil, fmt.Errorf("%w: more than %d recipients", domain.ErrInvalidCampaign, maxCampaignRecipients)
+		}
+
+		row := domain.CampaignRecipient{
+			Row:       len(rows) + 1,
+			Status:    domain.RecipientPending,
+			Variables: make(map[string]string, len(record)-1),
+		}
+		for i, value := range record {
+			if i == phoneColumn {
+				row.PhoneNumber = strings.TrimSpace(value)
+			} else if header[i] != "" {
+				row.Variables[header[i]] = value
+			}
+		}
+
+		number, err := phone.Parse(row.PhoneNumber, defaultRegion)
+		switch {
+		case err != nil:
+			row.Status = domain.RecipientFailed
+			row.Error = err.Error()
+		case firstRow[number.E164()] > 0:
+			row.PhoneNumber = number.E164()
+			row.Status = domain.RecipientFailed
+			row.Error = fmt.Sprintf("duplicate of row %d", firstRow[number.E164()])
+		default:
+			row.PhoneNumber = number.E164()
+			firstRow[row.PhoneNumber] = row.Row
+		}
+		rows = append(rows, row)
+	}
+
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("%w: recipient list is empty", domain.ErrInvalidCampaign)
+	}
+	return rows, nil
+}
+
+// recipientVariables converts CSV values for template rendering
+func recipientVariables(r domain.CampaignRecipient) map[string]interface{} {
+	variables := make(map[string]interface{}, len(r.Variables))
+	for name, value := range r.Variables {
+		variables[name] = value
+	}
+	return variables
+}
+
+func (u *campaignUseCase) GetCampaign(ctx context.Context, tenantID string, id string) (*domain.Campaign, error) {
+	u.mu.Lock()
+	if run, ok := u.runs[id]; ok && run.campaign.TenantID == tenantID {
+		c := copyCampaign(run.campaign)
+		u.mu.Unlock()
+		return c, nil
+	}
+	u.mu.Unlock()
+
+	c, err := u.repo.GetByID(tenantID, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get campaign: %w", err)
+	}
+	if c == nil {
+		return nil, domain.ErrCampaignNotFound
+	}
+	return c, nil
+}
+
+func (u *campaignUseCase) ListCampaigns(ctx context.Context, tenantID string) ([]*domain.Campaign, error) {
+	campaigns, err := u.repo.List(tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list campaigns: %w", err)
+	}
+
+	// Stored state of running campaigns may lag behind the latest send
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	for i, c := range campaigns {
+		if run, ok := u.runs[c.ID]; ok {
+			campaigns[i] = copyCampaign(run.campaign)
+		}
+	}
+	return campaigns, nil
+}
+
+func (u *campaignUseCase) PauseCampaign(ctx context.Context, tenantID, id string) (*domain.Campaign, error) {
+	return u.stop(ctx, tenantID, id, domain.CampaignPaused)
+}
+
+func (u *campaignUseCase) CancelCampaign(ctx context.Context, tenantID, id string) (*domain.Campaign, error) {
+	return u.stop(ctx, tenantID, id, domain.CampaignCancelled)
+}
+
+// stop halts a campaign's run and moves it to the paused or cancelled status
+func (u *campaignUseCase) stop(ctx context.Context, tenantID, id string, status domain.CampaignStatus) (*domain.Campaign, error) {
+	c, err := u.GetCampaign(ctx, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+	change, err := stoppable(c, status)
+	if err != nil {
+		return nil, err
+	}
+	if !change {
+		return c, nil
+	}
+
+	u.mu.Lock()
+	run, ok := u.runs[id]
+	u.mu.Unlock()
+	if ok {
+		run.cancel()
+		<-run.done
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if ok {
+		c = run.campaign
+	} else {
+		// The snapshot may predate a run that finished since; what it saved wins
+		c, err = u.repo.GetByID(tenantID, id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get campaign: %w", err)
+		}
+		if c == nil {
+			return nil, domain.ErrCampaignNotFound
+		}
+	}
+	change, err = stoppable(c, status)
+	if err != nil {
+		return nil, err
+	}
+	if !change {
+		return copyCampaign(c), nil
+	}
+
+	c.Status = status
+	c.Error = ""
+	c.UpdatedAt = time.Now()
+	if status == domain.CampaignCancelled {
+		for i := range c.Recipients {
+			if c.Recipients[i].Status == domain.RecipientPending {
+				c.Recipients[i].Status = domain.RecipientSkipped
+			}
+		}
+		c.FinishedAt = &c.UpdatedAt
+	}
+	if err := u.repo.Save(c); err != nil {
+		return nil, fmt.Errorf("failed to save campaign: %w", err)
+	}
+	return copyCampaign(c), nil
+}
+
+// stoppable tells whether a campaign can be given the pause or cancel status.
+// Finished campaigns cannot; pausing a paused campaign changes nothing.
+func stoppable(c *domain.Campaign, status domain.CampaignStatus) (bool, error) {
+	switch {
+	case c.Status == domain.CampaignCompleted, c.Status == domain.CampaignCancelled:
+		return false, fmt.Errorf("%w: campaign is %s", domain.ErrCampaignState, c.Status)
+	case c.Status == domain.CampaignPaused && status == domain.CampaignPaused:
+		return false, nil
+	}
+	return true, nil
+}
+
+func (u *campaignUseCase) ResumeCampaign(ctx context.Context, tenantID, id string) (*domain.Campaign, error) {
+	c, err := u.GetCampaign(ctx, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+	if c.Status != domain.CampaignPaused {
+		return nil, fmt.Errorf("%w: campaign is %s", domain.ErrCampaignState, c.Status)
+	}
+
+	c.Status = domain.CampaignRunning
+	c.Error = ""
+	c.UpdatedAt = time.Now()
+	if err := u.repo.Save(c); err != nil {
+		return nil, fmt.Errorf("failed to save campaign: %w", err)
+	}
+	if err := u.startRun(c); err != nil {
+		return nil, err
+	}
+	return u.GetCampaign(ctx, tenantID, id)
+}
+
+func (u *campaignUseCase) ExportCampaign(ctx context.Context, tenantID, id string, w io.Writer) error {
+	c, err := u.GetCampaign(ctx, tenantID, id)
+	if err != nil {
+		return err
+	}
+
+	out := csv.NewWriter(w)
+	if err := out.Write([]string{"row", "phone_number", "status", "session_id", "message_id", "sent_at", "error"}); err != nil {
+		return err
+	}
+	for _, r := range c.Recipients {
+		var sentAt string
+		if r.SentAt != nil {
+			sentAt = r.SentAt.Format(time.RFC3339)
+		}
+		record := []string{strconv.Itoa(r.Row), r.PhoneNumber, string(r.Status), r.SessionID, r.MessageID, sentAt, r.Error}
+		if err := out.Write(record); err != nil {
+			return err
+		}
+	}
+	out.Flush()
+	return out.Error()
+}
+
+func (u *campaignUseCase) Start() error {
+	campaigns, err := u.repo.ListRunning()
+	if err != nil {
+		return fmt.Errorf("failed to list running campaigns: %w", err)
+	}
+	for _, c := range campaigns {
+		if err := u.startRun(c); err != nil {
+			return err
+		}
+		log.Printf("Resumed campaign %s of tenant %s", c.ID, c.TenantID)
+	}
+	return nil
+}
+
+// Shutdown stops all runs and waits for them to save their state. Running
+// campaigns keep their status and are resumed by Start.
+func (u *campaignUseCase) Shutdown(ctx context.Context) error {
+	u.mu.Lock()
+	u.closing = true
+	runs := make([]*campaignRun, 0, len(u.runs))
+	for _, run := range u.runs {
+		runs = append(runs, run)
+	}
+	u.mu.Unlock()
+
+	for _, run := range runs {
+		run.cancel()
+	}
+	for _, run := range runs {
+		select {
+		case <-run.done:
+		case <-ctx.Done():
+			return fmt.Errorf("campaigns did not stop in time: %w", ctx.Err())
+		}
+	}
+	return nil
+}
+
+// startRun sends the pending recipients of a campaign in the background,
+// with one worker per session of the pool
+func (u *campaignUseCase) startRun(c *domain.Campaign) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if u.closing {
+		return domain.ErrShuttingDown
+	}
+	if _, ok := u.runs[c.ID]; ok {
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	run := &campaignRun{
+		campaign: c,
+		claimed:  make(map[int]bool),
+		cancel:   cancel,
+		done:     make(chan struct{}),
+	}
+	u.runs[c.ID] = run
+
+	go func() {
+		defer close(run.done)
+		defer cancel()
+
+		var wg sync.WaitGroup
+		reasons := make([]error, len(c.SessionIDs))
+		for i, sessionID := range c.SessionIDs {
+			wg.Add(1)
+			go func(i int, sessionID string) {
+				defer wg.Done()
+				reasons[i] = u.work(ctx, run, sessionID)
+			}(i, sessionID)
+		}
+		wg.Wait()
+
+		u.finish(ctx, run, reasons)
+	}()
+	return nil
+}
+
+// work sends pending recipients through one session until none are left. The
+// returned error tells why the worker stopped early.
+func (u *campaignUseCase) work(ctx context.Context, run *campaignRun, sessionID string) error {
+	c := run.campaign
+	for {
+		u.mu.Lock()
+		index := -1
+		for i := range c.Recipients {
+			if c.Recipients[i].Status == domain.RecipientPending && !run.claimed[i] {
+				index = i
+				break
+			}
+		}
+		if index < 0 {
+			u.mu.Unlock()
+			return nil
+		}
+		run.claimed[index] = true
+		recipient := c.Recipients[index]
+		u.mu.Unlock()
+
+		messageID, err := u.sendTo(ctx, c, sessionID, recipient)
+
+		u.mu.Lock()
+		delete(run.claimed, index)
+		stopped := ctx.Err() != nil ||
+			errors.Is(err, domain.ErrShuttingDown) ||
+			errors.Is(err, domain.ErrQuotaExceeded) ||
+			errors.Is(err, domain.ErrSessionNotFound)
+		if stopped {
+			// The recipient stays pending and is sent after a resume
+			u.mu.Unlock()
+			if err == nil {
+				err = ctx.Err()
+			}
+			return err
+		}
+
+		r := &c.Recipients[index]
+		r.SessionID = sessionID
+		if err != nil {
+			r.Status = domain.RecipientFailed
+			r.Error = err.Error()
+		} else {
+			now := time.Now()
+			r.Status = domain.RecipientSent
+			r.MessageID = messageID
+			r.SentAt = &now
+			r.Error = ""
+		}
+		c.UpdatedAt = time.Now()
+		// Only the recipient is written, and the whole campaign now and then
+		run.logged++
+		if run.logged >= campaignCompactEvery {
+			run.logged = 0
+			err = u.repo.Save(c)
+		} else {
+			err = u.repo.SaveRecipient(c, index)
+		}
+		if err != nil {
+			log.Printf("Warning: failed to save campaign %s: %v", c.ID, err)
+		}
+		u.mu.Unlock()
+	}
+}
+
+// sendTo renders the campaign template for a recipient and queues the message
+func (u *campaignUseCase) sendTo(ctx context.Context, c *domain.Campaign, sessionID string, r domain.CampaignRecipient) (string, error) {
+	text, err := u.templates.RenderTemplate(ctx, c.TenantID, c.TemplateID, c.Locale, recipientVariables(r))
+	if err != nil {
+		return "", err
+	}
+
+	return u.sender.Send(ctx, c.TenantID, sessionID, domain.OutgoingMessage{
+		Target: domain.ChatTarget{PhoneNumber: r.PhoneNumber},
+		Text:   text,
+	})
+}
+
+// finish records the outcome of a run once all its workers stopped. Runs
+// stopped by pause, cancel or shutdown leave the status to their caller.
+func (u *campaignUseCase) finish(ctx context.Context, run *campaignRun, reasons []error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	c := run.campaign
+	delete(u.runs, c.ID)
+	if ctx.Err() != nil || u.closing {
+		if err := u.repo.Save(c); err != nil {
+			log.Printf("Warning: failed to save campaign %s: %v", c.ID, err)
+		}
+		return
+	}
+
+	c.UpdatedAt = time.Now()
+	if c.Progress().Pending == 0 {
+		c.Status = domain.CampaignCompleted
+		c.FinishedAt = &c.UpdatedAt
+	} else {
+		// Every worker gave up, e.g. the daily quota is used up or sessions were deleted
+		var messages []string
+		for i, err := range reasons {
+			if err != nil {
+				messages = append(messages, fmt.Sprintf("%s: %v", c.SessionIDs[i], err))
+			}
+		}
+		c.Status = domain.CampaignPaused
+		c.Error = strings.Join(messages, "; ")
+		log.Printf("Campaign %s paused: %s", c.ID, c.Error)
+	}
+	if err := u.repo.Save(c); err != nil {
+		log.Printf("Warning: failed to save campaign %s: %v", c.ID, err)
+	}
+}
+
+// copyCampaign returns a snapshot of a campaign that a run may still modify
+func copyCampaign(c *domain.Campaign) *domain.Campaign {
+	copied := *c
+	copied.SessionIDs = append([]string(nil), c.SessionIDs...)
+	copied.Recipients = append([]domain.CampaignRecipient(nil), c.Recipients...)
+	return &copied
+}
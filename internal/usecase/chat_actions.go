@@ -0,0 +1,37 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+
+	"whatsapp-parser/internal/domain"
+)
+
+func (u *sessionUseCase) ChatAction(ctx context.Context, tenantID, sessionID string, target domain.ChatTarget, req domain.ChatActionRequest) (*domain.Chat, error) {
+	if err := u.begin(); err != nil {
+		return nil, err
+	}
+	defer u.inflight.Done()
+
+	session, err := u.getSession(tenantID, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	b, err := u.browserFor(ctx, session)
+	if err != nil {
+		return nil, err
+	}
+	if err := b.acquire(); err != nil {
+		return nil, err
+	}
+	state, err := b.client.ChatAction(ctx, clientTarget(target), string(req.Action), req.MuteFor)
+	b.mu.Unlock()
+	if err != nil {
+		return nil, fmt.Errorf("chat action %s failed: %w", req.Action, translateClientError(err))
+	}
+	if state == nil {
+		return nil, nil
+	}
+	chat := domainChat(*state)
+	return &chat, nil
+}
@@ -0,0 +1,195 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"whatsapp-parser/internal/domain"
+	"whatsapp-parser/pkg/phone"
+)
+
+func (u *sessionUseCase) CheckContacts(ctx context.Context, tenantID, sessionID string, phoneNumbers []string) ([]domain.ContactCheck, error) {
+	if err := u.begin(); err != nil {
+		return nil, err
+	}
+	defer u.inflight.Done()
+
+	session, err := u.getSession(tenantID, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]domain.ContactCheck, len(phoneNumbers))
+	var rateLimit *domain.RateLimitError
+	ttl := time.Duration(u.cfg.ContactCheckTTL)
+
+	for i, number := range phoneNumbers {
+		results[i] = domain.ContactCheck{
+			PhoneNumber: number,
+			Status:      domain.RegistrationUnknown,
+		}
+
+		// Serve fresh results from the cache without touching the browser
+		cached, err := u.contacts.Get(tenantID, number)
+		if err != nil {
+			log.Printf("Warning: failed to read contact check cache: %v", err)
+		}
+		if cached != nil && time.Since(cached.CheckedAt) < ttl {
+			cached.Cached = true
+			results[i] = *cached
+			continue
+		}
+
+		// Once pacing stops us, the remaining numbers stay unknown
+		if rateLimit != nil {
+			results[i].Error = rateLimit.Error()
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			results[i].Error = err.Error()
+			continue
+		}
+
+		// Every browser lookup opens a chat, so it is paced like a send
+		if _, err := u.pacer.await(ctx, session, ""); err != nil {
+			switch {
+			case errors.As(err, &rateLimit):
+				results[i].Error = rateLimit.Error()
+				continue
+			case ctx.Err() != nil:
+				results[i].Error = err.Error()
+				continue
+			}
+			return nil, err
+		}
+
+		check, err := u.checkNumber(ctx, session, number)
+		if err != nil {
+			results[i].Error = err.Error()
+			continue
+		}
+		results[i] = *check
+		if err := u.contacts.Save(tenantID, check); err != nil {
+			log.Printf("Warning: failed to cache contact check: %v", err)
+		}
+	}
+
+	// Nothing could be answered, so report the limit itself
+	if rateLimit != nil && !anyKnown(results) {
+		return nil, rateLimit
+	}
+	return results, nil
+}
+
+// checkNumber looks a number up in the session's browser
+func (u *sessionUseCase) checkNumber(ctx context.Context, session *domain.Session, number string) (*domain.ContactCheck, error) {
+	b, err := u.browserFor(ctx, session)
+	if err != nil {
+		return nil, err
+	}
+	if err := b.acquire(); err != nil {
+		return nil, err
+	}
+	registered, err := b.client.CheckNumber(ctx, number)
+	b.mu.Unlock()
+	if err != nil {
+		return nil, fmt.Errorf("failed to check number: %w", err)
+	}
+
+	check := &domain.ContactCheck{
+		PhoneNumber: number,
+		Status:      domain.RegistrationUnregistered,
+		CheckedAt:   time.Now(),
+	}
+	if registered {
+		check.Status = domain.RegistrationRegistered
+		if n, err := phone.Parse(number, ""); err == nil {
+			check.JID = n.JID()
+		}
+	}
+	return check, nil
+}
+
+func anyKnown(results []domain.ContactCheck) bool {
+	for _, r := range results {
+		if r.Status != domain.RegistrationUnknown {
+			return true
+		}
+	}
+	return false
+}
+
+func (u *sessionUseCase) ListContacts(ctx context.Context, tenantID, sessionID string, withAbout bool) ([]domain.Contact, error) {
+	if err := u.begin(); err != nil {
+		return nil, err
+	}
+	defer u.inflight.Done()
+
+	session, err := u.getSession(tenantID, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	b, err := u.browserFor(ctx, session)
+	if err != nil {
+		return nil, err
+	}
+	if err := b.acquire(); err != nil {
+		return nil, err
+	}
+	found, err := b.client.Contacts(ctx, withAbout)
+	b.mu.Unlock()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read contacts: %w", err)
+	}
+
+	contacts := make([]domain.Contact, len(found))
+	for i, c := range found {
+		contacts[i] = domain.Contact{
+			JID:               c.JID,
+			PhoneNumber:       "+" + c.Number,
+			Name:              c.Name,
+			PushName:          c.PushName,
+			About:             c.About,
+			IsBusiness:        c.IsBusiness,
+			ProfilePictureURL: c.PictureURL,
+		}
+	}
+	sort.Slice(contacts, func(i, j int) bool {
+		return contacts[i].Name < contacts[j].Name
+	})
+	return contacts, nil
+}
+
+func (u *sessionUseCase) GroupInfo(ctx context.Context, tenantID, sessionID string, target domain.ChatTarget) (*domain.Chat, error) {
+	if err := u.begin(); err != nil {
+		return nil, err
+	}
+	defer u.inflight.Done()
+
+	session, err := u.getSession(tenantID, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	b, err := u.browserFor(ctx, session)
+	if err != nil {
+		return nil, err
+	}
+	if err := b.acquire(); err != nil {
+		return nil, err
+	}
+	group, err := b.client.GroupInfo(ctx, clientTarget(target))
+	b.mu.Unlock()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read group: %w", translateClientError(err))
+	}
+
+	chat := domainChat(*group)
+	if err := u.messages.SaveChat(tenantID, sessionID, chat); err != nil {
+		log.Printf("Warning: failed to archive chat %s: %v", chat.JID, err)
+	}
+	return &chat, nil
+}
@@ -0,0 +1,180 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"whatsapp-parser/internal/domain"
+	"whatsapp-parser/pkg/selenium"
+)
+
+func (u *sessionUseCase) CreateGroup(ctx context.Context, tenantID, sessionID, title string, phoneNumbers []string) (*domain.GroupResult, error) {
+	result := &domain.GroupResult{Title: title}
+	err := u.withGroupBrowser(ctx, tenantID, sessionID, domain.GroupAddMembers, "", true, func(client *selenium.WhatsAppClient) error {
+		jid, changes, err := client.CreateGroup(ctx, title, phoneNumbers)
+		if err != nil {
+			return fmt.Errorf("failed to create group: %w", err)
+		}
+		result.JID = jid
+		result.Participants = participantChanges(domain.GroupAddMembers, phoneNumbers, changes)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (u *sessionUseCase) UpdateGroup(ctx context.Context, tenantID, sessionID string, target domain.ChatTarget, update domain.GroupUpdate) (*domain.GroupResult, error) {
+	result := &domain.GroupResult{}
+	err := u.withGroupBrowser(ctx, tenantID, sessionID, domain.GroupUpdateInfo, target.Key(), false, func(client *selenium.WhatsAppClient) error {
+		jid, err := client.UpdateGroup(ctx, clientTarget(target), selenium.GroupUpdate{
+			Title:       update.Title,
+			Description: update.Description,
+			Icon:        update.Icon,
+			AdminsOnly:  update.AdminsOnly,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to update group: %w", err)
+		}
+		result.JID = jid
+		if update.Title != nil {
+			result.Title = *update.Title
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (u *sessionUseCase) ChangeParticipants(ctx context.Context, tenantID, sessionID string, target domain.ChatTarget, action domain.GroupOperation, phoneNumbers []string) (*domain.GroupResult, error) {
+	result := &domain.GroupResult{}
+	// Adding strangers to groups is what gets numbers banned, so it is paced like a send
+	paced := action == domain.GroupAddMembers
+	err := u.withGroupBrowser(ctx, tenantID, sessionID, action, target.Key(), paced, func(client *selenium.WhatsAppClient) error {
+		jid, changes, err := client.ChangeParticipants(ctx, clientTarget(target), string(action), phoneNumbers)
+		if err != nil {
+			return fmt.Errorf("failed to %s participants: %w", action, err)
+		}
+		result.JID = jid
+		result.Participants = participantChanges(action, phoneNumbers, changes)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (u *sessionUseCase) GroupInviteLink(ctx context.Context, tenantID, sessionID string, target domain.ChatTarget, revoke bool) (*domain.GroupResult, error) {
+	op := domain.GroupInviteLink
+	if revoke {
+		op = domain.GroupRevokeInvite
+	}
+	result := &domain.GroupResult{}
+	err := u.withGroupBrowser(ctx, tenantID, sessionID, op, target.Key(), false, func(client *selenium.WhatsAppClient) error {
+		jid, link, err := client.InviteLink(ctx, clientTarget(target), revoke)
+		if err != nil {
+			return fmt.Errorf("failed to get invite link: %w", err)
+		}
+		result.JID = jid
+		result.InviteLink = link
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// withGroupBrowser runs fn with the session's browser locked. Paced operations
+// take a send of the daily quota, given back if they fail, and a send slot
+// first. A refusal for lack of admin rights is reported as a GroupAdminError
+// naming the operation.
+func (u *sessionUseCase) withGroupBrowser(ctx context.Context, tenantID, sessionID string, op domain.GroupOperation, group string, paced bool, fn func(client *selenium.WhatsAppClient) error) (err error) {
+	if err := u.begin(); err != nil {
+		return err
+	}
+	defer u.inflight.Done()
+
+	session, err := u.getSession(tenantID, sessionID)
+	if err != nil {
+		return err
+	}
+	if paced {
+		day := time.Now().Format("2006-01-02")
+		if err := u.reserveQuota(tenantID, day, 1); err != nil {
+			return err
+		}
+		defer func() {
+			if err != nil {
+				u.releaseQuota(tenantID, day, 1)
+			}
+		}()
+		if err := u.pacer.reserve(session, "", time.Now()); err != nil {
+			return err
+		}
+	}
+	b, err := u.browserFor(ctx, session)
+	if err != nil {
+		return err
+	}
+	if err := b.acquire(); err != nil {
+		return err
+	}
+	err = fn(b.client)
+	b.mu.Unlock()
+
+	if errors.Is(err, selenium.ErrNotAdmin) {
+		return &domain.GroupAdminError{Group: group, Operation: op}
+	}
+	return translateClientError(err)
+}
+
+// participantChanges pairs the requested numbers with WhatsApp's result codes.
+// A number WhatsApp did not report on is unknown rather than assumed changed.
+func participantChanges(action domain.GroupOperation, phoneNumbers []string, changes []selenium.ParticipantChange) []domain.ParticipantChange {
+	codes := make(map[string]int, len(changes))
+	for _, c := range changes {
+		codes[c.JID] = c.Code
+	}
+
+	result := make([]domain.ParticipantChange, len(phoneNumbers))
+	for i, number := range phoneNumbers {
+		code, ok := codes[number[1:]+"@c.us"]
+		if !ok {
+			result[i] = domain.ParticipantChange{PhoneNumber: number, Status: domain.ParticipantUnknown}
+			continue
+		}
+		result[i] = domain.ParticipantChange{
+			PhoneNumber: number,
+			Status:      participantStatus(action, code),
+			Code:        code,
+		}
+	}
+	return result
+}
+
+// participantStatus interprets a WhatsApp result code of a participant change
+func participantStatus(action domain.GroupOperation, code int) domain.ParticipantStatus {
+	switch code {
+	case 200:
+		return domain.ParticipantOK
+	case 403:
+		return domain.ParticipantInviteRequired
+	case 404:
+		if action == domain.GroupAddMembers {
+			return domain.ParticipantNotOnWhatsApp
+		}
+		return domain.ParticipantNotMember
+	case 408:
+		return domain.ParticipantRecentlyLeft
+	case 409:
+		return domain.ParticipantAlreadyMember
+	}
+	return domain.ParticipantFailed
+}
@@ -0,0 +1,183 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"whatsapp-parser/internal/domain"
+	"whatsapp-parser/pkg/selenium"
+)
+
+// archiveBatch is the number of messages archived in one transaction
+const archiveBatch = 200
+
+// ReadChatHistory opens a chat in the session's browser and streams it to w,
+// archiving every message on the way. The browser is held for the whole read,
+// so sends of the session wait for it.
+func (u *sessionUseCase) ReadChatHistory(ctx context.Context, tenantID, sessionID string, target domain.ChatTarget, opts domain.HistoryOptions, w domain.ChatWriter) error {
+	if err := u.begin(); err != nil {
+		return err
+	}
+	defer u.inflight.Done()
+
+	session, err := u.getSession(tenantID, sessionID)
+	if err != nil {
+		return err
+	}
+	b, err := u.browserFor(ctx, session)
+	if err != nil {
+		return err
+	}
+
+	if err := b.acquire(); err != nil {
+		return err
+	}
+	defer b.mu.Unlock()
+
+	archive := &archiveWriter{messages: u.messages, tenantID: tenantID, sessionID: sessionID, w: w}
+	defer archive.flush()
+	downloads := opts.Media
+
+	err = b.client.ReadHistory(ctx, clientTarget(target), selenium.HistoryOptions{
+		Since:      opts.Since,
+		Limit:      opts.Limit,
+		Thumbnails: opts.Thumbnails,
+	}, func(chat selenium.Chat) error {
+		return archive.WriteChat(domainChat(chat))
+	}, func(m selenium.ChatMessage) error {
+		msg := domainMessage(m)
+		if downloads && domain.IsMediaType(msg.Type) {
+			media, err := u.saveMedia(ctx, b, msg.ID)
+			switch {
+			case err == nil:
+				msg.MediaHash = media.Hash
+				if msg.Attachment == "" {
+					msg.Attachment = media.FileName
+				}
+			case errors.Is(err, domain.ErrMediaQuotaExceeded):
+				log.Printf("Warning: media of session %s is no longer downloaded: %v", sessionID, err)
+				downloads = false
+			case ctx.Err() != nil:
+				return ctx.Err()
+			default:
+				log.Printf("Warning: failed to download media of message %s: %v", msg.ID, err)
+			}
+		}
+		return archive.WriteMessage(msg)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to read chat history: %w", translateClientError(err))
+	}
+	return nil
+}
+
+// ChatActivity reads the latest activity of the chats of a session's chat list.
+// The browser is only held for one script, so sends are not kept waiting.
+func (u *sessionUseCase) ChatActivity(ctx context.Context, tenantID, sessionID string) (map[string]time.Time, error) {
+	if err := u.begin(); err != nil {
+		return nil, err
+	}
+	defer u.inflight.Done()
+
+	session, err := u.getSession(tenantID, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	b, err := u.browserFor(ctx, session)
+	if err != nil {
+		return nil, err
+	}
+	if err := b.acquire(); err != nil {
+		return nil, err
+	}
+	activity, err := b.client.ChatActivity(ctx)
+	b.mu.Unlock()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read chat activity: %w", translateClientError(err))
+	}
+	return activity, nil
+}
+
+// saveMedia moves the media of a message loaded in the browser into the media store
+func (u *sessionUseCase) saveMedia(ctx context.Context, b *browser, messageID string) (*domain.Media, error) {
+	info, reader, err := b.client.OpenMedia(ctx, messageID)
+	if err != nil {
+		return nil, translateClientError(err)
+	}
+	defer reader.Close()
+	return u.media.Save(reader, info.MimeType, info.FileName)
+}
+
+// domainChat converts a chat read by the browser client
+func domainChat(c selenium.Chat) domain.Chat {
+	chat := domain.Chat{
+		JID:      c.JID,
+		Title:    c.Title,
+		IsGroup:  c.IsGroup,
+		Archived: c.Archived,
+		Pinned:   c.Pinned,
+		Muted:    c.MuteExpiration != 0,
+	}
+	if c.MuteExpiration > 0 {
+		until := time.Unix(c.MuteExpiration, 0).UTC()
+		chat.MutedUntil = &until
+	}
+	switch {
+	case c.Unread > 0:
+		chat.UnreadCount = c.Unread
+	case c.Unread < 0:
+		chat.MarkedUnread = true
+	}
+	for _, p := range c.Participants {
+		participant := domain.Participant{
+			JID:        p.JID,
+			Name:       p.Name,
+			Admin:      p.Admin,
+			SuperAdmin: p.SuperAdmin,
+		}
+		if p.Number != "" {
+			participant.PhoneNumber = "+" + p.Number
+		}
+		if p.Joined > 0 {
+			joined := time.Unix(p.Joined, 0).UTC()
+			participant.JoinedAt = &joined
+		}
+		chat.Participants = append(chat.Participants, participant)
+	}
+	return chat
+}
+
+// archiveWriter stores messages in the archive in batches before passing them on
+type archiveWriter struct {
+	messages  domain.MessageRepository
+	tenantID  string
+	sessionID string
+	w         domain.ChatWriter
+	batch     []domain.Message
+}
+
+func (a *archiveWriter) WriteChat(chat domain.Chat) error {
+	if err := a.messages.SaveChat(a.tenantID, a.sessionID, chat); err != nil {
+		log.Printf("Warning: failed to archive chat %s: %v", chat.JID, err)
+	}
+	return a.w.WriteChat(chat)
+}
+
+func (a *archiveWriter) WriteMessage(msg domain.Message) error {
+	a.batch = append(a.batch, msg)
+	if len(a.batch) >= archiveBatch {
+		a.flush()
+	}
+	return a.w.WriteMessage(msg)
+}
+
+// flush archives the pending batch; archive failures do not stop the read
+func (a *archiveWriter) flush() {
+	if _, err := a.messages.SaveMessages(a.tenantID, a.sessionID, a.batch); err != nil {
+		log.Printf("Warning: failed to archive messages of session %s: %v", a.sessionID, err)
+	}
+	a.batch = a.batch[:0]
+}
@@ -0,0 +1,49 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"whatsapp-parser/internal/chatexport"
+	"whatsapp-parser/internal/config"
+	"whatsapp-parser/internal/domain"
+)
+
+type importUseCase struct {
+	cfg *config.Config
+}
+
+// NewImportUseCase creates a use case reading chats exported from phones
+func NewImportUseCase(cfg *config.Config) domain.ImportUseCase {
+	return &importUseCase{
+		cfg: cfg,
+	}
+}
+
+func (u *importUseCase) ImportChatExport(ctx context.Context, tenantID, fileName string, file io.ReaderAt, size int64, opts domain.ChatExportOptions) (*domain.ChatImport, error) {
+	if !domain.ValidTenantID(tenantID) {
+		return nil, domain.ErrInvalidTenant
+	}
+
+	parseOpts := chatexport.Options{
+		SelfName:      opts.SelfName,
+		DefaultRegion: u.cfg.DefaultPhoneRegion,
+	}
+	switch opts.DateOrder {
+	case "", "dmy", "mdy", "ymd":
+		parseOpts.DateOrder = opts.DateOrder
+	default:
+		return nil, fmt.Errorf("%w: date_order must be dmy, mdy or ymd", domain.ErrInvalidChatExport)
+	}
+	if opts.TimeZone != "" {
+		loc, err := time.LoadLocation(opts.TimeZone)
+		if err != nil {
+			return nil, fmt.Errorf("%w: unknown time zone %q", domain.ErrInvalidChatExport, opts.TimeZone)
+		}
+		parseOpts.Location = loc
+	}
+
+	return chatexport.ParseFile(fileName, file, size, parseOpts)
+}
@@ -0,0 +1,96 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"whatsapp-parser/internal/domain"
+	"whatsapp-parser/pkg/selenium"
+)
+
+func (u *sessionUseCase) MessageAction(ctx context.Context, tenantID, sessionID, messageID string, req domain.MessageActionRequest) error {
+	if err := u.begin(); err != nil {
+		return err
+	}
+	defer u.inflight.Done()
+
+	session, err := u.getSession(tenantID, sessionID)
+	if err != nil {
+		return err
+	}
+
+	// Forwards reach other chats, so they are checked and paced like sends
+	if req.Action == domain.MessageForward {
+		for _, target := range req.Targets {
+			if err := u.checkRecipient(ctx, tenantID, session, target); err != nil {
+				return err
+			}
+		}
+		day := time.Now().Format("2006-01-02")
+		if err := u.reserveQuota(tenantID, day, len(req.Targets)); err != nil {
+			return err
+		}
+		forwarded, err := u.forwardEach(ctx, session, req.Targets, func(target domain.ChatTarget) error {
+			b, err := u.browserFor(ctx, session)
+			if err != nil {
+				return err
+			}
+			if err := b.acquire(); err != nil {
+				return err
+			}
+			_, err = b.client.ForwardMessage(ctx, messageID, []selenium.ChatTarget{clientTarget(target)})
+			b.mu.Unlock()
+			return err
+		})
+		// Only forwards seen to arrive count as sends, even if others failed
+		u.releaseQuota(tenantID, day, len(req.Targets)-forwarded)
+		if err != nil {
+			return fmt.Errorf("message action %s failed: %w", req.Action, translateClientError(err))
+		}
+		return nil
+	}
+
+	b, err := u.browserFor(ctx, session)
+	if err != nil {
+		return err
+	}
+	if err := b.acquire(); err != nil {
+		return err
+	}
+	switch req.Action {
+	case domain.MessageReact:
+		err = b.client.React(ctx, messageID, req.Emoji)
+	case domain.MessageEdit:
+		err = b.client.EditMessage(ctx, messageID, req.Text)
+	case domain.MessageDelete:
+		err = b.client.DeleteForEveryone(ctx, messageID)
+	case domain.MessageStar, domain.MessageUnstar:
+		err = b.client.StarMessage(ctx, messageID, req.Action == domain.MessageStar)
+	default:
+		err = fmt.Errorf("unknown message action %q", req.Action)
+	}
+	b.mu.Unlock()
+
+	if err != nil {
+		return fmt.Errorf("message action %s failed: %w", req.Action, translateClientError(err))
+	}
+	return nil
+}
+
+// forwardEach forwards to the targets one at a time. Each forward waits for
+// its own send slot right before it goes out, and gives the slot back if it
+// fails. It returns how many forwards went out before the first failure.
+func (u *sessionUseCase) forwardEach(ctx context.Context, session *domain.Session, targets []domain.ChatTarget, forward func(target domain.ChatTarget) error) (int, error) {
+	for i, target := range targets {
+		at, err := u.pacer.await(ctx, session, target.Key())
+		if err != nil {
+			return i, err
+		}
+		if err := forward(target); err != nil {
+			u.pacer.release(session, target.Key(), at)
+			return i, err
+		}
+	}
+	return len(targets), nil
+}
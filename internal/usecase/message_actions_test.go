@@ -0,0 +1,106 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"whatsapp-parser/internal/config"
+	"whatsapp-parser/internal/domain"
+)
+
+func TestForwardEachWaitsForEachSlot(t *testing.T) {
+	limits := config.Default().Pacing
+	p, clock := newTestPacer(t, limits)
+	u := &sessionUseCase{pacer: p}
+	session := &domain.Session{ID: "s1", TenantID: "t1", CreatedAt: clock.Now().Add(-30 * 24 * time.Hour)}
+	targets := []domain.ChatTarget{{PhoneNumber: "+79991234567"}, {JID: "120363000000000000@g.us"}}
+
+	var sentAt []time.Time
+	forwarded, err := u.forwardEach(context.Background(), session, targets, func(target domain.ChatTarget) error {
+		sentAt = append(sentAt, clock.Now())
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("forwardEach: %v", err)
+	}
+	if forwarded != 2 || len(sentAt) != 2 {
+		t.Fatalf("forwarded %d of 2 targets, %d forwards made", forwarded, len(sentAt))
+	}
+	if gap := sentAt[1].Sub(sentAt[0]); gap < time.Duration(limits.MinGap) || gap > time.Duration(limits.MinGap+limits.Jitter) {
+		t.Errorf("second forward went out %s after the first, want between %s and %s", gap, time.Duration(limits.MinGap), time.Duration(limits.MinGap+limits.Jitter))
+	}
+
+	state, _ := p.repo.Get(session.TenantID, session.ID)
+	if len(state.Sends) != 2 {
+		t.Errorf("pacing recorded %d sends, want 2", len(state.Sends))
+	}
+	for _, target := range targets {
+		if _, ok := state.LastByRecipient[target.Key()]; !ok {
+			t.Errorf("no recipient cooldown recorded for %s", target.Key())
+		}
+	}
+}
+
+func TestForwardEachReleasesFailedSlot(t *testing.T) {
+	limits := config.Default().Pacing
+	p, clock := newTestPacer(t, limits)
+	u := &sessionUseCase{pacer: p}
+	session := &domain.Session{ID: "s1", TenantID: "t1", CreatedAt: clock.Now().Add(-30 * 24 * time.Hour)}
+	targets := []domain.ChatTarget{{PhoneNumber: "+79991234567"}, {PhoneNumber: "+79997654321"}, {PhoneNumber: "+79990000000"}}
+
+	failure := errors.New("forward dialog not found")
+	calls := 0
+	forwarded, err := u.forwardEach(context.Background(), session, targets, func(target domain.ChatTarget) error {
+		calls++
+		if calls == 2 {
+			return failure
+		}
+		return nil
+	})
+	if !errors.Is(err, failure) {
+		t.Fatalf("forwardEach error = %v, want %v", err, failure)
+	}
+	if forwarded != 1 || calls != 2 {
+		t.Fatalf("forwarded %d after %d attempts, want 1 after 2", forwarded, calls)
+	}
+
+	state, _ := p.repo.Get(session.TenantID, session.ID)
+	if len(state.Sends) != 1 {
+		t.Errorf("pacing kept %d sends, want only the forward that went out", len(state.Sends))
+	}
+	if _, ok := state.LastByRecipient[targets[1].Key()]; ok {
+		t.Errorf("failed forward left a recipient cooldown for %s", targets[1].Key())
+	}
+	if state.NextAllowedAt.After(clock.Now()) {
+		t.Errorf("failed forward left the gap until %s, now is %s", state.NextAllowedAt, clock.Now())
+	}
+
+	// The released slot is free again for the same recipient
+	if err := p.reserve(session, targets[1].Key(), clock.Now()); err != nil {
+		t.Errorf("reserve after release: %v", err)
+	}
+}
+
+func TestForwardEachGivesUpBeforeDeadline(t *testing.T) {
+	p, clock := newTestPacer(t, config.Default().Pacing)
+	u := &sessionUseCase{pacer: p}
+	session := &domain.Session{ID: "s1", TenantID: "t1", CreatedAt: clock.Now().Add(-30 * 24 * time.Hour)}
+	targets := []domain.ChatTarget{{PhoneNumber: "+79991234567"}, {PhoneNumber: "+79997654321"}}
+
+	// The deadline is in real time; the fake clock does not reach it
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	forwarded, err := u.forwardEach(ctx, session, targets, func(domain.ChatTarget) error { return nil })
+	var rateLimit *domain.RateLimitError
+	if !errors.As(err, &rateLimit) {
+		t.Fatalf("forwardEach error = %v, want a rate limit", err)
+	}
+	if forwarded != 1 {
+		t.Errorf("forwarded %d, want 1", forwarded)
+	}
+	if len(clock.slept) != 0 {
+		t.Errorf("waited %v although the deadline was too close", clock.slept)
+	}
+}
@@ -0,0 +1,197 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"sync"
+	"time"
+
+	"whatsapp-parser/internal/config"
+	"whatsapp-parser/internal/domain"
+)
+
+// pacer enforces per-session send pacing. State is persisted after every
+// reservation so limits survive restarts.
+type pacer struct {
+	repo domain.PacingRepository
+	cfg  *config.Config
+
+	mu   sync.Mutex
+	rand *rand.Rand
+
+	// now and sleep are the clock of await, replaced in tests
+	now   func() time.Time
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+func newPacer(repo domain.PacingRepository, cfg *config.Config) *pacer {
+	return &pacer{
+		repo:  repo,
+		cfg:   cfg,
+		rand:  rand.New(rand.NewSource(time.Now().UnixNano())),
+		now:   time.Now,
+		sleep: wait,
+	}
+}
+
+// await reserves a send of session to recipient like reserve, waiting while
+// pacing asks to, and returns the time of the reservation. It gives up with the
+// *domain.RateLimitError if ctx ends before the wait would.
+func (p *pacer) await(ctx context.Context, session *domain.Session, recipient string) (time.Time, error) {
+	for {
+		now := p.now()
+		err := p.reserve(session, recipient, now)
+		var rateLimit *domain.RateLimitError
+		if !errors.As(err, &rateLimit) {
+			return now, err
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < rateLimit.RetryAfter {
+			return time.Time{}, rateLimit
+		}
+		if err := p.sleep(ctx, rateLimit.RetryAfter); err != nil {
+			return time.Time{}, err
+		}
+	}
+}
+
+// release gives back the send of session to recipient reserved at a time
+// when it did not go out: it no longer counts against the limits, the
+// recipient cooldown it started is cleared and, unless a later send was
+// reserved since, so is the gap.
+func (p *pacer) release(session *domain.Session, recipient string, at time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	state, err := p.repo.Get(session.TenantID, session.ID)
+	if err != nil {
+		log.Printf("Warning: failed to release send of session %s: %v", session.ID, err)
+		return
+	}
+	later := false
+	for i := len(state.Sends) - 1; i >= 0; i-- {
+		if state.Sends[i].Equal(at) {
+			state.Sends = append(state.Sends[:i], state.Sends[i+1:]...)
+			break
+		}
+		later = later || state.Sends[i].After(at)
+	}
+	if !later && state.NextAllowedAt.After(at) {
+		state.NextAllowedAt = at
+	}
+	if last, ok := state.LastByRecipient[recipient]; ok && last.Equal(at) {
+		delete(state.LastByRecipient, recipient)
+	}
+	if err := p.repo.Save(session.TenantID, session.ID, state); err != nil {
+		log.Printf("Warning: failed to release send of session %s: %v", session.ID, err)
+	}
+}
+
+// reserve records a send of session to recipient at now, or returns a
+// *domain.RateLimitError telling when the send would be allowed. An empty
+// recipient counts against all limits except the recipient cooldown.
+func (p *pacer) reserve(session *domain.Session, recipient string, now time.Time) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	state, err := p.repo.Get(session.TenantID, session.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get pacing state: %v", err)
+	}
+	limits := p.cfg.PacingFor(session.ID)
+
+	// Forget sends older than the longest window
+	recent := state.Sends[:0]
+	for _, t := range state.Sends {
+		if now.Sub(t) < 24*time.Hour {
+			recent = append(recent, t)
+		}
+	}
+	state.Sends = recent
+	if state.LastByRecipient == nil {
+		state.LastByRecipient = make(map[string]time.Time)
+	}
+	for r, t := range state.LastByRecipient {
+		if now.Sub(t) >= time.Duration(limits.RecipientCooldown) {
+			delete(state.LastByRecipient, r)
+		}
+	}
+
+	if err := p.check(session, limits, state, recipient, now); err != nil {
+		return err
+	}
+
+	gap := time.Duration(limits.MinGap)
+	if limits.Jitter > 0 {
+		gap += time.Duration(p.rand.Int63n(int64(limits.Jitter)))
+	}
+	state.Sends = append(state.Sends, now)
+	state.NextAllowedAt = now.Add(gap)
+	if limits.RecipientCooldown > 0 && recipient != "" {
+		state.LastByRecipient[recipient] = now
+	}
+
+	if err := p.repo.Save(session.TenantID, session.ID, state); err != nil {
+		return fmt.Errorf("failed to save pacing state: %v", err)
+	}
+	return nil
+}
+
+// check returns the first limit the send would violate
+func (p *pacer) check(session *domain.Session, limits config.Pacing, state *domain.PacingState, recipient string, now time.Time) error {
+	if now.Before(state.NextAllowedAt) {
+		return &domain.RateLimitError{Reason: "minimum gap between sends", RetryAfter: state.NextAllowedAt.Sub(now)}
+	}
+
+	if last, ok := state.LastByRecipient[recipient]; ok && limits.RecipientCooldown > 0 {
+		return &domain.RateLimitError{
+			Reason:     "recipient cooldown",
+			RetryAfter: last.Add(time.Duration(limits.RecipientCooldown)).Sub(now),
+		}
+	}
+
+	if limits.HourlyLimit > 0 {
+		if retry, ok := windowRetry(state.Sends, limits.HourlyLimit, time.Hour, now); !ok {
+			return &domain.RateLimitError{Reason: fmt.Sprintf("hourly limit of %d sends", limits.HourlyLimit), RetryAfter: retry}
+		}
+	}
+
+	daily := limits.DailyLimit
+	reason := fmt.Sprintf("daily limit of %d sends", daily)
+	age := now.Sub(session.CreatedAt)
+	for _, stage := range limits.WarmUp {
+		if age < time.Duration(stage.Days)*24*time.Hour {
+			if daily == 0 || stage.DailyLimit < daily {
+				daily = stage.DailyLimit
+				reason = fmt.Sprintf("warm-up limit of %d sends per day for numbers linked less than %d days ago", daily, stage.Days)
+			}
+			break
+		}
+	}
+	if daily > 0 {
+		if retry, ok := windowRetry(state.Sends, daily, 24*time.Hour, now); !ok {
+			return &domain.RateLimitError{Reason: reason, RetryAfter: retry}
+		}
+	}
+
+	return nil
+}
+
+// windowRetry reports whether another send fits into the sliding window and,
+// if not, how long until the oldest send in the window expires
+func windowRetry(sends []time.Time, limit int, window time.Duration, now time.Time) (time.Duration, bool) {
+	var inWindow []time.Time
+	for _, t := range sends {
+		if now.Sub(t) < window {
+			inWindow = append(inWindow, t)
+		}
+	}
+	if len(inWindow) < limit {
+		return 0, true
+	}
+	// Sends are appended in order, so the window frees up when this one expires
+	oldest := inWindow[len(inWindow)-limit]
+	return oldest.Add(window).Sub(now), false
+}
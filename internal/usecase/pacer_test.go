@@ -0,0 +1,348 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"whatsapp-parser/internal/config"
+	"whatsapp-parser/internal/domain"
+)
+
+// memoryPacing keeps pacing states in memory, round-tripping them through
+// JSON like the file repository does
+type memoryPacing struct {
+	mu     sync.Mutex
+	states map[string][]byte
+}
+
+func (m *memoryPacing) Get(tenantID, sessionID string) (*domain.PacingState, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	state := &domain.PacingState{}
+	if data, ok := m.states[tenantID+"/"+sessionID]; ok {
+		if err := json.Unmarshal(data, state); err != nil {
+			return nil, err
+		}
+	}
+	return state, nil
+}
+
+func (m *memoryPacing) Save(tenantID, sessionID string, state *domain.PacingState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.states == nil {
+		m.states = make(map[string][]byte)
+	}
+	m.states[tenantID+"/"+sessionID] = data
+	return nil
+}
+
+// testClock is a fake clock; sleeping advances it at once
+type testClock struct {
+	now   time.Time
+	slept []time.Duration
+}
+
+func (c *testClock) Now() time.Time {
+	return c.now
+}
+
+func (c *testClock) Sleep(ctx context.Context, d time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	c.slept = append(c.slept, d)
+	c.now = c.now.Add(d)
+	return nil
+}
+
+// newTestPacer returns a pacer with the given limits for every session, a
+// fake clock and a seeded random source
+func newTestPacer(t *testing.T, limits config.Pacing) (*pacer, *testClock) {
+	t.Helper()
+	cfg := config.Default()
+	cfg.Pacing = limits
+	clock := &testClock{now: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)}
+	p := newPacer(&memoryPacing{}, cfg)
+	p.rand = rand.New(rand.NewSource(1))
+	p.now = clock.Now
+	p.sleep = clock.Sleep
+	return p, clock
+}
+
+// testSession is older than any warm-up stage unless a test says otherwise
+func testSession(clock *testClock, age time.Duration) *domain.Session {
+	return &domain.Session{ID: "s1", TenantID: "t1", CreatedAt: clock.Now().Add(-age)}
+}
+
+// rateLimit returns the *domain.RateLimitError err wraps, or fails the test
+func rateLimit(t *testing.T, err error) *domain.RateLimitError {
+	t.Helper()
+	var limit *domain.RateLimitError
+	if !errors.As(err, &limit) {
+		t.Fatalf("got %v, want a rate limit", err)
+	}
+	return limit
+}
+
+func TestPacerGap(t *testing.T) {
+	tests := []struct {
+		name   string
+		minGap time.Duration
+		jitter time.Duration
+	}{
+		{"gap only", 15 * time.Second, 0},
+		{"gap and jitter", 15 * time.Second, 15 * time.Second},
+		{"jitter only", 0, 5 * time.Second},
+		{"sub-second", 200 * time.Millisecond, 300 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, clock := newTestPacer(t, config.Pacing{MinGap: config.Duration(tt.minGap), Jitter: config.Duration(tt.jitter)})
+			session := testSession(clock, 365*24*time.Hour)
+
+			gaps := make(map[time.Duration]bool)
+			for i := 0; i < 20; i++ {
+				now := clock.Now()
+				if err := p.reserve(session, "", now); err != nil {
+					t.Fatalf("send %d: %v", i, err)
+				}
+				state, _ := p.repo.Get(session.TenantID, session.ID)
+				gap := state.NextAllowedAt.Sub(now)
+				if gap < tt.minGap || (tt.jitter > 0 && gap >= tt.minGap+tt.jitter) || (tt.jitter == 0 && gap != tt.minGap) {
+					t.Fatalf("send %d: gap %s, want %s plus jitter below %s", i, gap, tt.minGap, tt.jitter)
+				}
+				gaps[gap] = true
+
+				if gap > 0 {
+					early := now.Add(gap - time.Millisecond)
+					limit := rateLimit(t, p.reserve(session, "", early))
+					if limit.Reason != "minimum gap between sends" || limit.RetryAfter != time.Millisecond {
+						t.Fatalf("send %d: early send limited by %q for %s, want the gap for 1ms", i, limit.Reason, limit.RetryAfter)
+					}
+				}
+				clock.now = now.Add(gap)
+			}
+			if tt.jitter > 0 && len(gaps) < 2 {
+				t.Errorf("jitter of %s never varied the gap", tt.jitter)
+			}
+		})
+	}
+}
+
+func TestPacerWindows(t *testing.T) {
+	tests := []struct {
+		name   string
+		limits config.Pacing
+		// sends are how long before now earlier sends were made, oldest first
+		sends      []time.Duration
+		wantReason string // "" if the send is allowed
+		wantRetry  time.Duration
+	}{
+		{
+			name:   "hourly limit not reached",
+			limits: config.Pacing{HourlyLimit: 3},
+			sends:  []time.Duration{50 * time.Minute, 30 * time.Minute},
+		},
+		{
+			name:       "hourly limit reached",
+			limits:     config.Pacing{HourlyLimit: 3},
+			sends:      []time.Duration{50 * time.Minute, 30 * time.Minute, 10 * time.Minute},
+			wantReason: "hourly limit of 3 sends",
+			wantRetry:  10 * time.Minute,
+		},
+		{
+			name:   "hourly window slides past old sends",
+			limits: config.Pacing{HourlyLimit: 3},
+			sends:  []time.Duration{2 * time.Hour, 61 * time.Minute, 60 * time.Minute, 30 * time.Minute, 10 * time.Minute},
+		},
+		{
+			name:       "hourly retry waits for the send that frees a slot",
+			limits:     config.Pacing{HourlyLimit: 2},
+			sends:      []time.Duration{55 * time.Minute, 40 * time.Minute, 20 * time.Minute},
+			wantReason: "hourly limit of 2 sends",
+			wantRetry:  20 * time.Minute,
+		},
+		{
+			name:       "daily limit reached",
+			limits:     config.Pacing{HourlyLimit: 10, DailyLimit: 3},
+			sends:      []time.Duration{23 * time.Hour, 12 * time.Hour, 2 * time.Hour},
+			wantReason: "daily limit of 3 sends",
+			wantRetry:  time.Hour,
+		},
+		{
+			name:   "daily window slides past old sends",
+			limits: config.Pacing{DailyLimit: 3},
+			sends:  []time.Duration{30 * time.Hour, 24 * time.Hour, 12 * time.Hour, 2 * time.Hour},
+		},
+		{
+			name:       "hourly limit is checked before the daily one",
+			limits:     config.Pacing{HourlyLimit: 1, DailyLimit: 1},
+			sends:      []time.Duration{30 * time.Minute},
+			wantReason: "hourly limit of 1 sends",
+			wantRetry:  30 * time.Minute,
+		},
+		{
+			name:   "zero limits are off",
+			limits: config.Pacing{},
+			sends:  []time.Duration{3 * time.Minute, 2 * time.Minute, time.Minute},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, clock := newTestPacer(t, tt.limits)
+			session := testSession(clock, 365*24*time.Hour)
+			state := &domain.PacingState{}
+			for _, ago := range tt.sends {
+				state.Sends = append(state.Sends, clock.Now().Add(-ago))
+			}
+			if err := p.repo.Save(session.TenantID, session.ID, state); err != nil {
+				t.Fatal(err)
+			}
+
+			err := p.reserve(session, "", clock.Now())
+			if tt.wantReason == "" {
+				if err != nil {
+					t.Fatalf("reserve: %v", err)
+				}
+				return
+			}
+			limit := rateLimit(t, err)
+			if limit.Reason != tt.wantReason || limit.RetryAfter != tt.wantRetry {
+				t.Errorf("limited by %q for %s, want %q for %s", limit.Reason, limit.RetryAfter, tt.wantReason, tt.wantRetry)
+			}
+		})
+	}
+}
+
+func TestPacerWarmUp(t *testing.T) {
+	stages := []config.WarmUpStage{{Days: 3, DailyLimit: 20}, {Days: 7, DailyLimit: 50}, {Days: 14, DailyLimit: 150}}
+	day := 24 * time.Hour
+
+	tests := []struct {
+		name      string
+		daily     int
+		age       time.Duration
+		wantLimit int
+		warmUp    bool // the limit comes from a warm-up stage
+	}{
+		{"first stage", 500, 12 * time.Hour, 20, true},
+		{"last hour of the first stage", 500, 3*day - time.Hour, 20, true},
+		{"second stage starts on day 3", 500, 3 * day, 50, true},
+		{"third stage", 500, 10 * day, 150, true},
+		{"warmed up", 500, 14 * day, 500, false},
+		{"daily limit below the stage", 30, 5 * day, 30, false},
+		{"no daily limit", 0, day, 20, true},
+		{"no daily limit once warmed up", 0, 30 * day, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, clock := newTestPacer(t, config.Pacing{DailyLimit: tt.daily, WarmUp: stages})
+			session := testSession(clock, tt.age)
+
+			// Fill the day up to the expected limit, one send a second from 12 hours ago
+			start := clock.Now().Add(-12 * time.Hour)
+			n := tt.wantLimit
+			if n == 0 {
+				n = 1000
+			}
+			state := &domain.PacingState{}
+			for i := 0; i < n; i++ {
+				state.Sends = append(state.Sends, start.Add(time.Duration(i)*time.Second))
+			}
+			if err := p.repo.Save(session.TenantID, session.ID, state); err != nil {
+				t.Fatal(err)
+			}
+			// One send fewer still fits
+			if err := p.check(session, p.cfg.PacingFor(session.ID), &domain.PacingState{Sends: state.Sends[1:]}, "", clock.Now()); err != nil {
+				t.Fatalf("send %d: %v", n, err)
+			}
+
+			err := p.reserve(session, "", clock.Now())
+			if tt.wantLimit == 0 {
+				if err != nil {
+					t.Fatalf("send without a limit: %v", err)
+				}
+				return
+			}
+			limit := rateLimit(t, err)
+			if got := strings.HasPrefix(limit.Reason, "warm-up limit"); got != tt.warmUp {
+				t.Errorf("limited by %q, want a warm-up limit: %v", limit.Reason, tt.warmUp)
+			}
+			if !strings.Contains(limit.Reason, fmt.Sprintf(" %d sends", tt.wantLimit)) {
+				t.Errorf("limited by %q, want a limit of %d sends", limit.Reason, tt.wantLimit)
+			}
+			// The first send of the day leaves the window 12 hours from now
+			if limit.RetryAfter != 12*time.Hour {
+				t.Errorf("retry after %s, want 12h", limit.RetryAfter)
+			}
+		})
+	}
+}
+
+func TestPacerRecipientCooldown(t *testing.T) {
+	const cooldown = 10 * time.Minute
+	tests := []struct {
+		name      string
+		first     string
+		next      string
+		after     time.Duration
+		wantRetry time.Duration // 0 if the send is allowed
+	}{
+		{"same recipient", "+79991234567", "+79991234567", time.Minute, 9 * time.Minute},
+		{"same recipient just before the cooldown ends", "+79991234567", "+79991234567", cooldown - time.Second, time.Second},
+		{"same recipient after the cooldown", "+79991234567", "+79991234567", cooldown, 0},
+		{"other recipient", "+79991234567", "+79997654321", time.Minute, 0},
+		{"no recipient has no cooldown", "", "", time.Minute, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, clock := newTestPacer(t, config.Pacing{MinGap: config.Duration(time.Second), RecipientCooldown: config.Duration(cooldown)})
+			session := testSession(clock, 365*24*time.Hour)
+
+			if err := p.reserve(session, tt.first, clock.Now()); err != nil {
+				t.Fatalf("first send: %v", err)
+			}
+			err := p.reserve(session, tt.next, clock.Now().Add(tt.after))
+			if tt.wantRetry == 0 {
+				if err != nil {
+					t.Fatalf("second send: %v", err)
+				}
+				return
+			}
+			limit := rateLimit(t, err)
+			if limit.Reason != "recipient cooldown" || limit.RetryAfter != tt.wantRetry {
+				t.Errorf("limited by %q for %s, want the recipient cooldown for %s", limit.Reason, limit.RetryAfter, tt.wantRetry)
+			}
+		})
+	}
+
+	// Expired cooldowns are dropped from the saved state
+	p, clock := newTestPacer(t, config.Pacing{RecipientCooldown: config.Duration(cooldown)})
+	session := testSession(clock, 365*24*time.Hour)
+	if err := p.reserve(session, "a", clock.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.reserve(session, "b", clock.Now().Add(cooldown)); err != nil {
+		t.Fatal(err)
+	}
+	state, _ := p.repo.Get(session.TenantID, session.ID)
+	if _, ok := state.LastByRecipient["a"]; ok || len(state.LastByRecipient) != 1 {
+		t.Errorf("cooldowns after expiry = %v, want only b", state.LastByRecipient)
+	}
+}
@@ -0,0 +1,300 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"whatsapp-parser/internal/config"
+	"whatsapp-parser/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/robfig/cron/v3"
+)
+
+type scheduleUseCase struct {
+	repo     domain.ScheduleRepository
+	sessions domain.SessionRepository
+	sender   domain.MessageSender
+	cfg      *config.Config
+
+	mu        sync.Mutex
+	schedules map[string]*domain.ScheduledMessage // Active schedules by ID
+	sending   map[string]bool                     // Schedules with a run in progress
+	ctx       context.Context                     // Cancelled on shutdown
+	cancel    context.CancelFunc
+	wg        sync.WaitGroup // Scheduler loop and runs in progress
+}
+
+// NewScheduleUseCase creates a scheduled message use case that hands due
+// messages to the session's sender
+func NewScheduleUseCase(repo domain.ScheduleRepository, sessions domain.SessionRepository, sender domain.MessageSender, cfg *config.Config) domain.ScheduleUseCase {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &scheduleUseCase{
+		repo:      repo,
+		sessions:  sessions,
+		sender:    sender,
+		cfg:       cfg,
+		schedules: make(map[string]*domain.ScheduledMessage),
+		sending:   make(map[string]bool),
+		ctx:       ctx,
+		cancel:    cancel,
+	}
+}
+
+func (u *scheduleUseCase) CreateSchedule(ctx context.Context, tenantID string, s *domain.ScheduledMessage) error {
+	if !domain.ValidTenantID(tenantID) {
+		return domain.ErrInvalidTenant
+	}
+	session, err := u.sessions.GetByID(tenantID, s.SessionID)
+	if err != nil {
+		return fmt.Errorf("failed to get session: %w", err)
+	}
+	if session == nil {
+		return domain.ErrSessionNotFound
+	}
+
+	now := time.Now()
+	if s.CatchUp == "" {
+		s.CatchUp = u.cfg.Scheduler.CatchUp
+	}
+	if !domain.ValidCatchUpPolicy(s.CatchUp) {
+		return fmt.Errorf("%w: unknown catch_up policy %q", domain.ErrInvalidSchedule, s.CatchUp)
+	}
+	if s.TimeZone == "" {
+		s.TimeZone = "UTC"
+	}
+	switch {
+	case s.SendAt != nil && s.Cron != "":
+		return fmt.Errorf("%w: send_at and cron are mutually exclusive", domain.ErrInvalidSchedule)
+	case s.SendAt != nil:
+		if s.SendAt.Before(now.Add(-time.Duration(u.cfg.Scheduler.Grace))) {
+			return fmt.Errorf("%w: send_at is in the past", domain.ErrInvalidSchedule)
+		}
+		s.NextRunAt = s.SendAt
+	case s.Cron != "":
+		next, err := nextCronRun(s, now)
+		if err != nil {
+			return err
+		}
+		s.NextRunAt = &next
+	default:
+		return fmt.Errorf("%w: send_at or cron is required", domain.ErrInvalidSchedule)
+	}
+
+	s.ID = uuid.New().String()
+	s.TenantID = tenantID
+	s.Status = domain.ScheduleActive
+	s.CreatedAt = now
+	s.UpdatedAt = now
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if err := u.repo.Save(s); err != nil {
+		return fmt.Errorf("failed to save schedule: %w", err)
+	}
+	u.schedules[s.ID] = s
+	return nil
+}
+
+// nextCronRun returns the first run of a cron schedule after t, evaluated in its time zone
+func nextCronRun(s *domain.ScheduledMessage, t time.Time) (time.Time, error) {
+	loc, err := time.LoadLocation(s.TimeZone)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: unknown time zone %q", domain.ErrInvalidSchedule, s.TimeZone)
+	}
+	schedule, err := cron.ParseStandard(s.Cron)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: %v", domain.ErrInvalidSchedule, err)
+	}
+	next := schedule.Next(t.In(loc))
+	if next.IsZero() {
+		return time.Time{}, fmt.Errorf("%w: cron expression %q never fires", domain.ErrInvalidSchedule, s.Cron)
+	}
+	return next, nil
+}
+
+func (u *scheduleUseCase) ListSchedules(ctx context.Context, tenantID, sessionID string) ([]*domain.ScheduledMessage, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	schedules, err := u.repo.List(tenantID, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list schedules: %w", err)
+	}
+	return schedules, nil
+}
+
+func (u *scheduleUseCase) CancelSchedule(ctx context.Context, tenantID, sessionID, id string) (*domain.ScheduledMessage, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	s, ok := u.schedules[id]
+	if !ok {
+		var err error
+		if s, err = u.repo.GetByID(tenantID, id); err != nil {
+			return nil, fmt.Errorf("failed to get schedule: %w", err)
+		}
+	}
+	if s == nil || s.TenantID != tenantID || s.SessionID != sessionID {
+		return nil, domain.ErrScheduleNotFound
+	}
+	if s.Status != domain.ScheduleActive {
+		copied := *s
+		return &copied, nil
+	}
+
+	s.Status = domain.ScheduleCancelled
+	s.NextRunAt = nil
+	s.UpdatedAt = time.Now()
+	if err := u.repo.Save(s); err != nil {
+		return nil, fmt.Errorf("failed to save schedule: %w", err)
+	}
+	delete(u.schedules, id)
+
+	copied := *s
+	return &copied, nil
+}
+
+func (u *scheduleUseCase) Start() error {
+	schedules, err := u.repo.ListActive()
+	if err != nil {
+		return fmt.Errorf("failed to list schedules: %w", err)
+	}
+
+	u.mu.Lock()
+	for _, s := range schedules {
+		u.schedules[s.ID] = s
+	}
+	u.mu.Unlock()
+	log.Printf("Loaded %d active scheduled messages", len(schedules))
+
+	u.wg.Add(1)
+	go u.loop()
+	return nil
+}
+
+// loop hands due messages to the sender until shutdown
+func (u *scheduleUseCase) loop() {
+	defer u.wg.Done()
+
+	ticker := time.NewTicker(time.Duration(u.cfg.Scheduler.Interval))
+	defer ticker.Stop()
+
+	u.dispatch(time.Now())
+	for {
+		select {
+		case <-u.ctx.Done():
+			return
+		case now := <-ticker.C:
+			u.dispatch(now)
+		}
+	}
+}
+
+// dispatch starts a run for every due schedule. Runs more than the grace
+// period late were missed and follow the schedule's catch-up policy.
+func (u *scheduleUseCase) dispatch(now time.Time) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	for id, s := range u.schedules {
+		if u.sending[id] || s.NextRunAt == nil || s.NextRunAt.After(now) {
+			continue
+		}
+
+		missed := now.Sub(*s.NextRunAt) > time.Duration(u.cfg.Scheduler.Grace)
+		if missed && s.CatchUp == domain.CatchUpSkip {
+			log.Printf("Skipping missed run of scheduled message %s due at %s", id, s.NextRunAt.Format(time.RFC3339))
+			s.LastError = "missed while the service was down"
+			u.advance(s, now, now)
+			continue
+		}
+
+		u.sending[id] = true
+		u.wg.Add(1)
+		go u.run(s, s.Message, missed)
+	}
+}
+
+// run sends one occurrence of a schedule and records its outcome
+func (u *scheduleUseCase) run(s *domain.ScheduledMessage, msg domain.OutgoingMessage, missed bool) {
+	defer u.wg.Done()
+
+	messageID, err := u.sender.Send(u.ctx, s.TenantID, s.SessionID, msg)
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	delete(u.sending, s.ID)
+	if u.ctx.Err() != nil {
+		// Interrupted by shutdown; the run is repeated according to the catch-up policy
+		return
+	}
+
+	now := time.Now()
+	s.Runs++
+	s.LastRunAt = &now
+	s.LastMessageID = messageID
+	s.LastError = ""
+	if err != nil {
+		s.LastError = err.Error()
+		log.Printf("Scheduled message %s failed: %v", s.ID, err)
+	}
+	if s.Status != domain.ScheduleActive {
+		// Cancelled while sending
+		if err := u.repo.Save(s); err != nil {
+			log.Printf("Warning: failed to save schedule %s: %v", s.ID, err)
+		}
+		return
+	}
+
+	// With the "all" policy the next missed occurrence follows right away
+	from := now
+	if missed && s.CatchUp == domain.CatchUpAll {
+		from = *s.NextRunAt
+	}
+	u.advance(s, from, now)
+}
+
+// advance moves a schedule to its first run after from, completing one-off
+// messages, and saves it. The caller holds u.mu.
+func (u *scheduleUseCase) advance(s *domain.ScheduledMessage, from, now time.Time) {
+	s.UpdatedAt = now
+	if s.Cron == "" {
+		s.Status = domain.ScheduleCompleted
+		s.NextRunAt = nil
+		delete(u.schedules, s.ID)
+	} else if next, err := nextCronRun(s, from); err != nil {
+		s.Status = domain.ScheduleCompleted
+		s.NextRunAt = nil
+		s.LastError = err.Error()
+		delete(u.schedules, s.ID)
+	} else {
+		s.NextRunAt = &next
+	}
+
+	if err := u.repo.Save(s); err != nil {
+		log.Printf("Warning: failed to save schedule %s: %v", s.ID, err)
+	}
+}
+
+// Shutdown stops the scheduler loop and cancels sends in progress; they run
+// again after a restart according to the catch-up policy
+func (u *scheduleUseCase) Shutdown(ctx context.Context) error {
+	u.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		u.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("scheduler did not stop in time: %w", ctx.Err())
+	}
+}
@@ -0,0 +1,138 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"whatsapp-parser/internal/config"
+	"whatsapp-parser/internal/domain"
+)
+
+type sendResult struct {
+	messageID string
+	err       error
+}
+
+type sendJob struct {
+	ctx       context.Context
+	tenantID  string
+	sessionID string
+	msg       domain.OutgoingMessage
+	done      chan sendResult
+}
+
+// sendQueue holds the waiting jobs of one session. A worker goroutine runs
+// while the queue is not empty.
+type sendQueue struct {
+	jobs    []*sendJob
+	running bool
+}
+
+type sender struct {
+	sessions domain.SessionUseCase
+	timeout  time.Duration
+
+	mu     sync.Mutex
+	queues map[string]*sendQueue // Keyed by tenant and session ID
+}
+
+// NewSender creates a message sender on top of the session use case. Sends
+// of a session are delivered in order; each one gets the send message timeout.
+func NewSender(sessions domain.SessionUseCase, cfg *config.Config) domain.MessageSender {
+	return &sender{
+		sessions: sessions,
+		timeout:  time.Duration(cfg.Timeouts.SendMessage),
+		queues:   make(map[string]*sendQueue),
+	}
+}
+
+// Send queues the message and waits until it is sent, fails or ctx is done
+func (s *sender) Send(ctx context.Context, tenantID, sessionID string, msg domain.OutgoingMessage) (string, error) {
+	job := &sendJob{
+		ctx:       ctx,
+		tenantID:  tenantID,
+		sessionID: sessionID,
+		msg:       msg,
+		done:      make(chan sendResult, 1),
+	}
+
+	key := tenantID + "/" + sessionID
+	s.mu.Lock()
+	q, ok := s.queues[key]
+	if !ok {
+		q = &sendQueue{}
+		s.queues[key] = q
+	}
+	q.jobs = append(q.jobs, job)
+	if !q.running {
+		q.running = true
+		go s.run(key, q)
+	}
+	s.mu.Unlock()
+
+	select {
+	case res := <-job.done:
+		return res.messageID, res.err
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// run delivers the jobs of a queue one by one and removes the queue once empty
+func (s *sender) run(key string, q *sendQueue) {
+	for {
+		s.mu.Lock()
+		if len(q.jobs) == 0 {
+			q.running = false
+			delete(s.queues, key)
+			s.mu.Unlock()
+			return
+		}
+		job := q.jobs[0]
+		q.jobs[0] = nil
+		q.jobs = q.jobs[1:]
+		s.mu.Unlock()
+
+		messageID, err := s.deliver(job)
+		job.done <- sendResult{messageID: messageID, err: err}
+	}
+}
+
+// deliver sends a job, waiting and retrying while the session is rate limited
+func (s *sender) deliver(job *sendJob) (string, error) {
+	for {
+		if err := job.ctx.Err(); err != nil {
+			return "", err
+		}
+
+		ctx, cancel := job.ctx, context.CancelFunc(func() {})
+		if s.timeout > 0 {
+			ctx, cancel = context.WithTimeout(job.ctx, s.timeout)
+		}
+		messageID, err := s.sessions.SendMessage(ctx, job.tenantID, job.sessionID, job.msg)
+		cancel()
+
+		var rateLimit *domain.RateLimitError
+		if !errors.As(err, &rateLimit) {
+			return messageID, err
+		}
+		if err := wait(job.ctx, rateLimit.RetryAfter); err != nil {
+			return "", err
+		}
+	}
+}
+
+// wait pauses for d or until ctx is done
+func wait(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
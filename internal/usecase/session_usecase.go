@@ -1,94 +1,727 @@
 package usecase
 
 import (
-	//"context"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
 	"time"
 
+	"whatsapp-parser/internal/config"
 	"whatsapp-parser/internal/domain"
 	"whatsapp-parser/pkg/selenium"
 
 	"github.com/google/uuid"
+	tselenium "github.com/tebeka/selenium"
 )
 
+// browser is a Chrome instance dedicated to one session. Operations on it are
+// serialized because WebDriver drives a single page.
+type browser struct {
+	mu       sync.Mutex
+	tenantID string
+	client   *selenium.WhatsAppClient
+
+	stop      chan struct{} // Closed to stop the incoming message listener
+	listening chan struct{} // Closed once the listener returned
+}
+
+// acquire locks the browser for exclusive use. It fails if Chrome did not start.
+func (b *browser) acquire() error {
+	b.mu.Lock()
+	if b.client == nil {
+		b.mu.Unlock()
+		return fmt.Errorf("browser failed to start")
+	}
+	return nil
+}
+
 type sessionUseCase struct {
-	repo           domain.SessionRepository
-	whatsappClient *selenium.WhatsAppClient
+	repo         domain.SessionRepository
+	usage        domain.UsageRepository
+	contacts     domain.ContactCheckRepository
+	suppressions domain.SuppressionRepository
+	messages     domain.MessageRepository
+	media        domain.MediaRepository
+	pacer        *pacer
+	cfg          *config.Config
+
+	mu       sync.Mutex
+	closing  bool
+	inflight sync.WaitGroup
+	browsers map[string]*browser // Keyed by session ID
+	handlers []domain.MessageHandler
 }
 
-// NewSessionUseCase creates a new session use case
-func NewSessionUseCase(repo domain.SessionRepository) (domain.SessionUseCase, error) {
-	client, err := selenium.NewWhatsAppClient()
-	if err != nil {
-		return nil, fmt.Errorf("failed to create WhatsApp client: %v", err)
+// NewSessionUseCase creates a new session use case. Browsers are started on
+// demand, one per session.
+func NewSessionUseCase(repo domain.SessionRepository, usage domain.UsageRepository, pacing domain.PacingRepository, contacts domain.ContactCheckRepository, suppressions domain.SuppressionRepository, messages domain.MessageRepository, media domain.MediaRepository, cfg *config.Config) (domain.SessionUseCase, error) {
+	if err := os.MkdirAll(cfg.BrowserDataDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create browser data directory: %v", err)
 	}
 
 	return &sessionUseCase{
-		repo:           repo,
-		whatsappClient: client,
+		repo:         repo,
+		usage:        usage,
+		contacts:     contacts,
+		suppressions: suppressions,
+		messages:     messages,
+		media:        media,
+		pacer:        newPacer(pacing, cfg),
+		cfg:          cfg,
+		browsers:     make(map[string]*browser),
 	}, nil
 }
 
-func (u *sessionUseCase) CreateSession() (*domain.Session, string, error) {
+// begin registers an in-flight operation unless shutdown has started
+func (u *sessionUseCase) begin() error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if u.closing {
+		return domain.ErrShuttingDown
+	}
+	u.inflight.Add(1)
+	return nil
+}
+
+// getSession loads a session of the tenant
+func (u *sessionUseCase) getSession(tenantID, id string) (*domain.Session, error) {
+	if !domain.ValidTenantID(tenantID) {
+		return nil, domain.ErrInvalidTenant
+	}
+	session, err := u.repo.GetByID(tenantID, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+	if session == nil {
+		return nil, domain.ErrSessionNotFound
+	}
+	return session, nil
+}
+
+// startBrowser launches Chrome for a session after checking the tenant's browser quota
+func (u *sessionUseCase) startBrowser(tenantID, sessionID string) (*browser, error) {
+	u.mu.Lock()
+	if b, ok := u.browsers[sessionID]; ok {
+		u.mu.Unlock()
+		return b, nil
+	}
+
+	limit := u.cfg.Tenant(tenantID).MaxBrowsers
+	running := 0
+	for _, b := range u.browsers {
+		if b.tenantID == tenantID {
+			running++
+		}
+	}
+	if limit > 0 && running >= limit {
+		u.mu.Unlock()
+		return nil, fmt.Errorf("%w: %d of %d browsers running", domain.ErrQuotaExceeded, running, limit)
+	}
+
+	// Reserve the slot while Chrome starts so concurrent requests see it
+	b := &browser{
+		tenantID:  tenantID,
+		stop:      make(chan struct{}),
+		listening: make(chan struct{}),
+	}
+	b.mu.Lock()
+	u.browsers[sessionID] = b
+	u.mu.Unlock()
+	defer b.mu.Unlock()
+
+	typing := selenium.TypingOptions{
+		CharDelay:          time.Duration(u.cfg.Typing.CharDelay),
+		CharDelayJitter:    time.Duration(u.cfg.Typing.CharDelayJitter),
+		PasteThreshold:     u.cfg.Typing.PasteThreshold,
+		ShowTyping:         u.cfg.Typing.ShowTyping,
+		MaxTypingIndicator: time.Duration(u.cfg.Typing.MaxTypingIndicator),
+	}
+	client, err := selenium.NewWhatsAppClient(filepath.Join(u.cfg.BrowserDataDir, tenantID, sessionID), typing)
+	if err != nil {
+		u.mu.Lock()
+		delete(u.browsers, sessionID)
+		u.mu.Unlock()
+		return nil, fmt.Errorf("failed to create WhatsApp client: %v", err)
+	}
+	b.client = client
+	go u.listen(sessionID, b)
+
+	return b, nil
+}
+
+// browserFor returns the running browser of a session, starting it and
+// restoring the stored login if needed
+func (u *sessionUseCase) browserFor(ctx context.Context, session *domain.Session) (*browser, error) {
+	u.mu.Lock()
+	b, ok := u.browsers[session.ID]
+	u.mu.Unlock()
+	if ok {
+		return b, nil
+	}
+
+	b, err := u.startBrowser(session.TenantID, session.ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(session.Cookies) == 0 && len(session.Storage) == 0 {
+		return b, nil
+	}
+
+	sessionData, err := sessionDataFromDomain(session)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode session data: %v", err)
+	}
+
+	if err := b.acquire(); err != nil {
+		return nil, err
+	}
+	defer b.mu.Unlock()
+	if err := b.client.RestoreSession(ctx, sessionData); err != nil {
+		return nil, fmt.Errorf("failed to restore session: %w", err)
+	}
+	return b, nil
+}
+
+// closeBrowser stops the browser of a session if one is running
+func (u *sessionUseCase) closeBrowser(sessionID string) {
+	u.mu.Lock()
+	b, ok := u.browsers[sessionID]
+	delete(u.browsers, sessionID)
+	u.mu.Unlock()
+	if !ok || b.client == nil {
+		return
+	}
+
+	close(b.stop)
+	<-b.listening
+	if err := b.client.Close(); err != nil {
+		log.Printf("Warning: failed to close browser for session %s: %v", sessionID, err)
+	}
+}
+
+func (u *sessionUseCase) CreateSession(ctx context.Context, tenantID string) (*domain.Session, string, error) {
+	if err := u.begin(); err != nil {
+		return nil, "", err
+	}
+	defer u.inflight.Done()
+
+	if !domain.ValidTenantID(tenantID) {
+		return nil, "", domain.ErrInvalidTenant
+	}
+
 	// Create new session
 	session := &domain.Session{
 		ID:        uuid.New().String(),
+		TenantID:  tenantID,
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 	}
 
+	b, err := u.startBrowser(tenantID, session.ID)
+	if err != nil {
+		return nil, "", err
+	}
+
 	// Get QR code
-	qrCode, err := u.whatsappClient.GetQRCode(session.ID)
+	if err := b.acquire(); err != nil {
+		return nil, "", err
+	}
+	qrCode, err := b.client.GetQRCode(ctx, session.ID)
+	b.mu.Unlock()
 	if err != nil {
-		return nil, "", fmt.Errorf("failed to get QR code: %v", err)
+		u.closeBrowser(session.ID)
+		return nil, "", fmt.Errorf("failed to get QR code: %w", err)
 	}
 
 	// Save session
 	if err := u.repo.Save(session); err != nil {
+		u.closeBrowser(session.ID)
 		return nil, "", fmt.Errorf("failed to save session: %v", err)
 	}
 
 	return session, qrCode, nil
 }
 
-func (u *sessionUseCase) RestoreSession(id string) error {
+func (u *sessionUseCase) RestoreSession(ctx context.Context, tenantID, id string) error {
+	if err := u.begin(); err != nil {
+		return err
+	}
+	defer u.inflight.Done()
+
 	// Get session from repository
-	session, err := u.repo.GetByID(id)
+	session, err := u.getSession(tenantID, id)
 	if err != nil {
-		return fmt.Errorf("failed to get session: %v", err)
+		return err
 	}
-	if session == nil {
-		return fmt.Errorf("session not found")
+
+	b, err := u.startBrowser(session.TenantID, session.ID)
+	if err != nil {
+		return err
 	}
 
-	// Convert session data to bytes
-	sessionData, err := u.whatsappClient.GetSessionData()
+	// Convert stored session data to bytes
+	sessionData, err := sessionDataFromDomain(session)
 	if err != nil {
-		return fmt.Errorf("failed to get session data: %v", err)
+		return fmt.Errorf("failed to encode session data: %v", err)
 	}
 
 	// Restore session in WhatsApp client
-	if err := u.whatsappClient.RestoreSession(sessionData); err != nil {
-		return fmt.Errorf("failed to restore session: %v", err)
+	if err := b.acquire(); err != nil {
+		return err
+	}
+	defer b.mu.Unlock()
+	if err := b.client.RestoreSession(ctx, sessionData); err != nil {
+		return fmt.Errorf("failed to restore session: %w", err)
 	}
 
 	return nil
 }
 
-func (u *sessionUseCase) SendMessage(sessionID string, phoneNumber string, message string) error {
+func (u *sessionUseCase) SendMessage(ctx context.Context, tenantID, sessionID string, msg domain.OutgoingMessage) (string, error) {
+	if err := u.begin(); err != nil {
+		return "", err
+	}
+	defer u.inflight.Done()
+
 	// Verify session exists
-	session, err := u.repo.GetByID(sessionID)
+	session, err := u.getSession(tenantID, sessionID)
 	if err != nil {
-		return fmt.Errorf("failed to get session: %v", err)
+		return "", err
 	}
-	if session == nil {
-		return fmt.Errorf("session not found")
+
+	if err := u.checkRecipient(ctx, tenantID, session, msg.Target); err != nil {
+		return "", err
+	}
+	day := time.Now().Format("2006-01-02")
+	if err := u.reserveQuota(tenantID, day, 1); err != nil {
+		return "", err
+	}
+	// The reserved send is given back unless the message goes out
+	sent := 0
+	defer func() { u.releaseQuota(tenantID, day, 1-sent) }()
+
+	// Apply the session's anti-ban pacing; the slot is kept even if the send fails
+	if err := u.pacer.reserve(session, msg.Target.Key(), time.Now()); err != nil {
+		return "", err
+	}
+
+	b, err := u.browserFor(ctx, session)
+	if err != nil {
+		return "", err
 	}
 
 	// Send message
-	if err := u.whatsappClient.SendMessage(phoneNumber, message); err != nil {
-		return fmt.Errorf("failed to send message: %v", err)
+	if err := b.acquire(); err != nil {
+		return "", err
+	}
+	messageID, err := b.client.SendMessage(ctx, clientTarget(msg.Target), clientMessage(msg))
+	b.mu.Unlock()
+	if err != nil {
+		return "", fmt.Errorf("failed to send message: %w", translateClientError(err))
+	}
+	sent = 1
+
+	return messageID, nil
+}
+
+// checkRecipient refuses numbers that opted out; they are never contacted again.
+// A title may name a 1:1 chat, so it is looked up in the session's chat list
+// first; invite links always lead to groups.
+func (u *sessionUseCase) checkRecipient(ctx context.Context, tenantID string, session *domain.Session, target domain.ChatTarget) error {
+	number := target.Phone()
+	if target.Title != "" {
+		jid, err := u.chatJID(ctx, session, target)
+		if err != nil {
+			return err
+		}
+		number = domain.PhoneFromJID(jid)
+	}
+	if number == "" {
+		return nil
+	}
+	suppression, err := u.suppressions.Get(tenantID, number)
+	if err != nil {
+		return fmt.Errorf("failed to check suppression list: %v", err)
+	}
+	if suppression != nil {
+		return fmt.Errorf("%w: %s (%s)", domain.ErrRecipientSuppressed, number, suppression.Reason)
+	}
+	return nil
+}
+
+// chatJID finds the JID of the chat target points to in the session's browser
+func (u *sessionUseCase) chatJID(ctx context.Context, session *domain.Session, target domain.ChatTarget) (string, error) {
+	b, err := u.browserFor(ctx, session)
+	if err != nil {
+		return "", err
+	}
+	if err := b.acquire(); err != nil {
+		return "", err
+	}
+	jid, err := b.client.ChatJID(ctx, clientTarget(target))
+	b.mu.Unlock()
+	if err != nil {
+		return "", fmt.Errorf("failed to find chat: %w", translateClientError(err))
+	}
+	return jid, nil
+}
+
+// reserveQuota takes n sends of the tenant's daily send quota before they are
+// made, so concurrent sends cannot together go over it. Sends that do not go
+// out are given back with releaseQuota.
+func (u *sessionUseCase) reserveQuota(tenantID, day string, n int) error {
+	_, err := u.usage.ReserveSends(tenantID, day, n, u.cfg.Tenant(tenantID).DailySends)
+	if errors.Is(err, domain.ErrQuotaExceeded) {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("failed to reserve sends: %v", err)
+	}
+	return nil
+}
+
+// releaseQuota gives back n reserved sends that did not go out
+func (u *sessionUseCase) releaseQuota(tenantID, day string, n int) {
+	if n <= 0 {
+		return
+	}
+	if err := u.usage.ReleaseSends(tenantID, day, n); err != nil {
+		log.Printf("Warning: failed to release sends for tenant %s: %v", tenantID, err)
+	}
+}
+
+// Shutdown stops accepting new work, waits for in-flight operations until ctx
+// expires, saves the browser state of every running session and closes all browsers
+func (u *sessionUseCase) Shutdown(ctx context.Context) error {
+	u.mu.Lock()
+	u.closing = true
+	u.mu.Unlock()
+
+	drained := make(chan struct{})
+	go func() {
+		u.inflight.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+		log.Println("All in-flight operations finished")
+	case <-ctx.Done():
+		log.Printf("Warning: shutdown deadline reached with operations still running: %v", ctx.Err())
+	}
+
+	u.mu.Lock()
+	browsers := u.browsers
+	u.browsers = make(map[string]*browser)
+	u.mu.Unlock()
+
+	var failed int
+	for sessionID, b := range browsers {
+		if b.client == nil {
+			continue
+		}
+		close(b.stop)
+		<-b.listening
+		if err := u.persistSession(b, sessionID); err != nil {
+			log.Printf("Warning: failed to persist session %s: %v", sessionID, err)
+		}
+		if err := b.client.Close(); err != nil {
+			log.Printf("Warning: failed to close browser for session %s: %v", sessionID, err)
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("failed to close %d of %d browsers", failed, len(browsers))
+	}
+	return nil
+}
+
+func (u *sessionUseCase) Subscribe(handler domain.MessageHandler) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.handlers = append(u.handlers, handler)
+}
+
+// incomingLookback is how far back a newly started browser reports incoming
+// messages, so messages received while it was not running are not missed
+const incomingLookback = 24 * time.Hour
+
+// Running tells whether the browser of a session is started
+func (u *sessionUseCase) Running(sessionID string) bool {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	_, ok := u.browsers[sessionID]
+	return ok
+}
+
+// listen polls a browser for new messages, archives them and passes incoming
+// ones to the subscribed handlers until the browser is closed
+func (u *sessionUseCase) listen(sessionID string, b *browser) {
+	defer close(b.listening)
+
+	interval := time.Duration(u.cfg.IncomingPoll)
+	if interval <= 0 {
+		<-b.stop
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	since := time.Now().Add(-incomingLookback)
+	seen := make(map[string]time.Time) // Messages in the overlap window of the next poll
+	for {
+		select {
+		case <-b.stop:
+			return
+		case <-ticker.C:
+		}
+
+		// Skip the tick while the browser is busy, e.g. typing a message
+		if !b.mu.TryLock() {
+			continue
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), interval)
+		// Messages share second-resolution timestamps, so the last second is read again
+		messages, err := b.client.RecentMessages(ctx, since.Add(-time.Second))
+		cancel()
+		b.mu.Unlock()
+		if err != nil {
+			log.Printf("Warning: failed to poll messages of session %s: %v", sessionID, err)
+			continue
+		}
+
+		u.mu.Lock()
+		handlers := u.handlers
+		u.mu.Unlock()
+
+		var fresh []domain.Message
+		for _, m := range messages {
+			if _, ok := seen[m.ID]; ok {
+				continue
+			}
+			seen[m.ID] = m.Time
+			if m.Time.After(since) {
+				since = m.Time
+			}
+			msg := domainMessage(m)
+			fresh = append(fresh, msg)
+			if m.FromMe {
+				continue
+			}
+			for _, handler := range handlers {
+				handler(b.tenantID, sessionID, msg)
+			}
+		}
+		if _, err := u.messages.SaveMessages(b.tenantID, sessionID, fresh); err != nil {
+			log.Printf("Warning: failed to archive messages of session %s: %v", sessionID, err)
+		}
+		for id, t := range seen {
+			if t.Before(since.Add(-time.Second)) {
+				delete(seen, id)
+			}
+		}
+	}
+}
+
+// domainMessage converts a message read by the browser client
+func domainMessage(m selenium.ChatMessage) domain.Message {
+	msg := domain.Message{
+		ID:         m.ID,
+		ChatJID:    m.ChatJID,
+		SenderJID:  m.Sender,
+		SenderName: m.SenderName,
+		FromMe:     m.FromMe,
+		Type:       m.Type,
+		Text:       m.Text,
+		Thumbnail:  m.Thumbnail,
+		Timestamp:  m.Time,
+	}
+	if l := m.Location; l != nil {
+		msg.Location = &domain.Location{Latitude: l.Lat, Longitude: l.Lng, Name: l.Name, Address: l.Address, URL: l.URL}
+	}
+	for _, c := range m.Contacts {
+		msg.Contacts = append(msg.Contacts, domain.SharedContact{Name: c.Name, PhoneNumber: vcardPhone(c.VCard), VCard: c.VCard})
+	}
+	if p := m.Poll; p != nil {
+		poll := &domain.Poll{Question: p.Question, MultipleAnswers: p.Selectable != 1, Voters: p.Voters}
+		for _, o := range p.Options {
+			poll.Options = append(poll.Options, domain.PollOption{Name: o.Name, Votes: o.Votes})
+		}
+		msg.Poll = poll
 	}
+	if msg.Text == "" {
+		msg.Text = msg.Summary()
+	}
+	return msg
+}
+
+// clientMessage converts a message to send for the browser client
+func clientMessage(msg domain.OutgoingMessage) selenium.Message {
+	out := selenium.Message{
+		Text:     msg.Text,
+		ReplyTo:  msg.ReplyTo,
+		Mentions: msg.Mentions,
+	}
+	if l := msg.Location; l != nil {
+		out.Location = &selenium.Location{Lat: l.Latitude, Lng: l.Longitude, Name: l.Name, Address: l.Address, URL: l.URL}
+	}
+	for _, c := range msg.Contacts {
+		out.Contacts = append(out.Contacts, selenium.ContactCard{Name: c.Name, VCard: c.VCard})
+	}
+	if p := msg.Poll; p != nil {
+		poll := &selenium.Poll{Question: p.Question, Selectable: 1}
+		if p.MultipleAnswers {
+			poll.Selectable = 0
+		}
+		for _, o := range p.Options {
+			poll.Options = append(poll.Options, selenium.PollOption{Name: o.Name})
+		}
+		out.Poll = poll
+	}
+	return out
+}
+
+// waidPattern finds the WhatsApp number of a vCard's TEL property
+var waidPattern = regexp.MustCompile(`waid=(\d+)`)
+
+// vcardPhone returns the E.164 number WhatsApp linked a shared contact to, or ""
+func vcardPhone(card string) string {
+	if match := waidPattern.FindStringSubmatch(card); match != nil {
+		return "+" + match[1]
+	}
+	return ""
+}
+
+// persistSession copies cookies and localStorage from the browser into the stored session
+func (u *sessionUseCase) persistSession(b *browser, id string) error {
+	session, err := u.getSession(b.tenantID, id)
+	if err != nil {
+		return err
+	}
+
+	// Browser state is read even if the shutdown deadline has passed
+	data, err := b.client.GetSessionData(context.Background())
+	if err != nil {
+		return fmt.Errorf("failed to get session data: %v", err)
+	}
+	if err := applySessionData(session, data); err != nil {
+		return fmt.Errorf("failed to decode session data: %v", err)
+	}
+
+	session.UpdatedAt = time.Now()
+	if err := u.repo.Save(session); err != nil {
+		return fmt.Errorf("failed to save session: %v", err)
+	}
+	return nil
+}
+
+// clientTarget converts a chat target for the browser client
+func clientTarget(target domain.ChatTarget) selenium.ChatTarget {
+	return selenium.ChatTarget{
+		Phone:      target.PhoneNumber,
+		JID:        target.JID,
+		InviteCode: target.InviteCode,
+		Title:      target.Title,
+	}
+}
+
+// clientError keeps the message of a browser client error while also matching a domain error
+type clientError struct {
+	kind error
+	err  error
+}
+
+func (e *clientError) Error() string   { return e.err.Error() }
+func (e *clientError) Unwrap() []error { return []error{e.kind, e.err} }
+
+// translateClientError makes browser client errors match the corresponding domain error
+func translateClientError(err error) error {
+	switch {
+	case errors.Is(err, selenium.ErrChatNotFound):
+		return &clientError{kind: domain.ErrChatNotFound, err: err}
+	case errors.Is(err, selenium.ErrAmbiguousChat):
+		return &clientError{kind: domain.ErrAmbiguousChat, err: err}
+	case errors.Is(err, selenium.ErrNotMember):
+		return &clientError{kind: domain.ErrNotChatMember, err: err}
+	case errors.Is(err, selenium.ErrMessageNotFound):
+		return &clientError{kind: domain.ErrMessageNotFound, err: err}
+	case errors.Is(err, selenium.ErrNoMedia):
+		return &clientError{kind: domain.ErrNoMedia, err: err}
+	case errors.Is(err, selenium.ErrActionUnavailable):
+		return &clientError{kind: domain.ErrChatActionUnavailable, err: err}
+	case errors.Is(err, selenium.ErrNotAllowed):
+		return &clientError{kind: domain.ErrMessageActionNotAllowed, err: err}
+	}
+	return err
+}
 
+// browserSessionData mirrors the JSON produced by WhatsAppClient.GetSessionData
+type browserSessionData struct {
+	Cookies      []tselenium.Cookie `json:"cookies"`
+	LocalStorage [][]string         `json:"localStorage"`
+}
+
+// applySessionData stores browser cookies and localStorage in the session
+func applySessionData(session *domain.Session, data []byte) error {
+	var browser browserSessionData
+	if err := json.Unmarshal(data, &browser); err != nil {
+		return err
+	}
+
+	session.Cookies = make([]domain.Cookie, 0, len(browser.Cookies))
+	for _, c := range browser.Cookies {
+		cookie := domain.Cookie{
+			Name:   c.Name,
+			Value:  c.Value,
+			Domain: c.Domain,
+			Path:   c.Path,
+			Secure: c.Secure,
+		}
+		if c.Expiry > 0 {
+			cookie.Expires = time.Unix(int64(c.Expiry), 0)
+		}
+		session.Cookies = append(session.Cookies, cookie)
+	}
+
+	session.Storage = make([]domain.Storage, 0, len(browser.LocalStorage))
+	for _, pair := range browser.LocalStorage {
+		if len(pair) != 2 {
+			continue
+		}
+		session.Storage = append(session.Storage, domain.Storage{Key: pair[0], Value: pair[1]})
+	}
 	return nil
 }
+
+// sessionDataFromDomain encodes a stored session for WhatsAppClient.RestoreSession
+func sessionDataFromDomain(session *domain.Session) ([]byte, error) {
+	browser := browserSessionData{
+		Cookies:      make([]tselenium.Cookie, 0, len(session.Cookies)),
+		LocalStorage: make([][]string, 0, len(session.Storage)),
+	}
+	for _, c := range session.Cookies {
+		cookie := tselenium.Cookie{
+			Name:   c.Name,
+			Value:  c.Value,
+			Domain: c.Domain,
+			Path:   c.Path,
+			Secure: c.Secure,
+		}
+		if !c.Expires.IsZero() {
+			cookie.Expiry = uint(c.Expires.Unix())
+		}
+		browser.Cookies = append(browser.Cookies, cookie)
+	}
+	for _, item := range session.Storage {
+		browser.LocalStorage = append(browser.LocalStorage, []string{item.Key, item.Value})
+	}
+	return json.Marshal(browser)
+}
@@ -0,0 +1,122 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"unicode"
+
+	"whatsapp-parser/internal/config"
+	"whatsapp-parser/internal/domain"
+)
+
+type suppressionUseCase struct {
+	repo     domain.SuppressionRepository
+	keywords map[string]bool // Upper-cased opt-out keywords
+}
+
+// NewSuppressionUseCase creates a suppression list use case. It subscribes to
+// incoming messages of all sessions and suppresses senders of an opt-out keyword.
+func NewSuppressionUseCase(repo domain.SuppressionRepository, sessions domain.SessionUseCase, cfg *config.Config) domain.SuppressionUseCase {
+	u := &suppressionUseCase{
+		repo:     repo,
+		keywords: make(map[string]bool, len(cfg.OptOutKeywords)),
+	}
+	for _, keyword := range cfg.OptOutKeywords {
+		if keyword = normalizeKeyword(keyword); keyword != "" {
+			u.keywords[keyword] = true
+		}
+	}
+	sessions.Subscribe(u.handleMessage)
+	return u
+}
+
+// normalizeKeyword upper-cases text and trims spaces and punctuation, so "Stop!" matches STOP
+func normalizeKeyword(text string) string {
+	return strings.ToUpper(strings.TrimFunc(text, func(r rune) bool {
+		return unicode.IsSpace(r) || unicode.IsPunct(r)
+	}))
+}
+
+// handleMessage suppresses the sender of a direct message that consists of an
+// opt-out keyword. Keywords inside longer messages and group messages are ignored.
+func (u *suppressionUseCase) handleMessage(tenantID, sessionID string, msg domain.Message) {
+	number := domain.PhoneFromJID(msg.ChatJID)
+	if number == "" || !u.keywords[normalizeKeyword(msg.Text)] {
+		return
+	}
+
+	existing, err := u.repo.Get(tenantID, number)
+	if err != nil {
+		log.Printf("Warning: failed to check suppression of %s: %v", number, err)
+		return
+	}
+	if existing != nil {
+		return
+	}
+
+	suppression := &domain.Suppression{
+		PhoneNumber: number,
+		Reason:      domain.SuppressionOptOut,
+		Note:        msg.Text,
+		SessionID:   sessionID,
+		MessageID:   msg.ID,
+		CreatedAt:   time.Now(),
+	}
+	if err := u.repo.Save(tenantID, suppression); err != nil {
+		log.Printf("Warning: failed to suppress %s: %v", number, err)
+		return
+	}
+	log.Printf("Suppressed %s of tenant %s after opt-out message %s", number, tenantID, msg.ID)
+}
+
+func (u *suppressionUseCase) AddSuppressions(ctx context.Context, tenantID string, phoneNumbers []string, note string) ([]*domain.Suppression, error) {
+	if !domain.ValidTenantID(tenantID) {
+		return nil, domain.ErrInvalidTenant
+	}
+
+	suppressions := make([]*domain.Suppression, 0, len(phoneNumbers))
+	for _, number := range phoneNumbers {
+		existing, err := u.repo.Get(tenantID, number)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get suppression: %w", err)
+		}
+		if existing != nil {
+			suppressions = append(suppressions, existing)
+			continue
+		}
+
+		suppression := &domain.Suppression{
+			PhoneNumber: number,
+			Reason:      domain.SuppressionManual,
+			Note:        note,
+			CreatedAt:   time.Now(),
+		}
+		if err := u.repo.Save(tenantID, suppression); err != nil {
+			return nil, fmt.Errorf("failed to save suppression: %w", err)
+		}
+		suppressions = append(suppressions, suppression)
+	}
+	return suppressions, nil
+}
+
+func (u *suppressionUseCase) ListSuppressions(ctx context.Context, tenantID string) ([]*domain.Suppression, error) {
+	suppressions, err := u.repo.List(tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list suppressions: %w", err)
+	}
+	return suppressions, nil
+}
+
+func (u *suppressionUseCase) RemoveSuppression(ctx context.Context, tenantID, phoneNumber string) error {
+	removed, err := u.repo.Delete(tenantID, phoneNumber)
+	if err != nil {
+		return fmt.Errorf("failed to delete suppression: %w", err)
+	}
+	if !removed {
+		return domain.ErrSuppressionNotFound
+	}
+	return nil
+}
@@ -0,0 +1,233 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+	"text/template/parse"
+	"time"
+
+	"whatsapp-parser/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+type templateUseCase struct {
+	repo domain.TemplateRepository
+}
+
+// NewTemplateUseCase creates a new template use case
+func NewTemplateUseCase(repo domain.TemplateRepository) domain.TemplateUseCase {
+	return &templateUseCase{
+		repo: repo,
+	}
+}
+
+func (u *templateUseCase) CreateTemplate(ctx context.Context, tenantID string, t *domain.Template) error {
+	if err := validateTemplate(t); err != nil {
+		return err
+	}
+
+	t.ID = uuid.New().String()
+	t.TenantID = tenantID
+	t.CreatedAt = time.Now()
+	t.UpdatedAt = t.CreatedAt
+
+	if err := u.repo.Save(t); err != nil {
+		return fmt.Errorf("failed to save template: %w", err)
+	}
+	return nil
+}
+
+func (u *templateUseCase) UpdateTemplate(ctx context.Context, tenantID string, t *domain.Template) error {
+	existing, err := u.GetTemplate(ctx, tenantID, t.ID)
+	if err != nil {
+		return err
+	}
+	if err := validateTemplate(t); err != nil {
+		return err
+	}
+
+	t.TenantID = tenantID
+	t.CreatedAt = existing.CreatedAt
+	t.UpdatedAt = time.Now()
+
+	if err := u.repo.Save(t); err != nil {
+		return fmt.Errorf("failed to save template: %w", err)
+	}
+	return nil
+}
+
+func (u *templateUseCase) GetTemplate(ctx context.Context, tenantID, id string) (*domain.Template, error) {
+	t, err := u.repo.GetByID(tenantID, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get template: %w", err)
+	}
+	if t == nil {
+		return nil, domain.ErrTemplateNotFound
+	}
+	return t, nil
+}
+
+func (u *templateUseCase) ListTemplates(ctx context.Context, tenantID string) ([]*domain.Template, error) {
+	templates, err := u.repo.List(tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list templates: %w", err)
+	}
+	return templates, nil
+}
+
+func (u *templateUseCase) DeleteTemplate(ctx context.Context, tenantID, id string) error {
+	if _, err := u.GetTemplate(ctx, tenantID, id); err != nil {
+		return err
+	}
+	if err := u.repo.Delete(tenantID, id); err != nil {
+		return fmt.Errorf("failed to delete template: %w", err)
+	}
+	return nil
+}
+
+func (u *templateUseCase) RenderTemplate(ctx context.Context, tenantID, id, locale string, variables map[string]interface{}) (string, error) {
+	t, err := u.GetTemplate(ctx, tenantID, id)
+	if err != nil {
+		return "", err
+	}
+
+	body, ok := selectVariant(t, locale)
+	if !ok {
+		return "", fmt.Errorf("%w: template %s has no variant for locale %q", domain.ErrInvalidTemplate, t.ID, locale)
+	}
+	return renderBody(body, variables)
+}
+
+// validateTemplate checks that every variant parses
+func validateTemplate(t *domain.Template) error {
+	if strings.TrimSpace(t.Name) == "" {
+		return fmt.Errorf("%w: name is required", domain.ErrInvalidTemplate)
+	}
+	if len(t.Variants) == 0 {
+		return fmt.Errorf("%w: at least one variant is required", domain.ErrInvalidTemplate)
+	}
+	if t.DefaultLocale == "" {
+		if len(t.Variants) > 1 {
+			return fmt.Errorf("%w: default_locale is required with several variants", domain.ErrInvalidTemplate)
+		}
+		for locale := range t.Variants {
+			t.DefaultLocale = locale
+		}
+	}
+	if _, ok := t.Variants[t.DefaultLocale]; !ok {
+		return fmt.Errorf("%w: no variant for default locale %q", domain.ErrInvalidTemplate, t.DefaultLocale)
+	}
+
+	for locale, body := range t.Variants {
+		if strings.TrimSpace(body) == "" {
+			return fmt.Errorf("%w: variant %q is empty", domain.ErrInvalidTemplate, locale)
+		}
+		if _, err := template.New(locale).Option("missingkey=error").Parse(body); err != nil {
+			return fmt.Errorf("%w: variant %q: %v", domain.ErrInvalidTemplate, locale, err)
+		}
+	}
+	return nil
+}
+
+// selectVariant picks the exact locale, then its language ("pt" for "pt-BR"),
+// then the default locale
+func selectVariant(t *domain.Template, locale string) (string, bool) {
+	if locale != "" {
+		if body, ok := t.Variants[locale]; ok {
+			return body, true
+		}
+		language := strings.ToLower(strings.SplitN(strings.ReplaceAll(locale, "_", "-"), "-", 2)[0])
+		for l, body := range t.Variants {
+			if strings.EqualFold(l, locale) || strings.EqualFold(l, language) {
+				return body, true
+			}
+		}
+	}
+	body, ok := t.Variants[t.DefaultLocale]
+	return body, ok
+}
+
+// renderBody executes a template body after checking that every referenced
+// variable is present, so a message is never sent half filled
+func renderBody(body string, variables map[string]interface{}) (string, error) {
+	tmpl, err := template.New("message").Option("missingkey=error").Parse(body)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", domain.ErrInvalidTemplate, err)
+	}
+
+	var missing []string
+	for _, name := range templateVariables(tmpl.Tree) {
+		if _, ok := variables[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return "", fmt.Errorf("%w: %s", domain.ErrMissingVariables, strings.Join(missing, ", "))
+	}
+
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, variables); err != nil {
+		return "", fmt.Errorf("%w: %v", domain.ErrMissingVariables, err)
+	}
+	return sb.String(), nil
+}
+
+// templateVariables returns the top-level fields ({{.name}}) a template
+// references. Bodies of range and with blocks are skipped since dot changes there.
+func templateVariables(tree *parse.Tree) []string {
+	seen := make(map[string]bool)
+	var walk func(node parse.Node)
+	walkPipe := func(pipe *parse.PipeNode) {
+		if pipe == nil {
+			return
+		}
+		for _, cmd := range pipe.Cmds {
+			for _, arg := range cmd.Args {
+				walk(arg)
+			}
+		}
+	}
+	walk = func(node parse.Node) {
+		switch n := node.(type) {
+		case *parse.ListNode:
+			if n == nil {
+				return
+			}
+			for _, child := range n.Nodes {
+				walk(child)
+			}
+		case *parse.ActionNode:
+			walkPipe(n.Pipe)
+		case *parse.IfNode:
+			walkPipe(n.Pipe)
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.RangeNode:
+			walkPipe(n.Pipe)
+			walk(n.ElseList)
+		case *parse.WithNode:
+			walkPipe(n.Pipe)
+			walk(n.ElseList)
+		case *parse.PipeNode:
+			walkPipe(n)
+		case *parse.FieldNode:
+			if len(n.Ident) > 0 {
+				seen[n.Ident[0]] = true
+			}
+		}
+	}
+	if tree != nil {
+		walk(tree.Root)
+	}
+
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,177 @@
+// Package markup renders structured text to WhatsApp formatting markup
+package markup
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Span is a run of text with uniform styling
+type Span struct {
+	Text          string `json:"text"`
+	Bold          bool   `json:"bold,omitempty"`
+	Italic        bool   `json:"italic,omitempty"`
+	Strikethrough bool   `json:"strikethrough,omitempty"`
+	Monospace     bool   `json:"monospace,omitempty"`
+}
+
+// Block is a paragraph, a list or a quote. A block with Items is a list;
+// otherwise Spans form a paragraph.
+type Block struct {
+	Spans   []Span   `json:"spans,omitempty"`
+	Items   [][]Span `json:"items,omitempty"`
+	Ordered bool     `json:"ordered,omitempty"`
+	Quote   bool     `json:"quote,omitempty"`
+}
+
+// Document is a formatted message
+type Document struct {
+	Blocks []Block `json:"blocks"`
+}
+
+// Render returns the document as WhatsApp markup. Blocks are separated by a blank line.
+func (d Document) Render() (string, error) {
+	if len(d.Blocks) == 0 {
+		return "", errors.New("formatted message has no blocks")
+	}
+
+	blocks := make([]string, 0, len(d.Blocks))
+	for i, block := range d.Blocks {
+		text, err := block.render()
+		if err != nil {
+			return "", fmt.Errorf("block %d: %v", i, err)
+		}
+		blocks = append(blocks, text)
+	}
+	return strings.Join(blocks, "\n\n"), nil
+}
+
+func (b Block) render() (string, error) {
+	var lines []string
+	switch {
+	case len(b.Items) > 0 && len(b.Spans) > 0:
+		return "", errors.New("a block is either a paragraph or a list")
+	case len(b.Items) > 0:
+		for i, item := range b.Items {
+			marker := "- "
+			if b.Ordered {
+				marker = strconv.Itoa(i+1) + ". "
+			}
+			lines = append(lines, marker+renderSpans(item))
+		}
+	case len(b.Spans) > 0:
+		lines = strings.Split(renderSpans(b.Spans), "\n")
+	default:
+		return "", errors.New("empty block")
+	}
+
+	if b.Quote {
+		for i := range lines {
+			lines[i] = "> " + lines[i]
+		}
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+const (
+	// joiner is put before marker characters of the text itself: a marker
+	// glued to the preceding character does not open or close a style
+	joiner = "\u200d"
+	// boundary separates a styled run from text touching it, since a closing
+	// marker followed by a letter is shown literally
+	boundary = "\u200b"
+)
+
+// markers are the characters WhatsApp reads as styles
+const markers = "*_~`"
+
+func renderSpans(spans []Span) string {
+	var sb strings.Builder
+	var prev *Span
+	for i := range spans {
+		span := &spans[i]
+		if span.Text == "" {
+			continue
+		}
+		if prev != nil && (prev.styled() || span.styled()) && prev.style() != span.style() && touch(prev.Text, span.Text) {
+			sb.WriteString(boundary)
+		}
+		sb.WriteString(span.render())
+		prev = span
+	}
+	return sb.String()
+}
+
+// touch reports whether no whitespace separates left and right
+func touch(left, right string) bool {
+	last, _ := utf8.DecodeLastRuneInString(left)
+	first, _ := utf8.DecodeRuneInString(right)
+	return !unicode.IsSpace(last) && !unicode.IsSpace(first)
+}
+
+// styled reports whether the span is wrapped in any marker
+func (s Span) styled() bool {
+	return s.Bold || s.Italic || s.Strikethrough || s.Monospace
+}
+
+// style is the span without its text, for comparing the styles of runs
+func (s Span) style() Span {
+	s.Text = ""
+	return s
+}
+
+// render wraps the span in style markers line by line, since WhatsApp does not
+// apply styles across line breaks
+func (s Span) render() string {
+	lines := strings.Split(s.Text, "\n")
+	for i, line := range lines {
+		lines[i] = s.renderLine(line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// renderLine styles a single line. WhatsApp only applies a marker that touches
+// non-space characters, so surrounding whitespace is kept outside.
+func (s Span) renderLine(text string) string {
+	core := strings.TrimFunc(text, unicode.IsSpace)
+	if core == "" {
+		return text
+	}
+	start := strings.Index(text, core)
+	lead, trail := text[:start], text[start+len(core):]
+
+	if s.Monospace {
+		// Other styles are not applied inside monospace, only backticks end it
+		return lead + "```" + escape(core, "`") + "```" + trail
+	}
+	core = escape(core, markers)
+	if s.Strikethrough {
+		core = "~" + core + "~"
+	}
+	if s.Italic {
+		core = "_" + core + "_"
+	}
+	if s.Bold {
+		core = "*" + core + "*"
+	}
+	return lead + core + trail
+}
+
+// escape keeps the given marker characters of text from being read as styles
+func escape(text, chars string) string {
+	if !strings.ContainsAny(text, chars) {
+		return text
+	}
+	var sb strings.Builder
+	for _, r := range text {
+		if strings.ContainsRune(chars, r) {
+			sb.WriteString(joiner)
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
@@ -0,0 +1,113 @@
+package markup
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	// Shown as ‹j› for the joiner and ‹b› for the boundary in the want strings
+	visible := strings.NewReplacer(joiner, "‹j›", boundary, "‹b›")
+
+	tests := []struct {
+		name string
+		doc  Document
+		want string
+	}{
+		{
+			name: "styles",
+			doc: Document{Blocks: []Block{{Spans: []Span{
+				{Text: "Incident", Bold: true},
+				{Text: " on "},
+				{Text: "api-1", Monospace: true},
+			}}}},
+			want: "*Incident* on ```api-1```",
+		},
+		{
+			name: "nested styles",
+			doc:  Document{Blocks: []Block{{Spans: []Span{{Text: "all", Bold: true, Italic: true, Strikethrough: true}}}}},
+			want: "*_~all~_*",
+		},
+		{
+			name: "whitespace stays outside markers",
+			doc:  Document{Blocks: []Block{{Spans: []Span{{Text: "a "}, {Text: " bold ", Bold: true}, {Text: "b"}}}}},
+			want: "a  *bold* b",
+		},
+		{
+			name: "styles do not cross lines",
+			doc:  Document{Blocks: []Block{{Spans: []Span{{Text: "one\ntwo", Italic: true}}}}},
+			want: "_one_\n_two_",
+		},
+		{
+			name: "markers in plain text",
+			doc:  Document{Blocks: []Block{{Spans: []Span{{Text: "2*3*4 snake_case ~x~ `code`"}}}}},
+			want: "2‹j›*3‹j›*4 snake‹j›_case ‹j›~x‹j›~ ‹j›`code‹j›`",
+		},
+		{
+			name: "markers in styled text",
+			doc:  Document{Blocks: []Block{{Spans: []Span{{Text: "*a*", Bold: true}}}}},
+			want: "*‹j›*a‹j›**",
+		},
+		{
+			name: "monospace only escapes backticks",
+			doc:  Document{Blocks: []Block{{Spans: []Span{{Text: "a*b ```c```", Monospace: true}}}}},
+			want: "```a*b ‹j›`‹j›`‹j›`c‹j›`‹j›`‹j›````",
+		},
+		{
+			name: "boundary after a styled run",
+			doc:  Document{Blocks: []Block{{Spans: []Span{{Text: "foo", Bold: true}, {Text: "bar"}}}}},
+			want: "*foo*‹b›bar",
+		},
+		{
+			name: "boundary before a styled run",
+			doc:  Document{Blocks: []Block{{Spans: []Span{{Text: "re"}, {Text: "start", Italic: true}}}}},
+			want: "re‹b›_start_",
+		},
+		{
+			name: "boundary between different styles",
+			doc:  Document{Blocks: []Block{{Spans: []Span{{Text: "a", Bold: true}, {Text: "b", Italic: true}}}}},
+			want: "*a*‹b›_b_",
+		},
+		{
+			name: "no boundary between plain runs, same styles or at whitespace",
+			doc: Document{Blocks: []Block{{Spans: []Span{
+				{Text: "a"}, {Text: "b"}, {Text: ""}, {Text: " c", Bold: true}, {Text: "d", Bold: true}, {Text: " e"},
+			}}}},
+			want: "ab *c**d* e",
+		},
+		{
+			name: "lists and quotes",
+			doc: Document{Blocks: []Block{
+				{Items: [][]Span{{{Text: "first"}}, {{Text: "second", Bold: true}}}, Ordered: true},
+				{Items: [][]Span{{{Text: "item"}}}},
+				{Spans: []Span{{Text: "said\nthis"}}, Quote: true},
+			}},
+			want: "1. first\n2. *second*\n\n- item\n\n> said\n> this",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.doc.Render()
+			if err != nil {
+				t.Fatalf("Render: %v", err)
+			}
+			if got := visible.Replace(got); got != tt.want {
+				t.Errorf("Render =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderInvalid(t *testing.T) {
+	tests := map[string]Document{
+		"no blocks":  {},
+		"empty":      {Blocks: []Block{{}}},
+		"both kinds": {Blocks: []Block{{Spans: []Span{{Text: "a"}}, Items: [][]Span{{{Text: "b"}}}}}},
+	}
+	for name, doc := range tests {
+		if got, err := doc.Render(); err == nil {
+			t.Errorf("%s: Render = %q, want an error", name, got)
+		}
+	}
+}
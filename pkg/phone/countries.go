@@ -0,0 +1,80 @@
+package phone
+
+// Country describes the numbering plan of a region
+type Country struct {
+	// Region is the ISO 3166-1 alpha-2 code
+	Region string
+	// CallingCode is the international dialing code without "+"
+	CallingCode string
+	// TrunkPrefix is dialed before national numbers inside the country, e.g. "8" in Russia
+	TrunkPrefix string
+	// Lengths lists valid lengths of the national significant number
+	Lengths []int
+	// Prefixes lists allowed leading digits of the national significant number;
+	// empty allows any
+	Prefixes []string
+}
+
+// countries is keyed by region. Regions sharing a calling code are told apart by Prefixes.
+var countries = map[string]Country{
+	"RU": {Region: "RU", CallingCode: "7", TrunkPrefix: "8", Lengths: []int{10}, Prefixes: []string{"3", "4", "8", "9"}},
+	"KZ": {Region: "KZ", CallingCode: "7", TrunkPrefix: "8", Lengths: []int{10}, Prefixes: []string{"6", "7"}},
+	"BY": {Region: "BY", CallingCode: "375", TrunkPrefix: "80", Lengths: []int{9}, Prefixes: []string{"17", "2", "25", "29", "33", "44"}},
+	"UA": {Region: "UA", CallingCode: "380", TrunkPrefix: "0", Lengths: []int{9}},
+	"UZ": {Region: "UZ", CallingCode: "998", Lengths: []int{9}},
+	"KG": {Region: "KG", CallingCode: "996", TrunkPrefix: "0", Lengths: []int{9}},
+	"TJ": {Region: "TJ", CallingCode: "992", Lengths: []int{9}},
+	"AM": {Region: "AM", CallingCode: "374", TrunkPrefix: "0", Lengths: []int{8}},
+	"AZ": {Region: "AZ", CallingCode: "994", TrunkPrefix: "0", Lengths: []int{9}},
+	"GE": {Region: "GE", CallingCode: "995", TrunkPrefix: "0", Lengths: []int{9}},
+	"US": {Region: "US", CallingCode: "1", TrunkPrefix: "1", Lengths: []int{10}, Prefixes: []string{"2", "3", "4", "5", "6", "7", "8", "9"}},
+	"GB": {Region: "GB", CallingCode: "44", TrunkPrefix: "0", Lengths: []int{9, 10}},
+	"DE": {Region: "DE", CallingCode: "49", TrunkPrefix: "0", Lengths: []int{6, 7, 8, 9, 10, 11, 12, 13}},
+	"FR": {Region: "FR", CallingCode: "33", TrunkPrefix: "0", Lengths: []int{9}},
+	"ES": {Region: "ES", CallingCode: "34", Lengths: []int{9}},
+	"IT": {Region: "IT", CallingCode: "39", Lengths: []int{6, 7, 8, 9, 10, 11}},
+	"TR": {Region: "TR", CallingCode: "90", TrunkPrefix: "0", Lengths: []int{10}},
+	"AE": {Region: "AE", CallingCode: "971", TrunkPrefix: "0", Lengths: []int{8, 9}},
+	"IN": {Region: "IN", CallingCode: "91", TrunkPrefix: "0", Lengths: []int{10}, Prefixes: []string{"6", "7", "8", "9"}},
+	"BR": {Region: "BR", CallingCode: "55", TrunkPrefix: "0", Lengths: []int{10, 11}},
+}
+
+// LookupRegion returns the numbering plan of a region
+func LookupRegion(region string) (Country, bool) {
+	c, ok := countries[region]
+	return c, ok
+}
+
+// byCallingCode returns the countries using a calling code
+func byCallingCode(code string) []Country {
+	var result []Country
+	for _, c := range countries {
+		if c.CallingCode == code {
+			result = append(result, c)
+		}
+	}
+	return result
+}
+
+// matches reports whether a national significant number fits the plan
+func (c Country) matches(national string) bool {
+	lengthOK := false
+	for _, l := range c.Lengths {
+		if len(national) == l {
+			lengthOK = true
+			break
+		}
+	}
+	if !lengthOK {
+		return false
+	}
+	if len(c.Prefixes) == 0 {
+		return true
+	}
+	for _, p := range c.Prefixes {
+		if len(national) >= len(p) && national[:len(p)] == p {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,126 @@
+// Package phone normalizes phone numbers to E.164 and WhatsApp JIDs
+package phone
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidNumber is wrapped by all parse errors
+var ErrInvalidNumber = errors.New("invalid phone number")
+
+// jidSuffix is the WhatsApp Web suffix of user chat IDs
+const jidSuffix = "@c.us"
+
+// Number is a validated phone number
+type Number struct {
+	// Region is empty for calling codes missing from the built-in plans
+	Region      string
+	CallingCode string
+	National    string
+}
+
+// E164 returns the number as "+<calling code><national number>"
+func (n Number) E164() string {
+	return "+" + n.Digits()
+}
+
+// Digits returns the international number without "+"
+func (n Number) Digits() string {
+	return n.CallingCode + n.National
+}
+
+// JID returns the WhatsApp chat ID of the number, e.g. "79991234567@c.us"
+func (n Number) JID() string {
+	return n.Digits() + jidSuffix
+}
+
+func (n Number) String() string {
+	return n.E164()
+}
+
+// Parse normalizes raw input such as "+7 (999) 123-45-67", "8 999 123 45 67",
+// "0049 30 123456" or "79991234567@c.us". Numbers without an international
+// prefix are read in defaultRegion.
+func Parse(raw, defaultRegion string) (Number, error) {
+	input := strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(raw), jidSuffix))
+	if input == "" {
+		return Number{}, fmt.Errorf("%w: empty", ErrInvalidNumber)
+	}
+
+	international := strings.HasPrefix(input, "+") || strings.HasSuffix(strings.TrimSpace(raw), jidSuffix)
+	var digits strings.Builder
+	for i, r := range input {
+		switch {
+		case r >= '0' && r <= '9':
+			digits.WriteRune(r)
+		case r == '+' && i == 0:
+		case r == ' ' || r == '-' || r == '(' || r == ')' || r == '.' || r == '\u00a0':
+		default:
+			return Number{}, fmt.Errorf("%w: unexpected character %q", ErrInvalidNumber, r)
+		}
+	}
+
+	number := digits.String()
+	if !international && strings.HasPrefix(number, "00") {
+		international = true
+		number = number[2:]
+	}
+	if len(number) < 6 || len(number) > 15 {
+		return Number{}, fmt.Errorf("%w: %d digits", ErrInvalidNumber, len(number))
+	}
+
+	if international {
+		return parseInternational(number)
+	}
+
+	country, ok := LookupRegion(strings.ToUpper(defaultRegion))
+	if !ok {
+		return Number{}, fmt.Errorf("%w: no international prefix and unknown default region %q", ErrInvalidNumber, defaultRegion)
+	}
+
+	// "8 999 ..." or "0 30 ..." dialed inside the country
+	if country.TrunkPrefix != "" && strings.HasPrefix(number, country.TrunkPrefix) {
+		if national := strings.TrimPrefix(number, country.TrunkPrefix); country.matches(national) {
+			return Number{Region: country.Region, CallingCode: country.CallingCode, National: national}, nil
+		}
+	}
+	if country.matches(number) {
+		return Number{Region: country.Region, CallingCode: country.CallingCode, National: number}, nil
+	}
+	// International number typed without "+", e.g. "79991234567"
+	if n, err := parseInternational(number); err == nil {
+		return n, nil
+	}
+
+	return Number{}, fmt.Errorf("%w: %s is not a valid %s number", ErrInvalidNumber, raw, country.Region)
+}
+
+// parseInternational splits digits that start with a calling code. Codes
+// without a built-in plan are only checked against the ITU list, so the
+// national number is taken as it is.
+func parseInternational(number string) (Number, error) {
+	// Calling codes are prefix-free, so at most one length matches
+	for l := 1; l <= 3 && l < len(number); l++ {
+		code, national := number[:l], number[l:]
+		if !callingCodes[code] {
+			continue
+		}
+		candidates := byCallingCode(code)
+		if len(candidates) == 0 {
+			if len(national) < minNational {
+				return Number{}, fmt.Errorf("%w: +%s %s is too short", ErrInvalidNumber, code, national)
+			}
+			return Number{CallingCode: code, National: national}, nil
+		}
+		for _, c := range candidates {
+			if c.matches(national) {
+				return Number{Region: c.Region, CallingCode: code, National: national}, nil
+			}
+		}
+		return Number{}, fmt.Errorf("%w: +%s %s does not match the numbering plan", ErrInvalidNumber, code, national)
+	}
+
+	return Number{}, fmt.Errorf("%w: unknown calling code of +%s", ErrInvalidNumber, number)
+}
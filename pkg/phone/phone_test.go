@@ -0,0 +1,144 @@
+package phone
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		raw, region string
+		e164        string
+		country     string
+	}{
+		{"+7 (999) 123-45-67", "", "+79991234567", "RU"},
+		{"8 999 123 45 67", "RU", "+79991234567", "RU"},
+		{"9991234567", "RU", "+79991234567", "RU"},
+		{"79991234567", "RU", "+79991234567", "RU"},
+		{"79991234567@c.us", "", "+79991234567", "RU"},
+		{"+7 701 123 4567", "", "+77011234567", "KZ"},
+		{"8 701 123 4567", "KZ", "+77011234567", "KZ"},
+		{"+375 29 123-45-67", "", "+375291234567", "BY"},
+		{"80 29 123 45 67", "BY", "+375291234567", "BY"},
+		{"0049 30 123456", "RU", "+4930123456", "DE"},
+		{"030 123456", "DE", "+4930123456", "DE"},
+		{"(415) 555-2671", "US", "+14155552671", "US"},
+		{"+44 7911 123456", "", "+447911123456", "GB"},
+		{"+91 98765 43210", "", "+919876543210", "IN"},
+		{"+55 11 91234-5678", "", "+5511912345678", "BR"},
+		{"+971 50 123 4567", "", "+971501234567", "AE"},
+
+		// Calling codes without a built-in plan
+		{"+86 138 0013 8000", "", "+8613800138000", ""},
+		{"+81 90-1234-5678", "", "+819012345678", ""},
+		{"+62 812 3456 7890", "", "+6281234567890", ""},
+		{"+20 10 1234 5678", "", "+201012345678", ""},
+		{"+1 441 234 5678", "", "+14412345678", "US"},
+		{"+683 4002", "", "+6834002", ""},
+		{"+852 5123 4567", "", "+85251234567", ""},
+		{"00 86 138 0013 8000", "RU", "+8613800138000", ""},
+	}
+
+	for _, tt := range tests {
+		n, err := Parse(tt.raw, tt.region)
+		if err != nil {
+			t.Errorf("Parse(%q, %q): %v", tt.raw, tt.region, err)
+			continue
+		}
+		if n.E164() != tt.e164 || n.Region != tt.country {
+			t.Errorf("Parse(%q, %q) = %s in %q, want %s in %q", tt.raw, tt.region, n.E164(), n.Region, tt.e164, tt.country)
+		}
+	}
+}
+
+func TestParseCallingCode(t *testing.T) {
+	tests := map[string]string{
+		"+8613800138000": "86",
+		"+819012345678":  "81",
+		"+6281234567890": "62",
+		"+201012345678":  "20",
+		"+2348031234567": "234",
+		"+85251234567":   "852",
+		"+37060012345":   "370",
+		"+9647901234567": "964",
+	}
+	for raw, code := range tests {
+		n, err := Parse(raw, "")
+		if err != nil {
+			t.Errorf("Parse(%q): %v", raw, err)
+			continue
+		}
+		if n.CallingCode != code || n.CallingCode+n.National != raw[1:] {
+			t.Errorf("Parse(%q) = +%s %s, want calling code %s", raw, n.CallingCode, n.National, code)
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	tests := []struct {
+		raw, region string
+	}{
+		{"", "RU"},
+		{"   ", "RU"},
+		{"+7 999 123", ""},           // too short
+		{"+7 999 123 45 678", ""},    // too long for the plan
+		{"+7 599 123 45 67", ""},     // no region of +7 uses 5
+		{"+375 55 123 45 67", ""},    // not a Belarusian prefix
+		{"+1 099 555 2671", ""},      // NANP numbers do not start with 0
+		{"+0 123 456 789", ""},       // calling codes never start with 0
+		{"+012 345 678 90", ""},      // calling codes never start with 0
+		{"+28 1234 5678", ""},        // unassigned calling code
+		{"+219 1234 5678", ""},       // unassigned calling code
+		{"+384 1234 5678", ""},       // unassigned calling code
+		{"+999 1234 5678", ""},       // unassigned calling code
+		{"+683 123", ""},             // too short
+		{"+1234567890123456", ""},    // longer than E.164 allows
+		{"+7 999 123-45-67 ext", ""}, // letters
+		{"999 123 45 67", "XX"},      // unknown default region
+		{"12345", "RU"},              // too short
+	}
+	for _, tt := range tests {
+		n, err := Parse(tt.raw, tt.region)
+		if err == nil {
+			t.Errorf("Parse(%q, %q) = %s, want an error", tt.raw, tt.region, n)
+			continue
+		}
+		if !errors.Is(err, ErrInvalidNumber) {
+			t.Errorf("Parse(%q, %q) error %v does not wrap ErrInvalidNumber", tt.raw, tt.region, err)
+		}
+	}
+}
+
+func TestNumberFormats(t *testing.T) {
+	n, err := Parse("+7 999 123-45-67", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := n.Digits(); got != "79991234567" {
+		t.Errorf("Digits() = %q", got)
+	}
+	if got := n.JID(); got != "79991234567@c.us" {
+		t.Errorf("JID() = %q", got)
+	}
+	if got := n.String(); got != "+79991234567" {
+		t.Errorf("String() = %q", got)
+	}
+}
+
+func TestCallingCodes(t *testing.T) {
+	for code := range callingCodes {
+		if code[0] == '0' {
+			t.Errorf("calling code %s starts with 0", code)
+		}
+		for l := 1; l < len(code); l++ {
+			if callingCodes[code[:l]] {
+				t.Errorf("calling code %s has calling code %s as a prefix", code, code[:l])
+			}
+		}
+	}
+	for region, c := range countries {
+		if !callingCodes[c.CallingCode] {
+			t.Errorf("calling code %s of %s is missing from callingCodes", c.CallingCode, region)
+		}
+	}
+}
@@ -0,0 +1,399 @@
+package selenium
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/tebeka/selenium"
+)
+
+// ErrActionUnavailable is returned when the chat menu does not offer an action,
+// or WhatsApp does not apply it
+var ErrActionUnavailable = errors.New("chat action is not available")
+
+// Chat actions offered by the chat list's context menu
+const (
+	ActionArchive    = "archive"
+	ActionUnarchive  = "unarchive"
+	ActionPin        = "pin"
+	ActionUnpin      = "unpin"
+	ActionMute       = "mute"
+	ActionUnmute     = "unmute"
+	ActionMarkRead   = "mark_read"
+	ActionMarkUnread = "mark_unread"
+	ActionClear      = "clear"
+	ActionDelete     = "delete"
+)
+
+// chatMenuItems are patterns of the context menu item of each action
+var chatMenuItems = map[string]string{
+	ActionArchive:    "^(archive chat|архивировать чат)$",
+	ActionUnarchive:  "^(unarchive chat|разархивировать чат|извлечь из архива)$",
+	ActionPin:        "^(pin chat|pin to top|закрепить чат|закрепить)$",
+	ActionUnpin:      "^(unpin chat|unpin from top|открепить чат|открепить)$",
+	ActionMute:       "^(mute notifications|mute|без звука|отключить звук)$",
+	ActionUnmute:     "^(unmute notifications|unmute|включить звук|со звуком)$",
+	ActionMarkRead:   "^(mark as read|отметить как прочитанное)$",
+	ActionMarkUnread: "^(mark as unread|отметить как непрочитанное)$",
+	ActionClear:      "^(clear chat|clear messages|очистить чат)$",
+	ActionDelete:     "^(delete chat|удалить чат)$",
+}
+
+// confirmButtons are patterns of the dialog button confirming an action
+var confirmButtons = map[string]string{
+	ActionMute:   "^(mute|без звука|ок|ok)$",
+	ActionClear:  "^(clear chat|очистить чат|очистить)$",
+	ActionDelete: "^(delete chat|удалить чат|удалить)$",
+}
+
+// Mute durations WhatsApp offers; longer requests mute for good
+var muteOptions = []struct {
+	duration time.Duration
+	pattern  string
+}{
+	{8 * time.Hour, "^(8 hours|8 часов)$"},
+	{7 * 24 * time.Hour, "^(1 week|1 неделя|1 неделю)$"},
+}
+
+// muteForeverOption is the pattern of the "Always" mute option
+const muteForeverOption = "^(always|всегда)$"
+
+// chatModelScript describes the chat with JID arguments[0] as JSON, or the
+// chat titled arguments[1] if no JID is given. It returns "" if there is no
+// such chat and a JSON number with the count if the title is ambiguous.
+const chatModelScript = chatSerializer + `
+	if (!collections || !collections.Chat) {
+		return "";
+	}
+	if (arguments[0]) {
+		const chat = collections.Chat.get(arguments[0]);
+		return chat ? JSON.stringify(serializeChat(chat)) : "";
+	}
+	const matches = collections.Chat.getModelsArray().filter((c) => (c.formattedTitle || c.name) === arguments[1]);
+	if (matches.length !== 1) {
+		return matches.length ? JSON.stringify(matches.length) : "";
+	}
+	return JSON.stringify(serializeChat(matches[0]));
+`
+
+// chatRowScript returns the visible chat list row titled arguments[0] if there
+// is exactly one
+const chatRowScript = `
+	const pane = document.querySelector("#pane-side");
+	if (!pane) {
+		return null;
+	}
+	const rows = [];
+	for (const row of pane.querySelectorAll("[role='listitem'], [role='row']")) {
+		const span = row.querySelector("span[title]");
+		if (span && span.getAttribute("title") === arguments[0]) {
+			rows.push(row);
+		}
+	}
+	return rows.length === 1 ? rows[0] : null;
+`
+
+// contextMenuScript opens the context menu of the chat list row arguments[0]
+const contextMenuScript = `
+	const row = arguments[0];
+	row.scrollIntoView({block: "center"});
+	const rect = row.getBoundingClientRect();
+	row.dispatchEvent(new MouseEvent("contextmenu", {
+		bubbles: true,
+		cancelable: true,
+		view: window,
+		button: 2,
+		clientX: rect.left + rect.width / 2,
+		clientY: rect.top + rect.height / 2,
+	}));
+`
+
+// dialogButtonScript clicks the option or button of the open dialog whose text matches arguments[0]
+const dialogButtonScript = `
+	const pattern = new RegExp(arguments[0], "i");
+	const dialog = document.querySelector("div[role='dialog'], div[data-animate-modal-popup='true']");
+	if (!dialog) {
+		return false;
+	}
+	for (const item of dialog.querySelectorAll("button, div[role='button'], label, [role='radio']")) {
+		if (pattern.test(item.innerText.trim())) {
+			item.click();
+			return true;
+		}
+	}
+	return false;
+`
+
+// ChatAction applies an action to a chat through its context menu in the chat
+// list and returns the chat's state afterwards; nil once the chat is deleted.
+// Only muteFor of mute is used: WhatsApp mutes for 8 hours, a week or for
+// good, and the shortest of them covering muteFor is chosen (0 is for good).
+func (c *WhatsAppClient) ChatAction(ctx context.Context, target ChatTarget, action string, muteFor time.Duration) (*Chat, error) {
+	pattern, ok := chatMenuItems[action]
+	if !ok {
+		return nil, fmt.Errorf("unknown chat action %q", action)
+	}
+	chat, err := c.chatState(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+
+	if action == ActionMute && chat.MuteExpiration != 0 {
+		return c.changeMute(ctx, chat, muteFor)
+	}
+	if applied(action, chat) {
+		return chat, nil
+	}
+
+	// The chat list row is found by title; refuse if that may be another chat
+	if err := c.confirmTitle(chat.JID, chat.Title); err != nil {
+		return nil, err
+	}
+	search, err := c.openChatMenu(ctx, chat.Title)
+	if search != nil {
+		defer c.clearSearch(search)
+	}
+	if err != nil {
+		return nil, err
+	}
+	clicked, err := c.driver.ExecuteScript(menuItemScript, []interface{}{pattern})
+	if err != nil {
+		return nil, fmt.Errorf("failed to choose %s: %v", action, err)
+	}
+	if ok, _ := clicked.(bool); !ok {
+		c.closeMenu()
+		return nil, fmt.Errorf("%w: the menu of %s has no %s", ErrActionUnavailable, target, action)
+	}
+
+	if action == ActionMute {
+		option := muteForeverOption
+		for _, o := range muteOptions {
+			if muteFor > 0 && muteFor <= o.duration {
+				option = o.pattern
+				break
+			}
+		}
+		if err := c.clickDialog(ctx, option); err != nil {
+			return nil, err
+		}
+	}
+	if button, ok := confirmButtons[action]; ok {
+		if err := c.clickDialog(ctx, button); err != nil {
+			return nil, err
+		}
+	}
+
+	return c.waitForState(ctx, chat.JID, action)
+}
+
+// ChatJID returns the JID of the chat target points to. Like chatState it
+// does not open the chat unless target is an invite link.
+func (c *WhatsAppClient) ChatJID(ctx context.Context, target ChatTarget) (string, error) {
+	chat, err := c.chatState(ctx, target)
+	if err != nil {
+		return "", err
+	}
+	return chat.JID, nil
+}
+
+// chatState finds the chat of target without opening it, since opening a
+// chat marks it as read. Only invite links need the chat to be opened.
+func (c *WhatsAppClient) chatState(ctx context.Context, target ChatTarget) (*Chat, error) {
+	var jid, title string
+	switch {
+	case target.Phone != "":
+		jid = strings.TrimPrefix(target.Phone, "+") + "@c.us"
+	case target.JID != "":
+		jid = target.JID
+	case target.InviteCode != "":
+		if _, err := c.OpenChat(ctx, target); err != nil {
+			return nil, err
+		}
+		open, err := c.activeChat(target)
+		if err != nil {
+			return nil, err
+		}
+		jid = open.JID
+	default:
+		title = target.Title
+	}
+	if err := c.ensureLoaded(ctx); err != nil {
+		return nil, err
+	}
+
+	chat, err := c.readState(jid, title)
+	if err != nil {
+		return nil, err
+	}
+	if chat == nil {
+		return nil, fmt.Errorf("%w: %s is not in the chat list", ErrChatNotFound, target)
+	}
+	return chat, nil
+}
+
+// changeMute gives a muted chat a new mute duration. The menu only offers mute
+// options once a chat is unmuted, so the chat is unmuted first; if muting it
+// again fails, the previous mute is put back with the shortest option that
+// covers what was left of it. The error tells which state the chat is in.
+func (c *WhatsAppClient) changeMute(ctx context.Context, chat *Chat, muteFor time.Duration) (*Chat, error) {
+	target := ChatTarget{JID: chat.JID}
+	previous := time.Duration(0)
+	if chat.MuteExpiration > 0 {
+		previous = time.Until(time.Unix(chat.MuteExpiration, 0))
+		if previous <= 0 {
+			previous = time.Second
+		}
+	}
+
+	unmuted, err := c.ChatAction(ctx, target, ActionUnmute, 0)
+	if err != nil {
+		return nil, fmt.Errorf("%w; the chat is still %s", err, muteState(chat))
+	}
+	muted, err := c.ChatAction(ctx, target, ActionMute, muteFor)
+	if err == nil {
+		return muted, nil
+	}
+	restored, restoreErr := c.ChatAction(ctx, target, ActionMute, previous)
+	if restoreErr != nil {
+		return nil, fmt.Errorf("%w; the chat was left %s, restoring its mute failed: %v", err, muteState(unmuted), restoreErr)
+	}
+	return nil, fmt.Errorf("%w; the previous mute was restored, the chat is %s", err, muteState(restored))
+}
+
+// muteState describes whether and how long a chat is muted
+func muteState(chat *Chat) string {
+	switch {
+	case chat.MuteExpiration == 0:
+		return "unmuted"
+	case chat.MuteExpiration < 0:
+		return "muted for good"
+	}
+	return "muted until " + time.Unix(chat.MuteExpiration, 0).UTC().Format(time.RFC3339)
+}
+
+// readState runs chatModelScript; a nil chat means there is none
+func (c *WhatsAppClient) readState(jid, title string) (*Chat, error) {
+	result, err := c.driver.ExecuteScript(chatModelScript, []interface{}{jid, title})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read chat: %v", err)
+	}
+	raw, _ := result.(string)
+	if raw == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(raw, "{") {
+		return nil, fmt.Errorf("%w: %s chats are titled %q", ErrAmbiguousChat, raw, title)
+	}
+	var chat Chat
+	if err := json.Unmarshal([]byte(raw), &chat); err != nil {
+		return nil, fmt.Errorf("failed to decode chat: %v", err)
+	}
+	return &chat, nil
+}
+
+// openChatMenu opens the context menu of the chat list row titled title, which
+// the caller has checked with confirmTitle. Rows that are not visible are found
+// through the sidebar search, which is returned so the caller can clear it.
+func (c *WhatsAppClient) openChatMenu(ctx context.Context, title string) (selenium.WebElement, error) {
+	var search selenium.WebElement
+	raw, err := c.driver.ExecuteScriptRaw(chatRowScript, []interface{}{title})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read chat list: %v", err)
+	}
+	row, err := c.driver.DecodeElement(raw)
+	if err != nil {
+		var rows []selenium.WebElement
+		search, rows, err = c.searchRows(ctx, title)
+		if err != nil {
+			return search, err
+		}
+		if len(rows) != 1 {
+			return search, titleError(title, len(rows))
+		}
+		row = rows[0]
+	}
+
+	if _, err := c.driver.ExecuteScript(contextMenuScript, []interface{}{row}); err != nil {
+		return search, fmt.Errorf("failed to open chat menu: %v", err)
+	}
+	return search, sleep(ctx, 300*time.Millisecond)
+}
+
+// clickDialog waits for the dialog of an action and clicks the matching option or button
+func (c *WhatsAppClient) clickDialog(ctx context.Context, pattern string) error {
+	waitCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	for {
+		clicked, err := c.driver.ExecuteScript(dialogButtonScript, []interface{}{pattern})
+		if ok, _ := clicked.(bool); err == nil && ok {
+			return sleep(ctx, 300*time.Millisecond)
+		}
+		if err := sleep(waitCtx, 300*time.Millisecond); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			c.closeMenu()
+			return fmt.Errorf("%w: no dialog option %s", ErrActionUnavailable, pattern)
+		}
+	}
+}
+
+// closeMenu dismisses an open menu or dialog
+func (c *WhatsAppClient) closeMenu() {
+	if active, err := c.driver.ActiveElement(); err == nil {
+		active.SendKeys(selenium.EscapeKey)
+	}
+}
+
+// waitForState polls the chat until the action shows in its state
+func (c *WhatsAppClient) waitForState(ctx context.Context, jid, action string) (*Chat, error) {
+	waitCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	for {
+		chat, err := c.readState(jid, "")
+		if err != nil {
+			return nil, err
+		}
+		if action == ActionDelete && chat == nil {
+			return nil, nil
+		}
+		// Clearing leaves no trace in the state; the closed dialog is all there is
+		if chat != nil && (action == ActionClear || applied(action, chat)) {
+			return chat, nil
+		}
+		if err := sleep(waitCtx, 300*time.Millisecond); err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			return nil, fmt.Errorf("%w: WhatsApp did not %s %s", ErrActionUnavailable, strings.ReplaceAll(action, "_", " "), jid)
+		}
+	}
+}
+
+// applied reports whether the chat's state already reflects the action;
+// never for clearing and deleting
+func applied(action string, chat *Chat) bool {
+	switch action {
+	case ActionArchive:
+		return chat.Archived
+	case ActionUnarchive:
+		return !chat.Archived
+	case ActionPin:
+		return chat.Pinned
+	case ActionUnpin:
+		return !chat.Pinned
+	case ActionMute:
+		return chat.MuteExpiration != 0
+	case ActionUnmute:
+		return chat.MuteExpiration == 0
+	case ActionMarkRead:
+		return chat.Unread == 0
+	case ActionMarkUnread:
+		return chat.Unread != 0
+	}
+	return false
+}
@@ -0,0 +1,349 @@
+package selenium
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/tebeka/selenium"
+)
+
+var (
+	// ErrChatNotFound is returned when no chat matches the target
+	ErrChatNotFound = errors.New("chat not found")
+	// ErrAmbiguousChat is returned when several chats have the requested title
+	ErrAmbiguousChat = errors.New("several chats match the title")
+	// ErrNotMember is returned for invite links of groups the account has not joined
+	ErrNotMember = errors.New("not a member of the group")
+)
+
+// ChatTarget identifies a chat to open. Exactly one field should be set.
+type ChatTarget struct {
+	Phone      string // E.164 phone number
+	JID        string // Chat ID such as 1203630xxxx@g.us
+	InviteCode string // Code from a chat.whatsapp.com invite link
+	Title      string // Exact chat title as shown in the sidebar
+}
+
+func (t ChatTarget) String() string {
+	switch {
+	case t.Phone != "":
+		return t.Phone
+	case t.JID != "":
+		return t.JID
+	case t.InviteCode != "":
+		return "invite " + t.InviteCode
+	default:
+		return fmt.Sprintf("%q", t.Title)
+	}
+}
+
+const (
+	searchSelector = "#side div[contenteditable='true']"
+	paneSelector   = "#pane-side"
+)
+
+// openChatByJIDScript opens a chat through WhatsApp Web's own modules; it
+// returns false if the chat is not in the chat list
+const openChatByJIDScript = `
+	const jid = arguments[0];
+	const collections = window.require && window.require("WAWebCollections");
+	const cmd = window.require && window.require("WAWebCmd");
+	if (!collections || !cmd) {
+		throw new Error("WhatsApp Web modules are not available");
+	}
+	const chat = collections.Chat.get(jid);
+	if (!chat) {
+		return false;
+	}
+	cmd.Cmd.openChatBottom(chat);
+	return true;
+`
+
+// titleOwnersScript returns JSON of the JIDs of the chats and contacts that
+// are listed under the title arguments[0]
+const titleOwnersScript = `
+	const collections = window.require && window.require("WAWebCollections");
+	const jids = new Set();
+	if (collections && collections.Chat) {
+		for (const chat of collections.Chat.getModelsArray()) {
+			if ((chat.formattedTitle || chat.name) === arguments[0]) {
+				jids.add(chat.id._serialized);
+			}
+		}
+	}
+	if (collections && collections.Contact) {
+		for (const contact of collections.Contact.getModelsArray()) {
+			if ([contact.name, contact.formattedName, contact.pushname].includes(arguments[0])) {
+				jids.add(contact.id._serialized);
+			}
+		}
+	}
+	return JSON.stringify(Array.from(jids));
+`
+
+// inviteStateScript inspects the page opened by an invite link
+const inviteStateScript = `
+	if (document.querySelector("footer div[contenteditable='true']")) {
+		return "open";
+	}
+	const dialog = document.querySelector("div[role='dialog'], div[data-animate-modal-popup='true']");
+	if (!dialog) {
+		return "";
+	}
+	const text = dialog.innerText;
+	if (/invalid|reset|недействител|сброшен/i.test(text)) {
+		return "invalid";
+	}
+	for (const button of dialog.querySelectorAll("button, div[role='button']")) {
+		if (/open chat|открыть чат/i.test(button.innerText)) {
+			button.click();
+			return "clicked";
+		}
+	}
+	if (/join|вступить|присоединиться/i.test(text)) {
+		return "join";
+	}
+	return "";
+`
+
+// searchResultsScript returns the sidebar rows whose title equals arguments[0],
+// ignoring the message search section that repeats chat titles
+const searchResultsScript = `
+	const title = arguments[0];
+	const pane = document.querySelector("#pane-side");
+	if (!pane) {
+		return [];
+	}
+	const matches = [];
+	const rows = pane.querySelectorAll("[role='listitem'], [role='row'], div[data-testid='section-header'], div[role='heading']");
+	for (const row of rows) {
+		const role = row.getAttribute("role");
+		if (role !== "listitem" && role !== "row") {
+			if (/^(messages|сообщения)$/i.test(row.innerText.trim())) {
+				break;
+			}
+			continue;
+		}
+		const span = row.querySelector("span[title]");
+		if (span && span.getAttribute("title") === title) {
+			matches.push(row);
+		}
+	}
+	return matches;
+`
+
+// OpenChat opens the chat identified by target and returns its message composer
+func (c *WhatsAppClient) OpenChat(ctx context.Context, target ChatTarget) (selenium.WebElement, error) {
+	switch {
+	case target.Phone != "":
+		return c.openURL(ctx, fmt.Sprintf("%s/send?phone=%s", whatsappURL, strings.TrimPrefix(target.Phone, "+")))
+	case target.JID != "":
+		return c.openByJID(ctx, target.JID)
+	case target.InviteCode != "":
+		return c.openByInvite(ctx, target.InviteCode)
+	case target.Title != "":
+		return c.openByTitle(ctx, target.Title)
+	}
+	return nil, fmt.Errorf("empty chat target")
+}
+
+// openURL loads a WhatsApp Web URL that opens a chat
+func (c *WhatsAppClient) openURL(ctx context.Context, chatURL string) (selenium.WebElement, error) {
+	if err := c.get(ctx, chatURL); err != nil {
+		return nil, fmt.Errorf("failed to open chat: %w", err)
+	}
+	return c.composer(ctx)
+}
+
+// composer waits for the message input of the open chat
+func (c *WhatsAppClient) composer(ctx context.Context) (selenium.WebElement, error) {
+	input, err := c.waitForElement(ctx, selenium.ByCSSSelector, composerSelector, defaultTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find message input: %w", err)
+	}
+	return input, nil
+}
+
+// ensureLoaded opens WhatsApp Web unless it is already the current page
+func (c *WhatsAppClient) ensureLoaded(ctx context.Context) error {
+	current, err := c.driver.CurrentURL()
+	if err == nil && strings.HasPrefix(current, whatsappURL) {
+		// The page may still be starting up; give it a chance before reloading
+		if _, err := c.waitForElement(ctx, selenium.ByCSSSelector, paneSelector, defaultTimeout); err == nil {
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+	}
+	if err := c.get(ctx, whatsappURL); err != nil {
+		return fmt.Errorf("failed to open WhatsApp Web: %w", err)
+	}
+	if _, err := c.waitForElement(ctx, selenium.ByCSSSelector, paneSelector, defaultTimeout); err != nil {
+		return fmt.Errorf("chat list did not load: %w", err)
+	}
+	return nil
+}
+
+func (c *WhatsAppClient) openByJID(ctx context.Context, jid string) (selenium.WebElement, error) {
+	// User chats can be opened by phone even if they are not in the chat list
+	if strings.HasSuffix(jid, "@c.us") {
+		return c.openURL(ctx, fmt.Sprintf("%s/send?phone=%s", whatsappURL, url.QueryEscape(strings.TrimSuffix(jid, "@c.us"))))
+	}
+
+	if err := c.ensureLoaded(ctx); err != nil {
+		return nil, err
+	}
+	result, err := c.driver.ExecuteScript(openChatByJIDScript, []interface{}{jid})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open chat %s: %v", jid, err)
+	}
+	if opened, _ := result.(bool); !opened {
+		return nil, fmt.Errorf("%w: %s", ErrChatNotFound, jid)
+	}
+	return c.composer(ctx)
+}
+
+func (c *WhatsAppClient) openByInvite(ctx context.Context, code string) (selenium.WebElement, error) {
+	if err := c.get(ctx, fmt.Sprintf("%s/accept?code=%s", whatsappURL, url.QueryEscape(code))); err != nil {
+		return nil, fmt.Errorf("failed to open invite link: %w", err)
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+	for {
+		result, err := c.driver.ExecuteScript(inviteStateScript, nil)
+		if err == nil {
+			switch result {
+			case "open":
+				return c.composer(ctx)
+			case "invalid":
+				return nil, fmt.Errorf("%w: invite link %s is invalid", ErrChatNotFound, code)
+			case "join":
+				return nil, fmt.Errorf("%w: invite %s", ErrNotMember, code)
+			}
+		}
+		if err := sleep(waitCtx, 500*time.Millisecond); err != nil {
+			return nil, fmt.Errorf("invite link did not open a chat: %w", err)
+		}
+	}
+}
+
+func (c *WhatsAppClient) openByTitle(ctx context.Context, title string) (selenium.WebElement, error) {
+	search, rows, err := c.searchRows(ctx, title)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) != 1 {
+		c.clearSearch(search)
+		return nil, titleError(title, len(rows))
+	}
+
+	if err := rows[0].Click(); err != nil {
+		return nil, fmt.Errorf("failed to open chat %q: %v", title, err)
+	}
+	return c.composer(ctx)
+}
+
+// searchRows types title into the sidebar search and returns the search box
+// with the result rows titled exactly title once the results settle
+func (c *WhatsAppClient) searchRows(ctx context.Context, title string) (selenium.WebElement, []selenium.WebElement, error) {
+	if err := c.ensureLoaded(ctx); err != nil {
+		return nil, nil, err
+	}
+
+	search, err := c.waitForElement(ctx, selenium.ByCSSSelector, searchSelector, defaultTimeout)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to find chat search: %w", err)
+	}
+	if err := search.Click(); err != nil {
+		return nil, nil, fmt.Errorf("failed to focus chat search: %v", err)
+	}
+	// Clear a previous query before typing the title
+	if err := search.SendKeys(selenium.ControlKey + "a" + selenium.NullKey + selenium.BackspaceKey); err != nil {
+		return nil, nil, fmt.Errorf("failed to clear chat search: %v", err)
+	}
+	if err := c.insertText(search, title); err != nil {
+		return nil, nil, err
+	}
+
+	// Results are filtered as the query is typed; wait until they settle
+	var rows []selenium.WebElement
+	settled := 0
+	waitCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	for settled < 3 {
+		if err := sleep(waitCtx, 500*time.Millisecond); err != nil {
+			break
+		}
+		found, err := c.findRows(title)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(found) > 0 && len(found) == len(rows) {
+			settled++
+		} else {
+			settled = 0
+		}
+		rows = found
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+	return search, rows, nil
+}
+
+// confirmTitle checks that the chat jid is the only chat or contact listed
+// under title. Rows of the chat list, its search and the forward dialog can
+// only be told apart by their title, so a shared title could pick another chat.
+func (c *WhatsAppClient) confirmTitle(jid, title string) error {
+	result, err := c.driver.ExecuteScript(titleOwnersScript, []interface{}{title})
+	if err != nil {
+		return fmt.Errorf("failed to read chat titles: %v", err)
+	}
+	raw, _ := result.(string)
+	var owners []string
+	if err := json.Unmarshal([]byte(raw), &owners); err != nil {
+		return fmt.Errorf("failed to decode chat titles: %v", err)
+	}
+	for _, owner := range owners {
+		if owner != jid {
+			return fmt.Errorf("%w: %s shares its title %q with %s", ErrAmbiguousChat, jid, title, owner)
+		}
+	}
+	return nil
+}
+
+// titleError explains why a title did not match exactly one chat
+func titleError(title string, matches int) error {
+	if matches == 0 {
+		return fmt.Errorf("%w: no chat titled %q", ErrChatNotFound, title)
+	}
+	return fmt.Errorf("%w: %d chats are titled %q", ErrAmbiguousChat, matches, title)
+}
+
+// findRows returns sidebar rows whose title equals title
+func (c *WhatsAppClient) findRows(title string) ([]selenium.WebElement, error) {
+	raw, err := c.driver.ExecuteScriptRaw(searchResultsScript, []interface{}{title})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read search results: %v", err)
+	}
+	rows, err := c.driver.DecodeElements(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode search results: %v", err)
+	}
+	return rows, nil
+}
+
+// clearSearch empties the sidebar search so the chat list is back to normal
+func (c *WhatsAppClient) clearSearch(search selenium.WebElement) {
+	if err := search.SendKeys(selenium.EscapeKey); err != nil {
+		log.Printf("Warning: failed to clear chat search: %v", err)
+	}
+}
@@ -0,0 +1,221 @@
+package selenium
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/tebeka/selenium"
+)
+
+// chatStateScript reports what /send?phone= opened: "registered" once the chat
+// composer is shown, "unregistered" for the invalid number dialog
+const chatStateScript = `
+	const dialog = document.querySelector("div[role='dialog'], div[data-animate-modal-popup='true']");
+	if (dialog && /invalid|недействител/i.test(dialog.innerText)) {
+		return "unregistered";
+	}
+	if (document.querySelector("footer div[contenteditable='true']")) {
+		return "registered";
+	}
+	return "";
+`
+
+// CheckNumber reports whether a phone number given in E.164 format has a
+// WhatsApp account. An error means the state could not be determined.
+func (c *WhatsAppClient) CheckNumber(ctx context.Context, phoneNumber string) (bool, error) {
+	chatURL := fmt.Sprintf("%s/send?phone=%s", whatsappURL, strings.TrimPrefix(phoneNumber, "+"))
+	if err := c.get(ctx, chatURL); err != nil {
+		return false, fmt.Errorf("failed to open chat: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		result, err := c.driver.ExecuteScript(chatStateScript, nil)
+		if err == nil {
+			switch result {
+			case "registered":
+				return true, nil
+			case "unregistered":
+				return false, nil
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return false, fmt.Errorf("chat state not detected: %w", ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// Contact is a contact saved in the account's address book
+type Contact struct {
+	JID        string `json:"jid"`
+	Number     string `json:"number"` // Digits of the phone number
+	Name       string `json:"name"`   // Name saved in the address book
+	PushName   string `json:"push_name"`
+	About      string `json:"about"`
+	IsBusiness bool   `json:"business"`
+	PictureURL string `json:"picture"` // Temporary URL of the profile picture
+}
+
+// contactsTimeout bounds reading the address book, about texts included
+const contactsTimeout = 5 * time.Minute
+
+// contactsScript calls back with the saved contacts as JSON, or "" before the
+// page is logged in. About texts are fetched one by one if arguments[0] is set.
+const contactsScript = `
+	const done = arguments[arguments.length - 1];
+	const withAbout = arguments[0];
+	const collections = window.require && window.require("WAWebCollections");
+	if (!collections || !collections.Contact) {
+		done("");
+		return;
+	}
+	const serialize = (wid) => wid ? (wid._serialized || String(wid)) : "";
+	const contacts = [];
+	for (const c of collections.Contact.getModelsArray()) {
+		const jid = serialize(c.id);
+		if (!jid.endsWith("@c.us") || !c.isMyContact) {
+			continue;
+		}
+		const pic = collections.ProfilePicThumb && collections.ProfilePicThumb.get(jid);
+		contacts.push({
+			jid: jid,
+			number: c.id.user || "",
+			name: c.name || "",
+			push_name: c.pushname || "",
+			about: "",
+			business: !!(c.isBusiness || c.isEnterprise),
+			picture: pic ? (pic.eurl || pic.img || "") : "",
+			model: c,
+		});
+	}
+	(async () => {
+		if (withAbout) {
+			let bridge = null;
+			try {
+				bridge = window.require("WAWebContactStatusBridge");
+			} catch (e) {}
+			for (const contact of contacts) {
+				try {
+					const status = bridge && bridge.getStatus
+						? await bridge.getStatus(contact.model.id)
+						: collections.Status && await collections.Status.find(contact.model.id);
+					contact.about = (status && status.status) || "";
+				} catch (e) {}
+			}
+		}
+		done(JSON.stringify(contacts.map(({model, ...contact}) => contact)));
+	})();
+`
+
+// Contacts returns the contacts saved in the account's address book. Reading
+// about texts asks WhatsApp for each contact, so it is optional.
+func (c *WhatsAppClient) Contacts(ctx context.Context, withAbout bool) ([]Contact, error) {
+	if err := c.ensureLoaded(ctx); err != nil {
+		return nil, err
+	}
+	if err := c.setScriptTimeout(ctx, contactsTimeout); err != nil {
+		return nil, err
+	}
+	defer c.driver.SetAsyncScriptTimeout(defaultTimeout)
+
+	result, err := c.driver.ExecuteScriptAsync(contactsScript, []interface{}{withAbout})
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		return nil, fmt.Errorf("failed to read contacts: %v", err)
+	}
+	raw, _ := result.(string)
+	if raw == "" {
+		return nil, fmt.Errorf("contacts are not available before login")
+	}
+
+	var contacts []Contact
+	if err := json.Unmarshal([]byte(raw), &contacts); err != nil {
+		return nil, fmt.Errorf("failed to decode contacts: %v", err)
+	}
+	return contacts, nil
+}
+
+// drawerParticipantsScript reads the participant list of the open group info
+// drawer as JSON, or returns "" until the list is shown
+const drawerParticipantsScript = `
+	const drawer = document.querySelector("[data-testid='chat-info-drawer'], [data-testid='group-info-drawer']")
+		|| document.querySelector("#app div[tabindex='-1'] section");
+	if (!drawer) {
+		return "";
+	}
+	const participants = [];
+	for (const row of drawer.querySelectorAll("[role='listitem'], [role='row']")) {
+		const title = row.querySelector("span[title]");
+		if (!title) {
+			continue;
+		}
+		const text = row.innerText;
+		const name = title.getAttribute("title");
+		const phone = (name.match(/^\+[\d\s()-]{6,}$/) || text.match(/\+\d[\d\s()-]{6,}\d/) || [""])[0];
+		const number = phone.replace(/\D/g, "");
+		participants.push({
+			jid: number ? number + "@c.us" : "",
+			number: number,
+			name: phone === name ? "" : name,
+			admin: /group admin|админ/i.test(text),
+			super_admin: false,
+		});
+	}
+	return participants.length ? JSON.stringify(participants) : "";
+`
+
+// GroupInfo opens a group and returns it with its participants. Participants
+// come from the group's metadata; if the page has none loaded they are read
+// from the group info drawer.
+func (c *WhatsAppClient) GroupInfo(ctx context.Context, target ChatTarget) (*Chat, error) {
+	if _, err := c.OpenChat(ctx, target); err != nil {
+		return nil, err
+	}
+	chat, err := c.activeChat(target)
+	if err != nil {
+		return nil, err
+	}
+	if !chat.IsGroup {
+		return nil, fmt.Errorf("%w: %s is not a group", ErrChatNotFound, target)
+	}
+	if len(chat.Participants) > 0 {
+		return chat, nil
+	}
+
+	header, err := c.waitForElement(ctx, selenium.ByCSSSelector, "#main header", defaultTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find chat header: %w", err)
+	}
+	if err := header.Click(); err != nil {
+		return nil, fmt.Errorf("failed to open group info: %v", err)
+	}
+	defer header.SendKeys(selenium.EscapeKey)
+
+	waitCtx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+	for {
+		result, err := c.driver.ExecuteScript(drawerParticipantsScript, nil)
+		if raw, _ := result.(string); err == nil && raw != "" {
+			if err := json.Unmarshal([]byte(raw), &chat.Participants); err != nil {
+				return nil, fmt.Errorf("failed to decode participants: %v", err)
+			}
+			return chat, nil
+		}
+		if err := sleep(waitCtx, 500*time.Millisecond); err != nil {
+			return nil, fmt.Errorf("group participants did not load: %w", err)
+		}
+	}
+}
@@ -0,0 +1,354 @@
+package selenium
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ErrNotAdmin is returned for group changes that need admin rights the account lacks
+var ErrNotAdmin = errors.New("not an admin of the group")
+
+// groupTimeout bounds one group operation, which waits for WhatsApp's servers
+const groupTimeout = 2 * time.Minute
+
+// ParticipantChange is WhatsApp's answer for one member of a participant change
+type ParticipantChange struct {
+	JID  string `json:"jid"`
+	Code int    `json:"code"` // 200 on success, otherwise WhatsApp's error code
+}
+
+// GroupUpdate lists the group settings to change; nil fields are left as they are
+type GroupUpdate struct {
+	Title       *string
+	Description *string
+	Icon        []byte // JPEG or PNG, cropped to a square
+	AdminsOnly  *bool  // Only admins may send messages
+}
+
+// groupResult is the JSON a group script calls back with
+type groupResult struct {
+	Error        string              `json:"error"`
+	Message      string              `json:"message"`
+	JID          string              `json:"jid"`
+	Code         string              `json:"code"`
+	Participants []ParticipantChange `json:"participants"`
+}
+
+// groupPrelude defines helpers for the group scripts: fail and finish call
+// back, fn finds a function in the first WhatsApp Web module exporting it,
+// and for scripts run on a group (arguments[0]) chat, meta and iAmAdmin
+const groupPrelude = `
+	const done = arguments[arguments.length - 1];
+	const finish = (result) => done(JSON.stringify(result || {}));
+	const fail = (error, message) => finish({error: error, message: message || ""});
+	const load = (name) => {
+		try {
+			return window.require(name);
+		} catch (e) {
+			return null;
+		}
+	};
+	const fn = (name, ...modules) => {
+		for (const m of modules) {
+			const mod = load(m);
+			if (mod && typeof mod[name] === "function") {
+				return mod[name].bind(mod);
+			}
+		}
+		throw new Error("WhatsApp Web does not provide " + name);
+	};
+	const serialize = (wid) => wid ? (wid._serialized || String(wid)) : "";
+	const createWid = (jid) => fn("createWid", "WAWebWidFactory")(jid);
+	const denied = (e) => e && (e.status === 401 || e.status === 403 || /not-authorized|forbidden|not-admin/i.test(String(e.message || e)));
+	const collections = load("WAWebCollections");
+	if (!collections || !collections.Chat) {
+		fail("unavailable");
+		return;
+	}
+	const changes = (result, wids) => {
+		const list = (result && (result.participants || result)) || [];
+		const byJid = {};
+		for (const p of (Array.isArray(list) ? list : [])) {
+			const wid = p.wid || p.id || p.userWid;
+			const code = Number(p.code || p.error || (p.status && p.status.code) || 200);
+			byJid[serialize(wid)] = code;
+		}
+		return wids.map((wid) => ({jid: serialize(wid), code: byJid[serialize(wid)] || 200}));
+	};
+	const run = async (body) => {
+		try {
+			await body();
+		} catch (e) {
+			if (denied(e)) {
+				fail("not_admin");
+			} else {
+				fail("failed", String((e && e.message) || e));
+			}
+		}
+	};
+	const chat = arguments[0] ? collections.Chat.get(arguments[0]) : null;
+	const meta = chat && chat.groupMetadata;
+	if (arguments[0] && !meta) {
+		fail(chat ? "not_group" : "not_found");
+		return;
+	}
+	const iAmAdmin = () => {
+		const members = meta.participants;
+		if (members && typeof members.iAmAdmin === "function") {
+			return members.iAmAdmin();
+		}
+		const me = load("WAWebUserPrefsMeUser");
+		const wid = me && me.getMaybeMeUser && me.getMaybeMeUser();
+		const self = wid && members && members.get(wid);
+		return !!(self && (self.isAdmin || self.isSuperAdmin));
+	};
+`
+
+// createGroupScript creates a group titled arguments[1] with the JIDs of
+// arguments[2] and calls back with its JID and the per-member results
+const createGroupScript = groupPrelude + `
+	run(async () => {
+		const wids = arguments[2].map(createWid);
+		const create = fn("createGroup", "WAWebGroupCreateJob");
+		const result = await create({
+			title: arguments[1],
+			ephemeralDuration: 0,
+			restrict: false,
+			announce: false,
+			parentGroupId: undefined,
+		}, wids);
+		const jid = serialize((result && (result.wid || result.gid || result.id)) || null);
+		if (!jid) {
+			fail("failed", "WhatsApp did not return the new group");
+			return;
+		}
+		finish({jid: jid, participants: changes(result, wids)});
+	});
+`
+
+// updateGroupScript applies the settings of arguments[1] to the group. Title,
+// description and icon may be changed by any member unless the group
+// restricts info edits to admins.
+const updateGroupScript = groupPrelude + `
+	const update = arguments[1];
+	run(async () => {
+		if (update.admins_only !== undefined && !iAmAdmin()) {
+			fail("not_admin", "announcement");
+			return;
+		}
+		if ((update.title !== undefined || update.description !== undefined || update.icon) && meta.restrict && !iAmAdmin()) {
+			fail("not_admin", "info");
+			return;
+		}
+		const modules = ["WAWebGroupModifyInfoJob", "WAWebGroupQueryJob", "WAWebGroupCreateJob"];
+		if (update.title !== undefined) {
+			await fn("setGroupSubject", ...modules)(chat.id, update.title);
+		}
+		if (update.description !== undefined) {
+			const newId = await fn("newId", "WAWebMsgKey")();
+			await fn("setGroupDescription", ...modules)(chat.id, update.description, newId, meta.descId);
+		}
+		if (update.admins_only !== undefined) {
+			await fn("setGroupProperty", ...modules)(chat.id, "announcement", update.admins_only ? 1 : 0);
+		}
+		if (update.icon) {
+			const image = new Image();
+			image.src = update.icon;
+			await image.decode();
+			const side = Math.min(image.width, image.height);
+			const scaled = (size) => {
+				const canvas = document.createElement("canvas");
+				canvas.width = size;
+				canvas.height = size;
+				canvas.getContext("2d").drawImage(image, (image.width - side) / 2, (image.height - side) / 2, side, side, 0, 0, size, size);
+				return canvas.toDataURL("image/jpeg", 0.9).split(",")[1];
+			};
+			await fn("sendSetPicture", "WAWebContactProfilePicThumbBridge", "WAWebGroupModifyInfoJob")(chat.id, scaled(96), scaled(640));
+		}
+		finish({jid: serialize(chat.id)});
+	});
+`
+
+// participantsScript applies the action arguments[1] to the JIDs of arguments[2]
+const participantsScript = groupPrelude + `
+	run(async () => {
+		if (!iAmAdmin()) {
+			fail("not_admin", arguments[1]);
+			return;
+		}
+		const wids = arguments[2].map(createWid);
+		const name = {
+			add: "addParticipants",
+			remove: "removeParticipants",
+			promote: "promoteParticipants",
+			demote: "demoteParticipants",
+		}[arguments[1]];
+		const action = fn(name, "WAWebModifyParticipantsGroupAction");
+		const members = wids.map((wid) => (collections.Contact && collections.Contact.get(wid)) || wid);
+		const result = await action(chat, members);
+		finish({jid: serialize(chat.id), participants: changes(result, wids)});
+	});
+`
+
+// inviteLinkScript calls back with the group's invite code, resetting it first if arguments[1] is set
+const inviteLinkScript = groupPrelude + `
+	run(async () => {
+		if (!iAmAdmin()) {
+			fail("not_admin", "invite");
+			return;
+		}
+		if (arguments[1]) {
+			await fn("resetGroupInviteCode", "WAWebGroupInviteJob", "WAWebGroupQueryJob")(chat.id);
+		}
+		const result = await fn("queryGroupInviteCode", "WAWebMexFetchGroupInviteCodeJob", "WAWebGroupInviteJob", "WAWebGroupQueryJob")(chat.id);
+		const code = typeof result === "string" ? result : (result && result.code) || "";
+		if (!code) {
+			fail("failed", "WhatsApp did not return an invite code");
+			return;
+		}
+		finish({jid: serialize(chat.id), code: code});
+	});
+`
+
+// CreateGroup creates a group with the given E.164 numbers as its members.
+// Members WhatsApp refuses to add are reported by their code.
+func (c *WhatsAppClient) CreateGroup(ctx context.Context, title string, numbers []string) (string, []ParticipantChange, error) {
+	if err := c.ensureLoaded(ctx); err != nil {
+		return "", nil, err
+	}
+	result, err := c.runGroupScript(ctx, createGroupScript, "", title, phoneJIDs(numbers))
+	if err != nil {
+		return "", nil, err
+	}
+	return result.JID, result.Participants, nil
+}
+
+// UpdateGroup changes the settings of a group and returns its JID
+func (c *WhatsAppClient) UpdateGroup(ctx context.Context, target ChatTarget, update GroupUpdate) (string, error) {
+	jid, err := c.groupJID(ctx, target)
+	if err != nil {
+		return "", err
+	}
+
+	settings := map[string]interface{}{}
+	if update.Title != nil {
+		settings["title"] = *update.Title
+	}
+	if update.Description != nil {
+		settings["description"] = *update.Description
+	}
+	if update.AdminsOnly != nil {
+		settings["admins_only"] = *update.AdminsOnly
+	}
+	if len(update.Icon) > 0 {
+		settings["icon"] = "data:" + http.DetectContentType(update.Icon) + ";base64," + base64.StdEncoding.EncodeToString(update.Icon)
+	}
+
+	result, err := c.runGroupScript(ctx, updateGroupScript, jid, settings)
+	if err != nil {
+		return "", err
+	}
+	return result.JID, nil
+}
+
+// ChangeParticipants adds, removes, promotes or demotes the given E.164
+// numbers in a group and returns the group's JID with the per-member results
+func (c *WhatsAppClient) ChangeParticipants(ctx context.Context, target ChatTarget, action string, numbers []string) (string, []ParticipantChange, error) {
+	switch action {
+	case "add", "remove", "promote", "demote":
+	default:
+		return "", nil, fmt.Errorf("unknown participant action %q", action)
+	}
+	jid, err := c.groupJID(ctx, target)
+	if err != nil {
+		return "", nil, err
+	}
+	result, err := c.runGroupScript(ctx, participantsScript, jid, action, phoneJIDs(numbers))
+	if err != nil {
+		return "", nil, err
+	}
+	return result.JID, result.Participants, nil
+}
+
+// InviteLink returns the group's JID and invite link. With revoke the current
+// link stops working and a new one is returned.
+func (c *WhatsAppClient) InviteLink(ctx context.Context, target ChatTarget, revoke bool) (string, string, error) {
+	jid, err := c.groupJID(ctx, target)
+	if err != nil {
+		return "", "", err
+	}
+	result, err := c.runGroupScript(ctx, inviteLinkScript, jid, revoke)
+	if err != nil {
+		return "", "", err
+	}
+	return result.JID, "https://chat.whatsapp.com/" + result.Code, nil
+}
+
+// groupJID resolves target to the JID of a group. Group JIDs are used as they
+// are; other targets open the chat to find it.
+func (c *WhatsAppClient) groupJID(ctx context.Context, target ChatTarget) (string, error) {
+	if strings.HasSuffix(target.JID, "@g.us") {
+		return target.JID, c.ensureLoaded(ctx)
+	}
+	if _, err := c.OpenChat(ctx, target); err != nil {
+		return "", err
+	}
+	chat, err := c.activeChat(target)
+	if err != nil {
+		return "", err
+	}
+	if !chat.IsGroup {
+		return "", fmt.Errorf("%w: %s is not a group", ErrChatNotFound, target)
+	}
+	return chat.JID, nil
+}
+
+// runGroupScript runs one of the group scripts and turns its error codes into errors
+func (c *WhatsAppClient) runGroupScript(ctx context.Context, script string, jid string, args ...interface{}) (*groupResult, error) {
+	if err := c.setScriptTimeout(ctx, groupTimeout); err != nil {
+		return nil, err
+	}
+	defer c.driver.SetAsyncScriptTimeout(defaultTimeout)
+
+	raw, err := c.driver.ExecuteScriptAsync(script, append([]interface{}{jid}, args...))
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		return nil, fmt.Errorf("group operation failed: %v", err)
+	}
+	text, _ := raw.(string)
+
+	var result groupResult
+	if err := json.Unmarshal([]byte(text), &result); err != nil {
+		return nil, fmt.Errorf("failed to decode group result: %v", err)
+	}
+	switch result.Error {
+	case "":
+		return &result, nil
+	case "not_admin":
+		return nil, fmt.Errorf("%w %s", ErrNotAdmin, jid)
+	case "not_found":
+		return nil, fmt.Errorf("%w: %s", ErrChatNotFound, jid)
+	case "not_group":
+		return nil, fmt.Errorf("%w: %s is not a group", ErrChatNotFound, jid)
+	case "unavailable":
+		return nil, fmt.Errorf("WhatsApp Web modules are not available")
+	}
+	return nil, fmt.Errorf("group operation failed: %s", result.Message)
+}
+
+// phoneJIDs turns E.164 numbers into user JIDs
+func phoneJIDs(numbers []string) []string {
+	jids := make([]string, len(numbers))
+	for i, n := range numbers {
+		jids[i] = strings.TrimPrefix(n, "+") + "@c.us"
+	}
+	return jids
+}
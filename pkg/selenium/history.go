@@ -0,0 +1,419 @@
+package selenium
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// ChatMessage is a message read from WhatsApp Web
+type ChatMessage struct {
+	ID         string    `json:"id"`     // Serialized message ID, the data-id of its row
+	ChatJID    string    `json:"chat"`   // JID of the chat the message belongs to
+	Sender     string    `json:"sender"` // JID of the author; the chat JID in user chats
+	SenderName string    `json:"sender_name"`
+	FromMe     bool      `json:"from_me"`
+	Type       string    `json:"type"`      // WhatsApp message type, e.g. "chat", "image", "location"
+	Text       string    `json:"text"`      // Body of text messages, caption of media
+	Thumbnail  string    `json:"thumbnail"` // Base64 JPEG preview of media, only when requested
+	Time       time.Time `json:"-"`
+	Unix       int64     `json:"t"`
+	// Location, Contacts and Poll are the content of location, vcard,
+	// multi_vcard and poll_creation messages
+	Location *Location     `json:"location"`
+	Contacts []ContactCard `json:"contacts"`
+	Poll     *Poll         `json:"poll"`
+}
+
+// Chat describes the open chat
+type Chat struct {
+	JID          string        `json:"jid"`
+	Title        string        `json:"title"`
+	IsGroup      bool          `json:"group"`
+	Participants []Participant `json:"participants"` // Group members; empty for user chats
+	Archived     bool          `json:"archived"`
+	Pinned       bool          `json:"pinned"`
+	// MuteExpiration is the Unix time notifications come back, -1 if muted for good, 0 if not muted
+	MuteExpiration int64 `json:"mute_expiration"`
+	// Unread is the number of unread messages, -1 if the chat is marked as unread
+	Unread int `json:"unread"`
+}
+
+// Participant is a member of a group chat
+type Participant struct {
+	JID        string `json:"jid"`
+	Number     string `json:"number"` // Digits of the phone number, if known
+	Name       string `json:"name"`
+	Admin      bool   `json:"admin"`
+	SuperAdmin bool   `json:"super_admin"` // The group's creator
+	Joined     int64  `json:"joined"`      // Unix time the member joined; 0 where WhatsApp does not show it
+}
+
+// HistoryOptions limit the messages read by ReadHistory
+type HistoryOptions struct {
+	Since      time.Time // Skip older messages; zero reads the whole history
+	Limit      int       // Read at most the newest Limit messages; 0 means no limit
+	Thumbnails bool      // Include media previews
+}
+
+// historyPageSize is the number of messages fetched from the page at once
+const historyPageSize = 500
+
+// messageSerializer defines serializeMessage(m, thumbnails), which turns a
+// WAWebCollections.Msg model into the JSON form of ChatMessage
+const messageSerializer = `
+	const collections = window.require && window.require("WAWebCollections");
+	const serialize = (wid) => wid ? (wid._serialized || String(wid)) : "";
+	const contactName = (jid) => {
+		const contact = collections && collections.Contact && collections.Contact.get(jid);
+		return contact ? (contact.name || contact.pushname || contact.verifiedName || "") : "";
+	};
+	const serializeMessage = (m, thumbnails) => {
+		const sender = serialize(m.author || m.from);
+		let thumbnail = "";
+		// Media models keep a base64 JPEG preview in body
+		if (thumbnails && m.type !== "chat" && typeof m.body === "string" && m.body.startsWith("/9j/")) {
+			thumbnail = m.body;
+		}
+		let location = null;
+		if (m.type === "location" && typeof m.lat === "number") {
+			const lines = (m.loc || "").split("\n");
+			location = {lat: m.lat, lng: m.lng, name: lines[0], address: lines.slice(1).join(", "), url: m.clientUrl || ""};
+		}
+		let contacts = null;
+		if (m.type === "vcard") {
+			contacts = [{name: m.vcardFormattedName || "", vcard: m.body || ""}];
+		} else if (m.type === "multi_vcard") {
+			contacts = (m.vcardList || []).map((c) => ({name: c.displayName || "", vcard: c.vcard || ""}));
+		}
+		let poll = null;
+		if (m.type === "poll_creation") {
+			poll = {
+				question: m.pollName || "",
+				options: (m.pollOptions || []).map((o) => ({id: o.localId, name: o.name || "", votes: 0})),
+				selectable: m.pollSelectableOptionsCount || 0,
+				voters: 0,
+			};
+		}
+		return {
+			id: serialize(m.id),
+			chat: serialize(m.id.remote),
+			sender: sender,
+			sender_name: m.id.fromMe ? "" : contactName(sender),
+			from_me: !!m.id.fromMe,
+			type: m.type || "",
+			text: m.type === "chat" ? (m.body || "") : (m.caption || ""),
+			thumbnail: thumbnail,
+			t: m.t,
+			location: location,
+			contacts: contacts,
+			poll: poll,
+		};
+	};
+`
+
+// recentMessagesScript returns messages loaded in the page that are newer than
+// the Unix time arguments[0], oldest first, as JSON. It returns "[]" before the
+// page is logged in.
+const recentMessagesScript = messageSerializer + `
+	const since = arguments[0];
+	if (!collections || !collections.Msg) {
+		return "[]";
+	}
+	const out = [];
+	for (const m of collections.Msg.getModelsArray()) {
+		if (!m.t || m.t <= since || m.isNotification) {
+			continue;
+		}
+		out.push(serializeMessage(m, false));
+	}
+	out.sort((a, b) => a.t - b.t);
+	return JSON.stringify(out);
+`
+
+// chatSerializer defines serializeChat(chat), which turns a
+// WAWebCollections.Chat model into the JSON form of Chat
+const chatSerializer = messageSerializer + `
+	const serializeChat = (chat) => {
+		const jid = serialize(chat.id);
+		const participants = [];
+		const meta = chat.groupMetadata;
+		if (meta && meta.participants) {
+			const members = meta.participants.getModelsArray ? meta.participants.getModelsArray() : meta.participants;
+			for (const p of members) {
+				const member = serialize(p.id);
+				participants.push({
+					jid: member,
+					number: member.endsWith("@c.us") ? p.id.user : "",
+					name: contactName(member),
+					admin: !!(p.isAdmin || p.isSuperAdmin),
+					super_admin: !!p.isSuperAdmin,
+					joined: p.joinedTs || p.addedTs || 0,
+				});
+			}
+		}
+		const mute = chat.mute ? chat.mute.expiration : chat.muteExpiration;
+		return {
+			jid: jid,
+			title: chat.formattedTitle || chat.name || "",
+			group: jid.endsWith("@g.us"),
+			participants: participants,
+			archived: !!chat.archive,
+			pinned: !!chat.pin,
+			mute_expiration: mute || 0,
+			unread: chat.unreadCount || 0,
+		};
+	};
+`
+
+// activeChatScript finds the chat open in the main pane
+const activeChatScript = `
+	const collections = window.require && window.require("WAWebCollections");
+	if (!collections || !collections.Chat) {
+		return null;
+	}
+	if (collections.Chat.getActive) {
+		return collections.Chat.getActive() || null;
+	}
+	return collections.Chat.getModelsArray().find((c) => c.active) || null;
+`
+
+// chatInfoScript describes the open chat as JSON, or returns "" if none is open
+const chatInfoScript = chatSerializer + `
+	const chat = (() => {` + activeChatScript + `})();
+	return chat ? JSON.stringify(serializeChat(chat)) : "";
+`
+
+// loadEarlierScript loads the previous batch of messages of the open chat and
+// calls back with JSON {"loaded": n, "total": n, "oldest": unix}. When the
+// loader module is unavailable it scrolls the conversation up instead.
+const loadEarlierScript = `
+	const done = arguments[arguments.length - 1];
+	const chat = (() => {` + activeChatScript + `})();
+	if (!chat) {
+		done("");
+		return;
+	}
+	const report = (before) => {
+		const msgs = chat.msgs.getModelsArray();
+		done(JSON.stringify({
+			loaded: msgs.length - before,
+			total: msgs.length,
+			oldest: msgs.length ? msgs[0].t : 0,
+		}));
+	};
+	const before = chat.msgs.length;
+	let loader = null;
+	try {
+		loader = window.require("WAWebChatLoadMessages");
+	} catch (e) {}
+	if (loader && loader.loadEarlierMsgs) {
+		Promise.resolve(loader.loadEarlierMsgs(chat)).then(() => report(before), () => report(before));
+		return;
+	}
+	const pane = document.querySelector("#main [role='application']") || document.querySelector("#main .copyable-area > div");
+	if (pane) {
+		pane.scrollTop = 0;
+	}
+	setTimeout(() => report(before), 1500);
+`
+
+// historyPageScript returns JSON of the open chat's messages not older than the
+// Unix time arguments[0], limited to the newest arguments[1] (0 for all), from
+// offset arguments[2], at most arguments[3] of them, oldest first
+const historyPageScript = messageSerializer + `
+	const [since, limit, offset, count, thumbnails] = arguments;
+	const chat = (() => {` + activeChatScript + `})();
+	if (!chat) {
+		return "";
+	}
+	let msgs = chat.msgs.getModelsArray().filter((m) => m.t && m.t >= since && !m.isNotification);
+	msgs.sort((a, b) => a.t - b.t);
+	if (limit > 0 && msgs.length > limit) {
+		msgs = msgs.slice(msgs.length - limit);
+	}
+	return JSON.stringify(msgs.slice(offset, offset + count).map((m) => serializeMessage(m, thumbnails)));
+`
+
+// chatActivityScript returns JSON of the Unix time of the latest activity of
+// every chat in the chat list, keyed by JID
+const chatActivityScript = `
+	const collections = window.require && window.require("WAWebCollections");
+	const out = {};
+	if (collections && collections.Chat) {
+		for (const chat of collections.Chat.getModelsArray()) {
+			if (chat.t) {
+				out[chat.id._serialized] = chat.t;
+			}
+		}
+	}
+	return JSON.stringify(out);
+`
+
+// ChatActivity returns the time of the latest activity of every chat in the
+// chat list, keyed by JID, without opening any chat
+func (c *WhatsAppClient) ChatActivity(ctx context.Context) (map[string]time.Time, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	result, err := c.driver.ExecuteScript(chatActivityScript, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read chat list: %v", err)
+	}
+	raw, _ := result.(string)
+	var activity map[string]int64
+	if err := json.Unmarshal([]byte(raw), &activity); err != nil {
+		return nil, fmt.Errorf("failed to decode chat list: %v", err)
+	}
+	out := make(map[string]time.Time, len(activity))
+	for jid, t := range activity {
+		out[jid] = time.Unix(t, 0)
+	}
+	return out, nil
+}
+
+// RecentMessages returns messages of all loaded chats received or sent after
+// since. WhatsApp Web keeps the latest messages of every chat in memory, so
+// polling this is enough to notice new incoming messages without opening chats.
+func (c *WhatsAppClient) RecentMessages(ctx context.Context, since time.Time) ([]ChatMessage, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	result, err := c.driver.ExecuteScript(recentMessagesScript, []interface{}{since.Unix()})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read messages: %v", err)
+	}
+	raw, _ := result.(string)
+	if raw == "" {
+		return nil, nil
+	}
+	return decodeMessages(raw)
+}
+
+// ReadHistory opens a chat, loads its history back to opts.Since (or opts.Limit
+// messages) and passes the chat to onChat and then its messages, oldest first,
+// to onMessage. Messages are fetched from the page in batches, so the history
+// is never held in memory as a whole.
+func (c *WhatsAppClient) ReadHistory(ctx context.Context, target ChatTarget, opts HistoryOptions, onChat func(Chat) error, onMessage func(ChatMessage) error) error {
+	if _, err := c.OpenChat(ctx, target); err != nil {
+		return err
+	}
+	chat, err := c.activeChat(target)
+	if err != nil {
+		return err
+	}
+	if err := onChat(*chat); err != nil {
+		return err
+	}
+
+	if err := c.loadHistory(ctx, opts); err != nil {
+		return err
+	}
+
+	var since int64
+	if !opts.Since.IsZero() {
+		since = opts.Since.Unix()
+	}
+	for offset := 0; ; offset += historyPageSize {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		result, err := c.driver.ExecuteScript(historyPageScript, []interface{}{since, opts.Limit, offset, historyPageSize, opts.Thumbnails})
+		if err != nil {
+			return fmt.Errorf("failed to read messages: %v", err)
+		}
+		raw, _ := result.(string)
+		if raw == "" {
+			return fmt.Errorf("chat %s was closed while reading its history", target)
+		}
+		messages, err := decodeMessages(raw)
+		if err != nil {
+			return err
+		}
+		if err := c.countVotes(ctx, messages); err != nil {
+			return err
+		}
+		for _, m := range messages {
+			if err := onMessage(m); err != nil {
+				return err
+			}
+		}
+		if len(messages) < historyPageSize {
+			return nil
+		}
+	}
+}
+
+// activeChat describes the chat opened for target
+func (c *WhatsAppClient) activeChat(target ChatTarget) (*Chat, error) {
+	result, err := c.driver.ExecuteScript(chatInfoScript, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read chat: %v", err)
+	}
+	raw, _ := result.(string)
+	if raw == "" {
+		return nil, fmt.Errorf("%w: %s", ErrChatNotFound, target)
+	}
+	var chat Chat
+	if err := json.Unmarshal([]byte(raw), &chat); err != nil {
+		return nil, fmt.Errorf("failed to decode chat: %v", err)
+	}
+	return &chat, nil
+}
+
+// loadHistory asks the open chat for earlier messages until it has loaded
+// enough for opts or reaches the start of the chat
+func (c *WhatsAppClient) loadHistory(ctx context.Context, opts HistoryOptions) error {
+	// The scroll fallback may need a few attempts before the next batch arrives
+	const maxIdle = 3
+
+	idle := 0
+	for idle < maxIdle {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		result, err := c.driver.ExecuteScriptAsync(loadEarlierScript, nil)
+		if err != nil {
+			return fmt.Errorf("failed to load earlier messages: %v", err)
+		}
+		raw, _ := result.(string)
+		if raw == "" {
+			return fmt.Errorf("%w: chat was closed while loading its history", ErrChatNotFound)
+		}
+		var state struct {
+			Loaded int   `json:"loaded"`
+			Total  int   `json:"total"`
+			Oldest int64 `json:"oldest"`
+		}
+		if err := json.Unmarshal([]byte(raw), &state); err != nil {
+			return fmt.Errorf("failed to decode history state: %v", err)
+		}
+
+		if !opts.Since.IsZero() && state.Oldest > 0 && state.Oldest < opts.Since.Unix() {
+			return nil
+		}
+		if opts.Limit > 0 && state.Total >= opts.Limit {
+			return nil
+		}
+		if state.Loaded > 0 {
+			idle = 0
+		} else {
+			idle++
+		}
+	}
+	return nil
+}
+
+// decodeMessages parses the JSON produced by the message scripts
+func decodeMessages(raw string) ([]ChatMessage, error) {
+	var messages []ChatMessage
+	if err := json.Unmarshal([]byte(raw), &messages); err != nil {
+		return nil, fmt.Errorf("failed to decode messages: %v", err)
+	}
+	for i := range messages {
+		messages[i].Time = time.Unix(messages[i].Unix, 0)
+	}
+	return messages, nil
+}
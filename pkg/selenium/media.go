@@ -0,0 +1,184 @@
+package selenium
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"time"
+)
+
+// ErrNoMedia is returned for messages without a downloadable file, including
+// media that expired on WhatsApp's servers
+var ErrNoMedia = errors.New("message has no downloadable media")
+
+// MediaInfo describes a downloaded media file
+type MediaInfo struct {
+	MimeType string `json:"mime"`
+	FileName string `json:"name"`
+	Size     int64  `json:"size"`
+}
+
+const (
+	// mediaDownloadTimeout bounds fetching and decrypting one file in the page
+	mediaDownloadTimeout = 2 * time.Minute
+	// mediaChunkSize is the number of bytes moved out of the page per script call
+	mediaChunkSize = 2 << 20
+)
+
+// fetchMediaScript downloads and decrypts the media of message arguments[0]
+// into window.__waMedia and calls back with its MediaInfo as JSON, or with
+// "notfound" or "nomedia"
+const fetchMediaScript = `
+	const done = arguments[arguments.length - 1];
+	const collections = window.require && window.require("WAWebCollections");
+	const m = collections && collections.Msg && collections.Msg.get(arguments[0]);
+	if (!m) {
+		done("notfound");
+		return;
+	}
+	if (!m.mediaData && !m.directPath) {
+		done("nomedia");
+		return;
+	}
+	const asBlob = (b) => b && (b.forceToBlob ? b.forceToBlob() : b);
+	(async () => {
+		let blob = m.mediaData && asBlob(m.mediaData.mediaBlob);
+		if (!blob && m.downloadMedia) {
+			await m.downloadMedia({downloadEvenIfExpensive: true, rmrReason: 1, isUserInitiated: true});
+			blob = m.mediaData && asBlob(m.mediaData.mediaBlob);
+		}
+		if (!blob) {
+			const manager = window.require("WAWebDownloadManager").downloadManager;
+			const data = await manager.downloadAndMaybeDecrypt({
+				directPath: m.directPath,
+				encFilehash: m.encFilehash,
+				filehash: m.filehash,
+				mediaKey: m.mediaKey,
+				mediaKeyTimestamp: m.mediaKeyTimestamp,
+				type: m.type,
+				signal: new AbortController().signal,
+			});
+			blob = new Blob([data], {type: m.mimetype});
+		}
+		window.__waMedia = blob;
+		done(JSON.stringify({mime: m.mimetype || blob.type || "", name: m.filename || "", size: blob.size}));
+	})().catch(() => done("nomedia"));
+`
+
+// mediaChunkScript calls back with bytes arguments[0] to arguments[1] of the
+// fetched media, base64 encoded
+const mediaChunkScript = `
+	const done = arguments[arguments.length - 1];
+	const reader = new FileReader();
+	reader.onload = () => done(reader.result.substring(reader.result.indexOf(",") + 1));
+	reader.onerror = () => done(null);
+	reader.readAsDataURL(window.__waMedia.slice(arguments[0], arguments[1]));
+`
+
+// OpenMedia fetches the file of a message loaded in the page, e.g. read by
+// ReadHistory, and returns a reader moving it out of the page in chunks. The
+// reader must be closed before the page is used for anything else.
+func (c *WhatsAppClient) OpenMedia(ctx context.Context, messageID string) (*MediaInfo, io.ReadCloser, error) {
+	if err := c.setScriptTimeout(ctx, mediaDownloadTimeout); err != nil {
+		return nil, nil, err
+	}
+
+	reader := &mediaReader{ctx: ctx, client: c}
+	result, err := c.driver.ExecuteScriptAsync(fetchMediaScript, []interface{}{messageID})
+	if err != nil {
+		reader.Close()
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, nil, ctxErr
+		}
+		return nil, nil, fmt.Errorf("failed to download media: %v", err)
+	}
+	raw, _ := result.(string)
+	switch raw {
+	case "notfound":
+		reader.Close()
+		return nil, nil, fmt.Errorf("%w: %s", ErrMessageNotFound, messageID)
+	case "nomedia", "":
+		reader.Close()
+		return nil, nil, fmt.Errorf("%w: %s", ErrNoMedia, messageID)
+	}
+
+	var info MediaInfo
+	if err := json.Unmarshal([]byte(raw), &info); err != nil {
+		reader.Close()
+		return nil, nil, fmt.Errorf("failed to decode media info: %v", err)
+	}
+	reader.size = info.Size
+	return &info, reader, nil
+}
+
+// setScriptTimeout lets asynchronous scripts run for limit, or until the
+// deadline of ctx if that comes first. Callers restore defaultTimeout.
+func (c *WhatsAppClient) setScriptTimeout(ctx context.Context, limit time.Duration) error {
+	if deadline, ok := ctx.Deadline(); ok {
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return context.DeadlineExceeded
+		}
+		if remaining < limit {
+			limit = remaining
+		}
+	}
+	if err := c.driver.SetAsyncScriptTimeout(limit); err != nil {
+		log.Printf("Warning: failed to set script timeout: %v\n", err)
+	}
+	return nil
+}
+
+// mediaReader reads the media fetched by OpenMedia
+type mediaReader struct {
+	ctx    context.Context
+	client *WhatsAppClient
+	size   int64
+	offset int64
+	buf    []byte
+}
+
+func (r *mediaReader) Read(p []byte) (int, error) {
+	if len(r.buf) == 0 {
+		if r.offset >= r.size {
+			return 0, io.EOF
+		}
+		if err := r.ctx.Err(); err != nil {
+			return 0, err
+		}
+		end := r.offset + mediaChunkSize
+		if end > r.size {
+			end = r.size
+		}
+		result, err := r.client.driver.ExecuteScriptAsync(mediaChunkScript, []interface{}{r.offset, end})
+		if err != nil {
+			return 0, fmt.Errorf("failed to read media: %v", err)
+		}
+		chunk, ok := result.(string)
+		if !ok {
+			return 0, fmt.Errorf("failed to read media")
+		}
+		if r.buf, err = base64.StdEncoding.DecodeString(chunk); err != nil {
+			return 0, fmt.Errorf("failed to decode media: %v", err)
+		}
+		if len(r.buf) == 0 {
+			return 0, io.ErrUnexpectedEOF
+		}
+		r.offset = end
+	}
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
+
+// Close releases the media held by the page and restores the script timeout
+func (r *mediaReader) Close() error {
+	if _, err := r.client.driver.ExecuteScript("delete window.__waMedia;", nil); err != nil {
+		return err
+	}
+	return r.client.driver.SetAsyncScriptTimeout(defaultTimeout)
+}
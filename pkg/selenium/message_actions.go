@@ -0,0 +1,492 @@
+package selenium
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/tebeka/selenium"
+)
+
+// ErrNotAllowed is returned when WhatsApp does not allow an action on a message
+var ErrNotAllowed = errors.New("WhatsApp does not allow this action")
+
+const (
+	// EditWindow is how long after sending WhatsApp lets a message be edited
+	EditWindow = 15 * time.Minute
+	// DeleteWindow is how long after sending WhatsApp lets a message be deleted for everyone
+	DeleteWindow = 60 * time.Hour
+	// MaxForwards is the number of chats WhatsApp forwards a message to at once
+	MaxForwards = 5
+)
+
+// messageModel is the state of a message as WhatsApp Web keeps it
+type messageModel struct {
+	ID      string `json:"id"`
+	ChatJID string `json:"chat"`
+	FromMe  bool   `json:"from_me"`
+	Type    string `json:"type"`
+	Text    string `json:"text"`
+	Unix    int64  `json:"t"`
+	Starred bool   `json:"starred"`
+	Revoked bool   `json:"revoked"`
+}
+
+// messageModelScript describes the message with ID arguments[0] as JSON, or
+// returns "" if it is not loaded
+const messageModelScript = `
+	const collections = window.require && window.require("WAWebCollections");
+	const m = collections && collections.Msg && collections.Msg.get(arguments[0]);
+	if (!m) {
+		return "";
+	}
+	return JSON.stringify({
+		id: m.id._serialized,
+		chat: m.id.remote._serialized || String(m.id.remote),
+		from_me: !!m.id.fromMe,
+		type: m.type || "",
+		text: m.type === "chat" ? (m.body || "") : (m.caption || ""),
+		t: m.t || 0,
+		starred: !!m.star,
+		revoked: m.type === "revoked" || !!m.isRevoked,
+	});
+`
+
+// reactScript reacts to the message arguments[0] with the emoji arguments[1];
+// an empty emoji removes the reaction. It calls back with "" or an error.
+const reactScript = `
+	const done = arguments[arguments.length - 1];
+	const collections = window.require && window.require("WAWebCollections");
+	const m = collections && collections.Msg && collections.Msg.get(arguments[0]);
+	if (!m) {
+		done("message is not loaded");
+		return;
+	}
+	let action = null;
+	try {
+		action = window.require("WAWebSendReactionMsgAction");
+	} catch (e) {}
+	if (!action || !action.sendReactionToMsg) {
+		done("WhatsApp Web does not provide reactions");
+		return;
+	}
+	action.sendReactionToMsg(m, arguments[1]).then(() => done(""), (e) => done(String((e && e.message) || e) || "reaction failed"));
+`
+
+// chatTitleScript returns the title of the chat or contact with JID arguments[0], or ""
+const chatTitleScript = `
+	const collections = window.require && window.require("WAWebCollections");
+	if (!collections) {
+		return "";
+	}
+	const chat = collections.Chat && collections.Chat.get(arguments[0]);
+	if (chat) {
+		return chat.formattedTitle || chat.name || "";
+	}
+	const contact = collections.Contact && collections.Contact.get(arguments[0]);
+	return contact ? (contact.name || contact.formattedName || contact.pushname || "") : "";
+`
+
+// forwardPickScript ticks the chat titled arguments[0] in the forward dialog
+// if exactly one row has that title, and returns the number of such rows
+const forwardPickScript = `
+	const dialog = document.querySelector("div[role='dialog'], div[data-animate-modal-popup='true']");
+	if (!dialog) {
+		return 0;
+	}
+	const rows = [];
+	for (const row of dialog.querySelectorAll("[role='listitem'], [role='row'], [role='button']")) {
+		const span = row.querySelector("span[title]");
+		if (span && span.getAttribute("title") === arguments[0] && !rows.some((r) => r.contains(row) || row.contains(r))) {
+			rows.push(row);
+		}
+	}
+	if (rows.length === 1) {
+		(rows[0].querySelector("input[type='checkbox'], [role='checkbox']") || rows[0]).click();
+	}
+	return rows.length;
+`
+
+// lastOutgoingInScript returns the ID of the newest message the account sent
+// in the chat with JID arguments[0], or "" if there is none
+const lastOutgoingInScript = `
+	const collections = window.require && window.require("WAWebCollections");
+	const chat = collections && collections.Chat && collections.Chat.get(arguments[0]);
+	if (!chat || !chat.msgs) {
+		return "";
+	}
+	const sent = chat.msgs.getModelsArray().filter((m) => m.id.fromMe);
+	return sent.length ? sent[sent.length - 1].id._serialized : "";
+`
+
+// forwardSendScript clicks the send button of the forward dialog
+const forwardSendScript = `
+	const button = document.querySelector("div[role='dialog'] span[data-icon='send'], div[data-animate-modal-popup='true'] span[data-icon='send']");
+	if (!button) {
+		return false;
+	}
+	(button.closest("button, div[role='button']") || button).click();
+	return true;
+`
+
+// React sets the account's reaction to a message; an empty emoji removes it
+func (c *WhatsAppClient) React(ctx context.Context, messageID, emoji string) error {
+	msg, err := c.openMessage(ctx, messageID)
+	if err != nil {
+		return err
+	}
+	if msg.Revoked {
+		return fmt.Errorf("%w: message %s was deleted", ErrNotAllowed, messageID)
+	}
+
+	result, err := c.driver.ExecuteScriptAsync(reactScript, []interface{}{messageID, emoji})
+	if err != nil {
+		return fmt.Errorf("failed to react: %v", err)
+	}
+	if text, _ := result.(string); text != "" {
+		return fmt.Errorf("failed to react: %s", text)
+	}
+	return nil
+}
+
+// EditMessage replaces the text of one of the account's text messages. WhatsApp
+// only allows this within EditWindow of sending. On failure the edit dialog is
+// cleared and closed, leaving the message as it was.
+func (c *WhatsAppClient) EditMessage(ctx context.Context, messageID, text string) error {
+	msg, err := c.openMessage(ctx, messageID)
+	if err != nil {
+		return err
+	}
+	switch {
+	case !msg.FromMe:
+		return fmt.Errorf("%w: only messages sent by the account can be edited", ErrNotAllowed)
+	case msg.Revoked:
+		return fmt.Errorf("%w: message %s was deleted", ErrNotAllowed, messageID)
+	case msg.Type != "chat":
+		return fmt.Errorf("%w: only text messages can be edited, not %s", ErrNotAllowed, msg.Type)
+	case time.Since(time.Unix(msg.Unix, 0)) > EditWindow:
+		return fmt.Errorf("%w: messages can only be edited within %s of sending", ErrNotAllowed, EditWindow)
+	}
+
+	if err := c.messageMenuItem(ctx, messageID, "^(edit|изменить|редактировать)$"); err != nil {
+		return err
+	}
+	input, err := c.waitForElement(ctx, selenium.ByCSSSelector, "div[role='dialog'] div[contenteditable='true'], div[data-animate-modal-popup='true'] div[contenteditable='true']", 5*time.Second)
+	if err != nil {
+		c.closeMenu()
+		return fmt.Errorf("failed to find edit input: %w", err)
+	}
+	if err := input.SendKeys(selenium.ControlKey + "a" + selenium.NullKey + selenium.BackspaceKey); err != nil {
+		c.closeMenu()
+		return fmt.Errorf("failed to clear edit input: %v", err)
+	}
+	if err := c.typeText(ctx, input, text); err != nil {
+		c.closeMenu()
+		return fmt.Errorf("failed to input message: %w", err)
+	}
+	if err := input.SendKeys(selenium.EnterKey); err != nil {
+		c.clearInput(input)
+		c.closeMenu()
+		return fmt.Errorf("failed to save edit: %v", err)
+	}
+
+	return c.waitForMessage(ctx, messageID, "edit", func(m *messageModel) bool {
+		return m.Text == text
+	})
+}
+
+// DeleteForEveryone deletes a message for all members of its chat. WhatsApp
+// allows this for the account's messages within DeleteWindow of sending and
+// for group admins.
+func (c *WhatsAppClient) DeleteForEveryone(ctx context.Context, messageID string) error {
+	msg, err := c.openMessage(ctx, messageID)
+	if err != nil {
+		return err
+	}
+	if msg.Revoked {
+		return nil
+	}
+	if msg.FromMe && time.Since(time.Unix(msg.Unix, 0)) > DeleteWindow {
+		return fmt.Errorf("%w: messages can only be deleted for everyone within %s of sending", ErrNotAllowed, DeleteWindow)
+	}
+
+	if err := c.messageMenuItem(ctx, messageID, "^(delete|удалить)$"); err != nil {
+		return err
+	}
+	if err := c.clickDialog(ctx, "^(delete for everyone|удалить у всех)$"); err != nil {
+		if errors.Is(err, ErrActionUnavailable) {
+			return fmt.Errorf("%w: WhatsApp does not offer to delete message %s for everyone", ErrNotAllowed, messageID)
+		}
+		return err
+	}
+
+	return c.waitForMessage(ctx, messageID, "delete", func(m *messageModel) bool {
+		return m.Revoked
+	})
+}
+
+// StarMessage stars or unstars a message
+func (c *WhatsAppClient) StarMessage(ctx context.Context, messageID string, star bool) error {
+	msg, err := c.openMessage(ctx, messageID)
+	if err != nil {
+		return err
+	}
+	if msg.Starred == star {
+		return nil
+	}
+	if msg.Revoked {
+		return fmt.Errorf("%w: message %s was deleted", ErrNotAllowed, messageID)
+	}
+
+	pattern := "^(star|add to starred|в избранное|добавить в избранное|отметить)$"
+	if !star {
+		pattern = "^(unstar|remove from starred|убрать из избранного|удалить из избранного|снять отметку)$"
+	}
+	if err := c.messageMenuItem(ctx, messageID, pattern); err != nil {
+		return err
+	}
+	return c.waitForMessage(ctx, messageID, "star", func(m *messageModel) bool {
+		return m.Starred == star
+	})
+}
+
+// ForwardMessage forwards a message to up to MaxForwards chats, picked by
+// their title in the forward dialog. Targets whose title is shared with
+// another chat or contact are refused. It returns the number of chats the
+// forward was seen to arrive in, which falls short of the targets on error.
+func (c *WhatsAppClient) ForwardMessage(ctx context.Context, messageID string, targets []ChatTarget) (int, error) {
+	if len(targets) == 0 || len(targets) > MaxForwards {
+		return 0, fmt.Errorf("a message is forwarded to 1 to %d chats at once", MaxForwards)
+	}
+	msg, err := c.openMessage(ctx, messageID)
+	if err != nil {
+		return 0, err
+	}
+	if msg.Revoked {
+		return 0, fmt.Errorf("%w: message %s was deleted", ErrNotAllowed, messageID)
+	}
+
+	jids := make([]string, len(targets))
+	titles := make([]string, len(targets))
+	previous := make([]string, len(targets))
+	for i, target := range targets {
+		if jids[i], titles[i], err = c.forwardTarget(target); err != nil {
+			return 0, err
+		}
+		previous[i] = c.lastOutgoingIn(jids[i])
+	}
+
+	if err := c.messageMenuItem(ctx, messageID, "^(forward|переслать)$"); err != nil {
+		return 0, err
+	}
+	search, err := c.waitForElement(ctx, selenium.ByCSSSelector, "div[role='dialog'] div[contenteditable='true'], div[role='dialog'] input[type='text']", 5*time.Second)
+	if err != nil {
+		c.closeMenu()
+		return 0, fmt.Errorf("failed to find forward search: %w", err)
+	}
+	for i, title := range titles {
+		if err := search.SendKeys(selenium.ControlKey + "a" + selenium.NullKey + selenium.BackspaceKey); err != nil {
+			c.closeMenu()
+			return 0, fmt.Errorf("failed to clear forward search: %v", err)
+		}
+		if err := c.insertText(search, title); err != nil {
+			c.closeMenu()
+			return 0, err
+		}
+		if err := c.pickForward(ctx, title); err != nil {
+			c.closeMenu()
+			return 0, fmt.Errorf("%w: %s", err, targets[i])
+		}
+	}
+
+	sent, err := c.driver.ExecuteScript(forwardSendScript, nil)
+	if ok, _ := sent.(bool); err != nil || !ok {
+		c.closeMenu()
+		return 0, fmt.Errorf("failed to find the forward send button")
+	}
+	return c.waitForForward(ctx, jids, previous)
+}
+
+// openMessage opens the chat of a message and returns the message's state
+func (c *WhatsAppClient) openMessage(ctx context.Context, messageID string) (*messageModel, error) {
+	chatJID := messageChatJID(messageID)
+	if chatJID == "" {
+		return nil, fmt.Errorf("%w: malformed message ID %q", ErrMessageNotFound, messageID)
+	}
+	if _, err := c.openByJID(ctx, chatJID); err != nil {
+		return nil, err
+	}
+	msg, err := c.readMessage(messageID)
+	if err != nil {
+		return nil, err
+	}
+	if msg == nil {
+		return nil, fmt.Errorf("%w: %s", ErrMessageNotFound, messageID)
+	}
+	return msg, nil
+}
+
+// readMessage runs messageModelScript; a nil message is not loaded
+func (c *WhatsAppClient) readMessage(messageID string) (*messageModel, error) {
+	result, err := c.driver.ExecuteScript(messageModelScript, []interface{}{messageID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read message: %v", err)
+	}
+	raw, _ := result.(string)
+	if raw == "" {
+		return nil, nil
+	}
+	var msg messageModel
+	if err := json.Unmarshal([]byte(raw), &msg); err != nil {
+		return nil, fmt.Errorf("failed to decode message: %v", err)
+	}
+	return &msg, nil
+}
+
+// messageMenuItem opens the menu of a message and clicks the item matching
+// pattern. A missing item means WhatsApp does not allow the action.
+func (c *WhatsAppClient) messageMenuItem(ctx context.Context, messageID, pattern string) error {
+	if err := c.openMessageMenu(ctx, messageID); err != nil {
+		return err
+	}
+	clicked, err := c.driver.ExecuteScript(menuItemScript, []interface{}{pattern})
+	if err != nil {
+		return fmt.Errorf("failed to choose menu item: %v", err)
+	}
+	if ok, _ := clicked.(bool); !ok {
+		c.closeMenu()
+		return fmt.Errorf("%w: the menu of message %s has no %s", ErrNotAllowed, messageID, strings.Trim(pattern, "^$()"))
+	}
+	return sleep(ctx, 300*time.Millisecond)
+}
+
+// waitForMessage polls a message until done reports the action took effect
+func (c *WhatsAppClient) waitForMessage(ctx context.Context, messageID, action string, done func(*messageModel) bool) error {
+	waitCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	for {
+		msg, err := c.readMessage(messageID)
+		if err != nil {
+			return err
+		}
+		if msg != nil && done(msg) {
+			return nil
+		}
+		if err := sleep(waitCtx, 300*time.Millisecond); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("%w: WhatsApp did not %s message %s", ErrNotAllowed, action, messageID)
+		}
+	}
+}
+
+// forwardTarget finds the JID of a forward target and the title it is listed
+// under, which must not be shared with another chat or contact
+func (c *WhatsAppClient) forwardTarget(target ChatTarget) (string, string, error) {
+	jid := target.JID
+	switch {
+	case target.Title != "":
+		chat, err := c.readState("", target.Title)
+		if err != nil {
+			return "", "", err
+		}
+		if chat == nil {
+			return "", "", fmt.Errorf("%w: %s", ErrChatNotFound, target)
+		}
+		jid = chat.JID
+	case target.Phone != "":
+		jid = strings.TrimPrefix(target.Phone, "+") + "@c.us"
+	case target.InviteCode != "":
+		return "", "", fmt.Errorf("messages cannot be forwarded to invite links")
+	}
+	result, err := c.driver.ExecuteScript(chatTitleScript, []interface{}{jid})
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read chat title: %v", err)
+	}
+	title, _ := result.(string)
+	if title == "" {
+		return "", "", fmt.Errorf("%w: %s", ErrChatNotFound, target)
+	}
+	if err := c.confirmTitle(jid, title); err != nil {
+		return "", "", err
+	}
+	return jid, title, nil
+}
+
+// pickForward waits for the search results of the forward dialog and ticks
+// the chat titled title; several rows with the title are refused
+func (c *WhatsAppClient) pickForward(ctx context.Context, title string) error {
+	waitCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	for {
+		result, err := c.driver.ExecuteScript(forwardPickScript, []interface{}{title})
+		rows, _ := result.(float64)
+		switch {
+		case err == nil && rows == 1:
+			return sleep(ctx, 300*time.Millisecond)
+		case err == nil && rows > 1:
+			return titleError(title, int(rows))
+		}
+		if err := sleep(waitCtx, 300*time.Millisecond); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return ErrChatNotFound
+		}
+	}
+}
+
+// lastOutgoingIn returns the ID of the newest message the account sent in the chat jid
+func (c *WhatsAppClient) lastOutgoingIn(jid string) string {
+	result, _ := c.driver.ExecuteScript(lastOutgoingInScript, []interface{}{jid})
+	id, _ := result.(string)
+	return id
+}
+
+// waitForForward waits until a new outgoing message shows in every chat of
+// jids and returns the number of chats it showed in
+func (c *WhatsAppClient) waitForForward(ctx context.Context, jids, previous []string) (int, error) {
+	waitCtx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+	arrived := make([]bool, len(jids))
+	n := 0
+	for {
+		for i, jid := range jids {
+			if !arrived[i] {
+				if id := c.lastOutgoingIn(jid); id != "" && id != previous[i] {
+					arrived[i] = true
+					n++
+				}
+			}
+		}
+		if n == len(jids) {
+			return n, nil
+		}
+		if err := sleep(waitCtx, 500*time.Millisecond); err != nil {
+			if ctx.Err() != nil {
+				return n, ctx.Err()
+			}
+			var missing []string
+			for i, jid := range jids {
+				if !arrived[i] {
+					missing = append(missing, jid)
+				}
+			}
+			return n, fmt.Errorf("forward did not arrive in %s", strings.Join(missing, ", "))
+		}
+	}
+}
+
+// messageChatJID returns the chat JID contained in a serialized message ID
+// ("true_<chat>_<id>" or "false_<chat>_<id>[_<author>]"), or "" if the ID is malformed
+func messageChatJID(messageID string) string {
+	parts := strings.Split(messageID, "_")
+	if len(parts) < 3 || (parts[0] != "true" && parts[0] != "false") || !strings.Contains(parts[1], "@") {
+		return ""
+	}
+	return parts[1]
+}
@@ -0,0 +1,284 @@
+package selenium
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/tebeka/selenium"
+)
+
+// ErrMessageNotFound is returned when a message ID is not in the open chat
+var ErrMessageNotFound = errors.New("message not found in chat")
+
+// Message is an outgoing text message
+type Message struct {
+	Text string
+	// ReplyTo is the ID (data-id) of a message in the same chat to quote
+	ReplyTo string
+	// Mentions lists phone numbers of group members to @mention. A number is
+	// mentioned where "@<digits>" appears in Text, or at the end otherwise.
+	Mentions []string
+	// Location, Contacts and Poll are sent instead of Text; only one may be
+	// set, and they are sent without quoting or mentions
+	Location *Location
+	Contacts []ContactCard
+	Poll     *Poll
+}
+
+// lastOutgoingScript returns the data-id of the newest outgoing message in the open chat
+const lastOutgoingScript = `
+	const rows = document.querySelectorAll("#main [data-id^='true_']");
+	return rows.length ? rows[rows.length - 1].getAttribute("data-id") : "";
+`
+
+// findMessageScript scrolls a message into view and returns it
+const findMessageScript = `
+	const row = document.querySelector("#main [data-id='" + CSS.escape(arguments[0]) + "']");
+	if (row) {
+		row.scrollIntoView({block: "center"});
+	}
+	return row;
+`
+
+// menuItemScript clicks the item of an open context menu whose text matches arguments[0]
+const menuItemScript = `
+	const pattern = new RegExp(arguments[0], "i");
+	const items = document.querySelectorAll("[role='application'] li, [role='menu'] [role='menuitem'], [role='menu'] li");
+	for (const item of items) {
+		if (pattern.test(item.innerText.trim())) {
+			item.click();
+			return true;
+		}
+	}
+	return false;
+`
+
+// messageArrowScript returns the menu arrow of the message with ID arguments[0], if shown
+const messageArrowScript = `
+	const row = document.querySelector("#main [data-id='" + CSS.escape(arguments[0]) + "']");
+	return row ? row.querySelector("span[data-icon='down-context'], span[data-icon='ic-chevron-down-menu']") : null;
+`
+
+// mentionPickerScript selects the suggestion of the composer's mention picker
+// that shows the number arguments[0] (digits only) or, failing that, the
+// member's name. It returns the number of matching suggestions and only
+// clicks when there is exactly one.
+const mentionPickerScript = `
+	const number = arguments[0];
+	const collections = window.require && window.require("WAWebCollections");
+	const contact = collections && collections.Contact && collections.Contact.get(number + "@c.us");
+	const names = contact ? [contact.name, contact.formattedName, contact.pushname].filter((n) => n) : [];
+	const options = Array.from(document.querySelectorAll("[role='listbox'] [role='option'], [role='listbox'] [role='button']"))
+		.filter((o, i, all) => !all.some((other) => other !== o && other.contains(o)));
+	let matches = options.filter((o) => o.innerText.replace(/\D/g, "").includes(number));
+	if (!matches.length) {
+		matches = options.filter((o) => Array.from(o.querySelectorAll("span[title]")).some((s) => names.includes(s.getAttribute("title"))));
+	}
+	if (matches.length === 1) {
+		matches[0].click();
+	}
+	return matches.length;
+`
+
+// SendMessage sends a message to the chat identified by target and returns the
+// ID of the sent message, or an empty ID if it could not be determined
+func (c *WhatsAppClient) SendMessage(ctx context.Context, target ChatTarget, msg Message) (string, error) {
+	input, err := c.OpenChat(ctx, target)
+	if err != nil {
+		return "", err
+	}
+
+	// Do not start typing once the caller has given up
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	if msg.Location != nil || len(msg.Contacts) > 0 || msg.Poll != nil {
+		chat, err := c.activeChat(target)
+		if err != nil {
+			return "", err
+		}
+		return c.sendPayload(ctx, chat.JID, msg)
+	}
+	previousID, _ := c.driver.ExecuteScript(lastOutgoingScript, nil)
+
+	if msg.ReplyTo != "" {
+		if err := c.quoteMessage(ctx, msg.ReplyTo); err != nil {
+			return "", err
+		}
+	}
+
+	if err := input.Click(); err != nil {
+		return "", fmt.Errorf("failed to focus message input: %v", err)
+	}
+
+	started := time.Now()
+	for _, part := range splitMentions(msg.Text, msg.Mentions) {
+		if part.mention == "" {
+			err = c.typeText(ctx, input, part.text)
+		} else {
+			err = c.typeMention(ctx, input, part.mention)
+		}
+		if err != nil {
+			c.clearInput(input)
+			return "", fmt.Errorf("failed to input message: %w", err)
+		}
+	}
+	if c.typing.ShowTyping {
+		if err := c.keepTyping(ctx, input, len([]rune(msg.Text)), time.Since(started)); err != nil {
+			c.clearInput(input)
+			return "", fmt.Errorf("failed to input message: %w", err)
+		}
+	}
+
+	// Send message
+	if err := input.SendKeys(selenium.EnterKey); err != nil {
+		c.clearInput(input)
+		return "", fmt.Errorf("failed to send message: %v", err)
+	}
+
+	return c.waitForNewOutgoing(ctx, previousID), nil
+}
+
+// waitForNewOutgoing returns the ID of the outgoing message that appears after previousID
+func (c *WhatsAppClient) waitForNewOutgoing(ctx context.Context, previousID interface{}) string {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	for {
+		id, err := c.driver.ExecuteScript(lastOutgoingScript, nil)
+		if s, ok := id.(string); err == nil && ok && s != "" && id != previousID {
+			return s
+		}
+		if err := sleep(ctx, 300*time.Millisecond); err != nil {
+			return ""
+		}
+	}
+}
+
+// quoteMessage opens the context menu of a message and chooses Reply
+func (c *WhatsAppClient) quoteMessage(ctx context.Context, messageID string) error {
+	if err := c.openMessageMenu(ctx, messageID); err != nil {
+		return err
+	}
+	clicked, err := c.driver.ExecuteScript(menuItemScript, []interface{}{"^(reply|ответить)$"})
+	if err != nil {
+		return fmt.Errorf("failed to choose reply: %v", err)
+	}
+	if ok, _ := clicked.(bool); !ok {
+		return fmt.Errorf("reply is not available for message %s", messageID)
+	}
+	return sleep(ctx, c.keyDelay())
+}
+
+// openMessageMenu hovers a message to reveal its menu arrow and opens the menu
+func (c *WhatsAppClient) openMessageMenu(ctx context.Context, messageID string) error {
+	raw, err := c.driver.ExecuteScriptRaw(findMessageScript, []interface{}{messageID})
+	if err != nil {
+		return fmt.Errorf("failed to find message: %v", err)
+	}
+	row, err := c.driver.DecodeElement(raw)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrMessageNotFound, messageID)
+	}
+	if err := row.MoveTo(10, 10); err != nil {
+		return fmt.Errorf("failed to hover message: %v", err)
+	}
+
+	// The ID is passed to the page as data, never spliced into a selector
+	waitCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	for {
+		raw, err := c.driver.ExecuteScriptRaw(messageArrowScript, []interface{}{messageID})
+		if err == nil {
+			if arrow, err := c.driver.DecodeElement(raw); err == nil {
+				if err := arrow.Click(); err != nil {
+					return fmt.Errorf("failed to open message menu: %v", err)
+				}
+				return sleep(ctx, 300*time.Millisecond)
+			}
+		}
+		if err := sleep(waitCtx, 300*time.Millisecond); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("failed to find menu of message %s: %w", messageID, err)
+		}
+	}
+}
+
+// typeMention types "@" and the number, then picks the member from the mention
+// picker; it fails rather than mention anyone else
+func (c *WhatsAppClient) typeMention(ctx context.Context, input selenium.WebElement, number string) error {
+	if err := c.typeText(ctx, input, "@"+number); err != nil {
+		return err
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	for {
+		result, err := c.driver.ExecuteScript(mentionPickerScript, []interface{}{number})
+		matches, _ := result.(float64)
+		switch {
+		case err == nil && matches == 1:
+			return sleep(ctx, c.keyDelay())
+		case err == nil && matches > 1:
+			return fmt.Errorf("%d group members match mention @%s", int(matches), number)
+		}
+		if err := sleep(waitCtx, 300*time.Millisecond); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("no group member matches mention @%s", number)
+		}
+	}
+}
+
+// messagePart is literal text or a mention to resolve through the picker
+type messagePart struct {
+	text    string
+	mention string
+}
+
+var mentionPattern = regexp.MustCompile(`@\+?(\d{6,15})`)
+
+// splitMentions cuts text at "@<digits>" tokens of mentioned numbers and
+// appends mentions that do not occur in the text
+func splitMentions(text string, mentions []string) []messagePart {
+	wanted := make(map[string]bool, len(mentions))
+	for _, m := range mentions {
+		wanted[strings.TrimPrefix(m, "+")] = true
+	}
+
+	var parts []messagePart
+	used := make(map[string]bool)
+	last := 0
+	for _, loc := range mentionPattern.FindAllStringSubmatchIndex(text, -1) {
+		number := text[loc[2]:loc[3]]
+		if !wanted[number] {
+			continue
+		}
+		if loc[0] > last {
+			parts = append(parts, messagePart{text: text[last:loc[0]]})
+		}
+		parts = append(parts, messagePart{mention: number})
+		used[number] = true
+		last = loc[1]
+	}
+	if last < len(text) {
+		parts = append(parts, messagePart{text: text[last:]})
+	}
+
+	for _, m := range mentions {
+		number := strings.TrimPrefix(m, "+")
+		if used[number] {
+			continue
+		}
+		used[number] = true
+		parts = append(parts, messagePart{text: " "}, messagePart{mention: number})
+	}
+	return parts
+}
@@ -0,0 +1,264 @@
+package selenium
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// payloadTimeout bounds sending a location, contact or poll, which waits for
+// WhatsApp's servers, and reading poll votes from the page's database
+const payloadTimeout = time.Minute
+
+// Location is a location pin
+type Location struct {
+	Lat     float64 `json:"lat"`
+	Lng     float64 `json:"lng"`
+	Name    string  `json:"name"`
+	Address string  `json:"address"`
+	URL     string  `json:"url"`
+}
+
+// ContactCard is a shared contact
+type ContactCard struct {
+	Name  string `json:"name"`
+	VCard string `json:"vcard"`
+}
+
+// Poll is a poll; Votes and Voters are only filled when reading history
+type Poll struct {
+	Question string       `json:"question"`
+	Options  []PollOption `json:"options"`
+	// Selectable is the number of options a voter may pick; 0 for any number
+	Selectable int `json:"selectable"`
+	Voters     int `json:"voters"`
+}
+
+// PollOption is an answer of a poll
+type PollOption struct {
+	ID    int    `json:"id"` // WhatsApp's local ID the votes refer to
+	Name  string `json:"name"`
+	Votes int    `json:"votes"`
+}
+
+// sendPayloadScript sends the location, contacts or poll of the JSON
+// arguments[1] to the chat with JID arguments[0] and calls back with JSON
+// {"id": message ID} or {"error": ..., "message": ...}
+const sendPayloadScript = `
+	const done = arguments[arguments.length - 1];
+	const finish = (result) => done(JSON.stringify(result));
+	const payload = JSON.parse(arguments[1]);
+	const load = (name) => {
+		try {
+			return window.require(name);
+		} catch (e) {
+			return null;
+		}
+	};
+	const collections = load("WAWebCollections");
+	const MsgKey = load("WAWebMsgKey");
+	const me = load("WAWebUserPrefsMeUser");
+	const action = load("WAWebSendMsgChatAction");
+	if (!collections || !collections.Chat || !MsgKey || !me || !action || !action.addAndSendMsgToChat) {
+		finish({error: "unavailable"});
+		return;
+	}
+	const chat = collections.Chat.get(arguments[0]);
+	if (!chat) {
+		finish({error: "not_found"});
+		return;
+	}
+	(async () => {
+		const from = me.getMaybeMeUser();
+		const id = new MsgKey({
+			from: from,
+			to: chat.id,
+			id: await MsgKey.newId(),
+			participant: chat.id.isGroup && chat.id.isGroup() ? from : undefined,
+			selfDir: "out",
+		});
+		const msg = {
+			id: id,
+			ack: 0,
+			from: from,
+			to: chat.id,
+			local: true,
+			self: "out",
+			t: Math.floor(Date.now() / 1000),
+			isNewMsg: true,
+		};
+		if (payload.location) {
+			const l = payload.location;
+			Object.assign(msg, {
+				type: "location",
+				lat: l.lat,
+				lng: l.lng,
+				loc: [l.name, l.address].filter((s) => s).join("\n"),
+				clientUrl: l.url || undefined,
+			});
+		} else if (payload.contacts && payload.contacts.length === 1) {
+			Object.assign(msg, {
+				type: "vcard",
+				body: payload.contacts[0].vcard,
+				vcardFormattedName: payload.contacts[0].name,
+			});
+		} else if (payload.contacts) {
+			Object.assign(msg, {
+				type: "multi_vcard",
+				vcardList: payload.contacts.map((c) => ({displayName: c.name, vcard: c.vcard})),
+			});
+		} else if (payload.poll) {
+			Object.assign(msg, {
+				type: "poll_creation",
+				kind: "pollCreation",
+				pollName: payload.poll.question,
+				pollOptions: payload.poll.options.map((o, i) => ({name: o.name, localId: i})),
+				pollSelectableOptionsCount: payload.poll.selectable,
+				messageSecret: window.crypto.getRandomValues(new Uint8Array(32)),
+			});
+		}
+		const results = await Promise.all([].concat(action.addAndSendMsgToChat(chat, msg)));
+		const sent = results[results.length - 1];
+		if (sent && sent.messageSendResult && sent.messageSendResult !== "OK") {
+			finish({error: "failed", message: String(sent.messageSendResult)});
+			return;
+		}
+		finish({id: id._serialized || id.toString()});
+	})().catch((e) => finish({error: "failed", message: String((e && e.message) || e)}));
+`
+
+// pollVotesScript counts the votes of the polls with IDs arguments[0] and calls
+// back with JSON {poll ID: {"voters": n, "votes": {option ID: n}}}. Only the
+// latest vote of each voter counts; polls without votes are left out.
+const pollVotesScript = `
+	const done = arguments[arguments.length - 1];
+	let schema = null;
+	try {
+		schema = window.require("WAWebPollsVotesSchema");
+	} catch (e) {}
+	const table = schema && (schema.getPollVotesTable || schema.getTable);
+	if (!table) {
+		done("{}");
+		return;
+	}
+	(async () => {
+		const out = {};
+		for (const id of arguments[0]) {
+			const rows = await table.call(schema).equals(["parentMsgKey"], id);
+			const latest = {};
+			for (const vote of rows || []) {
+				const sender = String(vote.sender || vote.senderJid || "");
+				if (!latest[sender] || (vote.senderTimestampMs || 0) > (latest[sender].senderTimestampMs || 0)) {
+					latest[sender] = vote;
+				}
+			}
+			const tally = {voters: 0, votes: {}};
+			for (const vote of Object.values(latest)) {
+				const selected = vote.selectedOptionLocalIds || [];
+				if (selected.length) {
+					tally.voters++;
+				}
+				for (const option of selected) {
+					tally.votes[option] = (tally.votes[option] || 0) + 1;
+				}
+			}
+			if (tally.voters) {
+				out[id] = tally;
+			}
+		}
+		done(JSON.stringify(out));
+	})().catch(() => done("{}"));
+`
+
+// pollTally is the JSON form of one poll's votes from pollVotesScript
+type pollTally struct {
+	Voters int         `json:"voters"`
+	Votes  map[int]int `json:"votes"`
+}
+
+// sendPayload sends the location, contacts or poll of msg to the chat with JID jid
+func (c *WhatsAppClient) sendPayload(ctx context.Context, jid string, msg Message) (string, error) {
+	payload, err := json.Marshal(struct {
+		Location *Location     `json:"location,omitempty"`
+		Contacts []ContactCard `json:"contacts,omitempty"`
+		Poll     *Poll         `json:"poll,omitempty"`
+	}{msg.Location, msg.Contacts, msg.Poll})
+	if err != nil {
+		return "", err
+	}
+
+	if err := c.setScriptTimeout(ctx, payloadTimeout); err != nil {
+		return "", err
+	}
+	defer c.driver.SetAsyncScriptTimeout(defaultTimeout)
+
+	raw, err := c.driver.ExecuteScriptAsync(sendPayloadScript, []interface{}{jid, string(payload)})
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return "", ctxErr
+		}
+		return "", fmt.Errorf("failed to send message: %v", err)
+	}
+	text, _ := raw.(string)
+	var result struct {
+		ID      string `json:"id"`
+		Error   string `json:"error"`
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal([]byte(text), &result); err != nil {
+		return "", fmt.Errorf("failed to decode send result: %v", err)
+	}
+	switch result.Error {
+	case "":
+		return result.ID, nil
+	case "not_found":
+		return "", fmt.Errorf("%w: %s", ErrChatNotFound, jid)
+	case "unavailable":
+		return "", fmt.Errorf("WhatsApp Web modules are not available")
+	}
+	return "", fmt.Errorf("failed to send message: %s", result.Message)
+}
+
+// countVotes fills in the votes of the polls among messages. Votes that cannot
+// be read leave the polls without tallies rather than failing the read.
+func (c *WhatsAppClient) countVotes(ctx context.Context, messages []ChatMessage) error {
+	var ids []string
+	for _, m := range messages {
+		if m.Poll != nil {
+			ids = append(ids, m.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	if err := c.setScriptTimeout(ctx, payloadTimeout); err != nil {
+		return err
+	}
+	defer c.driver.SetAsyncScriptTimeout(defaultTimeout)
+
+	raw, err := c.driver.ExecuteScriptAsync(pollVotesScript, []interface{}{ids})
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		return nil
+	}
+	text, _ := raw.(string)
+	var tallies map[string]pollTally
+	if err := json.Unmarshal([]byte(text), &tallies); err != nil {
+		return nil
+	}
+	for _, m := range messages {
+		tally, ok := tallies[m.ID]
+		if m.Poll == nil || !ok {
+			continue
+		}
+		m.Poll.Voters = tally.Voters
+		for i, option := range m.Poll.Options {
+			m.Poll.Options[i].Votes = tally.Votes[option.ID]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,169 @@
+package selenium
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// processFile lists browser resources started by the running instance
+const processFile = "processes.json"
+
+// processRecord describes what a run started so a later run can clean it up
+type processRecord struct {
+	OwnerPID     int      `json:"owner_pid"`
+	DriverPorts  []int    `json:"driver_ports"`
+	UserDataDirs []string `json:"user_data_dirs"`
+}
+
+// processRegistry persists the ChromeDriver ports and Chrome profile
+// directories of live clients
+type processRegistry struct {
+	mu     sync.Mutex
+	path   string
+	record processRecord
+}
+
+var registry = &processRegistry{}
+
+// ErrDataDirInUse is returned by KillOrphans when another live instance owns
+// the browser data directory
+var ErrDataDirInUse = errors.New("browser data directory is in use")
+
+// KillOrphans terminates chrome and chromedriver processes left behind by a
+// previous run that exited without closing its clients and removes stale
+// temporary profiles. It must be called before any client is created. If
+// another running instance owns baseDir it fails with ErrDataDirInUse and
+// leaves the directory and that instance's registry alone.
+func KillOrphans(baseDir string) error {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	if err := os.MkdirAll(baseDir, 0755); err != nil {
+		return fmt.Errorf("failed to create browser data directory: %v", err)
+	}
+	path := filepath.Join(baseDir, processFile)
+
+	var previous processRecord
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(data, &previous); err != nil {
+			log.Printf("Warning: ignoring unreadable process registry: %v", err)
+			previous = processRecord{}
+		}
+	case !os.IsNotExist(err):
+		return fmt.Errorf("failed to read process registry: %v", err)
+	}
+	if previous.OwnerPID != 0 && previous.OwnerPID != os.Getpid() && processAlive(previous.OwnerPID) {
+		return fmt.Errorf("%w by process %d", ErrDataDirInUse, previous.OwnerPID)
+	}
+
+	// The directory is ours from here on
+	registry.path = path
+	defer func() {
+		registry.record = processRecord{OwnerPID: os.Getpid()}
+		if err := registry.save(); err != nil {
+			log.Printf("Warning: failed to reset process registry: %v", err)
+		}
+	}()
+	if err := cleanOldSessions(baseDir); err != nil {
+		log.Printf("Warning: failed to clean old sessions: %v", err)
+	}
+	if len(previous.DriverPorts) == 0 && len(previous.UserDataDirs) == 0 {
+		return nil
+	}
+
+	processes, err := listProcesses()
+	if err != nil {
+		return fmt.Errorf("failed to list processes: %v", err)
+	}
+
+	for _, p := range processes {
+		if p.pid == os.Getpid() || !previous.owns(p.cmdline) {
+			continue
+		}
+		log.Printf("Killing orphaned browser process %d: %s", p.pid, p.cmdline)
+		proc, err := os.FindProcess(p.pid)
+		if err != nil {
+			continue
+		}
+		if err := proc.Kill(); err != nil {
+			log.Printf("Warning: failed to kill process %d: %v", p.pid, err)
+		}
+	}
+
+	return nil
+}
+
+// owns reports whether a command line belongs to a recorded browser or driver
+func (r processRecord) owns(cmdline string) bool {
+	for _, dir := range r.UserDataDirs {
+		if strings.Contains(cmdline, "--user-data-dir") && strings.Contains(cmdline, dir) {
+			return true
+		}
+	}
+	if !strings.Contains(strings.ToLower(cmdline), "chromedriver") {
+		return false
+	}
+	for _, port := range r.DriverPorts {
+		if strings.Contains(cmdline, fmt.Sprintf("--port=%d", port)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *processRegistry) add(port int, userDataDir string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.record.OwnerPID = os.Getpid()
+	r.record.DriverPorts = append(r.record.DriverPorts, port)
+	r.record.UserDataDirs = append(r.record.UserDataDirs, userDataDir)
+	if err := r.save(); err != nil {
+		log.Printf("Warning: failed to update process registry: %v", err)
+	}
+}
+
+func (r *processRegistry) remove(port int, userDataDir string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ports := r.record.DriverPorts[:0]
+	for _, p := range r.record.DriverPorts {
+		if p != port {
+			ports = append(ports, p)
+		}
+	}
+	r.record.DriverPorts = ports
+
+	dirs := r.record.UserDataDirs[:0]
+	for _, d := range r.record.UserDataDirs {
+		if d != userDataDir {
+			dirs = append(dirs, d)
+		}
+	}
+	r.record.UserDataDirs = dirs
+
+	if err := r.save(); err != nil {
+		log.Printf("Warning: failed to update process registry: %v", err)
+	}
+}
+
+// save writes the record; callers must hold r.mu
+func (r *processRegistry) save() error {
+	if r.path == "" {
+		return nil
+	}
+	data, err := json.Marshal(r.record)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(r.path, data, 0644)
+}
@@ -0,0 +1,50 @@
+//go:build !windows
+
+package selenium
+
+import (
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+type processInfo struct {
+	pid     int
+	cmdline string
+}
+
+// listProcesses returns the PID and command line of every running process
+func listProcesses() ([]processInfo, error) {
+	out, err := exec.Command("ps", "-eo", "pid=,args=").Output()
+	if err != nil {
+		return nil, err
+	}
+
+	var processes []processInfo
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		pid, err := strconv.Atoi(fields[0])
+		if err != nil {
+			continue
+		}
+		processes = append(processes, processInfo{pid: pid, cmdline: strings.Join(fields[1:], " ")})
+	}
+	return processes, nil
+}
+
+// processAlive reports whether a process with the given PID exists
+func processAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	proc, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	return proc.Signal(syscall.Signal(0)) == nil
+}
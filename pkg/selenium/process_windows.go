@@ -0,0 +1,50 @@
+//go:build windows
+
+package selenium
+
+import (
+	"encoding/json"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+type processInfo struct {
+	pid     int
+	cmdline string
+}
+
+// listProcesses returns the PID and command line of every running process
+func listProcesses() ([]processInfo, error) {
+	out, err := exec.Command("powershell", "-NoProfile", "-Command",
+		"Get-CimInstance Win32_Process | Select-Object ProcessId,CommandLine | ConvertTo-Json -Compress").Output()
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []struct {
+		ProcessId   int
+		CommandLine string
+	}
+	if err := json.Unmarshal(out, &rows); err != nil {
+		return nil, err
+	}
+
+	processes := make([]processInfo, 0, len(rows))
+	for _, row := range rows {
+		processes = append(processes, processInfo{pid: row.ProcessId, cmdline: row.CommandLine})
+	}
+	return processes, nil
+}
+
+// processAlive reports whether a process with the given PID exists
+func processAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	out, err := exec.Command("tasklist", "/FI", "PID eq "+strconv.Itoa(pid), "/NH").Output()
+	if err != nil {
+		return false
+	}
+	return strings.Contains(string(out), strconv.Itoa(pid))
+}
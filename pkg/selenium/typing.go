@@ -0,0 +1,218 @@
+package selenium
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/tebeka/selenium"
+)
+
+// TypingOptions controls how text is entered into the message composer
+type TypingOptions struct {
+	// CharDelay is the average pause between keystrokes; zero types without pauses
+	CharDelay time.Duration
+	// CharDelayJitter randomizes each pause by up to this value in either direction
+	CharDelayJitter time.Duration
+	// PasteThreshold is the line length above which a line is pasted at once
+	// instead of typed; zero always types
+	PasteThreshold int
+	// ShowTyping keeps the "typing…" indicator visible for a duration matching
+	// the message length before sending, capped by MaxTypingIndicator
+	ShowTyping         bool
+	MaxTypingIndicator time.Duration
+}
+
+// insertTextScript inserts text at the caret of a contenteditable element the
+// way a paste does, which also works for characters SendKeys cannot type
+const insertTextScript = `
+	const el = arguments[0];
+	el.focus();
+	return document.execCommand('insertText', false, arguments[1]);
+`
+
+// sleep pauses for d or until ctx is done
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// typeText enters text into the composer. Lines are separated with Shift+Enter
+// so that newlines do not send the message. If typing fails or ctx is done the
+// composer is cleared, so half a message is not left behind as a draft.
+func (c *WhatsAppClient) typeText(ctx context.Context, input selenium.WebElement, text string) (err error) {
+	defer func() {
+		if err != nil {
+			c.clearInput(input)
+		}
+	}()
+
+	for i, line := range strings.Split(text, "\n") {
+		if i > 0 {
+			if err := input.SendKeys(selenium.ShiftKey + selenium.EnterKey + selenium.NullKey); err != nil {
+				return fmt.Errorf("failed to insert line break: %v", err)
+			}
+			if err := sleep(ctx, c.keyDelay()); err != nil {
+				return err
+			}
+		}
+		if line == "" {
+			continue
+		}
+
+		if c.typing.PasteThreshold > 0 && utf8.RuneCountInString(line) > c.typing.PasteThreshold {
+			if err := c.insertText(input, line); err != nil {
+				return err
+			}
+			continue
+		}
+
+		for _, chunk := range splitTypable(line) {
+			if !chunk.typable {
+				if err := c.insertText(input, chunk.text); err != nil {
+					return err
+				}
+				if err := sleep(ctx, c.keyDelay()); err != nil {
+					return err
+				}
+				continue
+			}
+			for _, r := range chunk.text {
+				if err := input.SendKeys(string(r)); err != nil {
+					return fmt.Errorf("failed to type message: %v", err)
+				}
+				if err := sleep(ctx, c.keyDelay()); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// clearInput selects everything in a composer and deletes it. It is cleanup on
+// a failure path, so its own error is ignored.
+func (c *WhatsAppClient) clearInput(input selenium.WebElement) {
+	input.SendKeys(selenium.ControlKey + "a" + selenium.NullKey + selenium.BackspaceKey)
+}
+
+// insertText pastes text at the caret
+func (c *WhatsAppClient) insertText(input selenium.WebElement, text string) error {
+	result, err := c.driver.ExecuteScript(insertTextScript, []interface{}{input, text})
+	if err != nil {
+		return fmt.Errorf("failed to insert text: %v", err)
+	}
+	if ok, isBool := result.(bool); isBool && !ok {
+		return fmt.Errorf("browser refused to insert text")
+	}
+	return nil
+}
+
+// keepTyping holds the typing indicator until a human would have finished
+// typing runes characters. Pasted text is typed much faster than that.
+func (c *WhatsAppClient) keepTyping(ctx context.Context, input selenium.WebElement, runes int, spent time.Duration) error {
+	total := time.Duration(runes) * c.typing.CharDelay
+	if c.typing.MaxTypingIndicator > 0 && total > c.typing.MaxTypingIndicator {
+		total = c.typing.MaxTypingIndicator
+	}
+
+	// WhatsApp hides the indicator after a few idle seconds, so nudge the composer
+	const keepAlive = 2 * time.Second
+	for remaining := total - spent; remaining > 0; remaining -= keepAlive {
+		pause := keepAlive
+		if remaining < pause {
+			pause = remaining
+		}
+		if err := sleep(ctx, pause); err != nil {
+			return err
+		}
+		if err := input.SendKeys(" " + selenium.BackspaceKey); err != nil {
+			return fmt.Errorf("failed to keep typing indicator: %v", err)
+		}
+	}
+	return nil
+}
+
+// keyDelay returns a randomized pause between keystrokes
+func (c *WhatsAppClient) keyDelay() time.Duration {
+	delay := c.typing.CharDelay
+	if jitter := int64(c.typing.CharDelayJitter); jitter > 0 {
+		delay += time.Duration(rand.Int63n(2*jitter) - jitter)
+	}
+	if delay < 0 {
+		return 0
+	}
+	return delay
+}
+
+// textChunk is a run of characters that can either be typed with SendKeys or
+// must be inserted as a whole
+type textChunk struct {
+	text    string
+	typable bool
+}
+
+// splitTypable splits text into runs ChromeDriver can type and runs it can't:
+// characters outside the Basic Multilingual Plane (most emoji) together with the
+// joiners and variation selectors that glue emoji sequences
+func splitTypable(text string) []textChunk {
+	var chunks []textChunk
+	for _, r := range text {
+		typable := r <= 0xFFFF && !isEmojiGlue(r)
+		if n := len(chunks); n > 0 && chunks[n-1].typable == typable {
+			chunks[n-1].text += string(r)
+			continue
+		}
+		chunks = append(chunks, textChunk{text: string(r), typable: typable})
+	}
+
+	// Keep a typable glyph that starts an emoji sequence (e.g. "❤" + U+FE0F) with it
+	for i := 1; i < len(chunks); i++ {
+		if chunks[i].typable || !chunks[i-1].typable {
+			continue
+		}
+		first, _ := utf8.DecodeRuneInString(chunks[i].text)
+		if !isEmojiGlue(first) {
+			continue
+		}
+		prev := []rune(chunks[i-1].text)
+		last := prev[len(prev)-1]
+		chunks[i-1].text = string(prev[:len(prev)-1])
+		chunks[i].text = string(last) + chunks[i].text
+	}
+
+	result := chunks[:0]
+	for _, chunk := range chunks {
+		if chunk.text != "" {
+			result = append(result, chunk)
+		}
+	}
+	return result
+}
+
+// isEmojiGlue reports characters that modify or join neighbouring emoji
+func isEmojiGlue(r rune) bool {
+	switch {
+	case r == 0x200D: // zero width joiner
+		return true
+	case r >= 0xFE00 && r <= 0xFE0F: // variation selectors
+		return true
+	case r == 0x20E3: // combining enclosing keycap
+		return true
+	}
+	return false
+}
@@ -1,6 +1,7 @@
 package selenium
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -8,6 +9,7 @@ import (
 	"net"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/tebeka/selenium"
@@ -15,20 +17,26 @@ import (
 )
 
 const (
-	whatsappURL    = "https://web.whatsapp.com"
-	qrCodeXPath    = "//*[@id='app']/div/div/div[2]/div[1]/div/div[2]/div/canvas"
-	defaultTimeout = 30 * time.Second
-	minPort        = 9515
-	maxPort        = 9999
+	whatsappURL = "https://web.whatsapp.com"
+	qrCodeXPath = "//*[@id='app']/div/div/div[2]/div[1]/div/div[2]/div/canvas"
+	// composerSelector matches the message input and not the sidebar search box
+	composerSelector = "footer div[contenteditable='true']"
+	defaultTimeout   = 30 * time.Second
+	minPort          = 9515
+	maxPort          = 9999
 )
 
 // WhatsAppClient handles WhatsApp Web automation
 type WhatsAppClient struct {
-	driver  selenium.WebDriver
-	service *selenium.Service
+	driver      selenium.WebDriver
+	service     *selenium.Service
+	port        int
+	userDataDir string
+	typing      TypingOptions
 }
 
-// cleanOldSessions removes sessions older than 24 hours
+// cleanOldSessions removes temporary session_* profiles older than 24 hours
+// created by earlier versions that started a fresh profile on every run
 func cleanOldSessions(baseDir string) error {
 	files, err := ioutil.ReadDir(baseDir)
 	if err != nil {
@@ -39,10 +47,10 @@ func cleanOldSessions(baseDir string) error {
 	}
 
 	for _, file := range files {
-		if !file.IsDir() {
+		if !file.IsDir() || !strings.HasPrefix(file.Name(), "session_") {
 			continue
 		}
-		if time.Since(file.ModTime()) > 24*time.Second {
+		if time.Since(file.ModTime()) > 24*time.Hour {
 			path := filepath.Join(baseDir, file.Name())
 			if err := os.RemoveAll(path); err != nil {
 				log.Printf("Warning: failed to remove old session directory %s: %v", path, err)
@@ -80,8 +88,10 @@ func waitForPort(port int, timeout time.Duration) error {
 	return fmt.Errorf("timeout waiting for port %d", port)
 }
 
-// NewWhatsAppClient creates a new WhatsApp automation client
-func NewWhatsAppClient() (*WhatsAppClient, error) {
+// NewWhatsAppClient creates a new WhatsApp automation client whose Chrome
+// profile lives in userDataDir. Reusing the directory keeps the WhatsApp Web
+// login across browser restarts. Messages are entered according to typing.
+func NewWhatsAppClient(userDataDir string, typing TypingOptions) (*WhatsAppClient, error) {
 	log.Println("Initializing WhatsApp client...")
 
 	// Find available port for ChromeDriver
@@ -91,28 +101,12 @@ func NewWhatsAppClient() (*WhatsAppClient, error) {
 	}
 	log.Printf("Using port %d for ChromeDriver\n", port)
 
-	// Base directory for Chrome user data
-	baseDir := filepath.Join(".", "chrome_data")
-
-	// Clean old sessions
-	if err := cleanOldSessions(baseDir); err != nil {
-		log.Printf("Warning: failed to clean old sessions: %v", err)
-	}
-
-	// Create unique user data directory with timestamp and process ID
-	timestamp := time.Now().Format("20060102_150405")
-	sessionDir := fmt.Sprintf("session_%s_%d", timestamp, os.Getpid())
-	userDataDir, err := filepath.Abs(filepath.Join(baseDir, sessionDir))
+	userDataDir, err = filepath.Abs(userDataDir)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get absolute path: %v", err)
 	}
 
-	// Remove directory if it exists
-	if err := os.RemoveAll(userDataDir); err != nil {
-		return nil, fmt.Errorf("failed to remove existing user data directory: %v", err)
-	}
-
-	// Create fresh directory
+	// Create user data directory if this is a new profile
 	if err := os.MkdirAll(userDataDir, 0755); err != nil {
 		return nil, fmt.Errorf("failed to create user data directory: %v", err)
 	}
@@ -185,8 +179,9 @@ func NewWhatsAppClient() (*WhatsAppClient, error) {
 	}
 	log.Println("WebDriver instance created successfully")
 
-	// Set implicit wait timeout
-	if err := driver.SetImplicitWaitTimeout(defaultTimeout); err != nil {
+	// Lookups must return at once: an implicit wait blocks inside chromedriver,
+	// where context deadlines cannot reach it. waitForElement polls instead.
+	if err := driver.SetImplicitWaitTimeout(0); err != nil {
 		driver.Quit()
 		service.Stop()
 		return nil, fmt.Errorf("failed to set implicit wait timeout: %v", err)
@@ -199,21 +194,29 @@ func NewWhatsAppClient() (*WhatsAppClient, error) {
 		return nil, fmt.Errorf("failed to set page load timeout: %v", err)
 	}
 
+	registry.add(port, userDataDir)
+
 	return &WhatsAppClient{
-		driver:  driver,
-		service: service,
+		driver:      driver,
+		service:     service,
+		port:        port,
+		userDataDir: userDataDir,
+		typing:      typing,
 	}, nil
 }
 
-// waitForElement waits for an element to be present and visible
-func (c *WhatsAppClient) waitForElement(by, value string, timeout time.Duration) (selenium.WebElement, error) {
+// waitForElement waits for an element to be present and visible. The wait
+// stops early when ctx is cancelled or its deadline passes.
+func (c *WhatsAppClient) waitForElement(ctx context.Context, by, value string, timeout time.Duration) (selenium.WebElement, error) {
 	log.Printf("Waiting for element: %s=%s (timeout: %v)\n", by, value, timeout)
-	deadline := time.Now().Add(timeout)
-	var element selenium.WebElement
-	var err error
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
 
-	for time.Now().Before(deadline) {
-		element, err = c.driver.FindElement(by, value)
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		element, err := c.driver.FindElement(by, value)
 		if err == nil {
 			visible, err := element.IsDisplayed()
 			if err == nil && visible {
@@ -221,14 +224,47 @@ func (c *WhatsAppClient) waitForElement(by, value string, timeout time.Duration)
 				return element, nil
 			}
 		}
-		time.Sleep(500 * time.Millisecond)
+
+		select {
+		case <-ctx.Done():
+			log.Printf("Element not found or not visible: %s=%s\n", by, value)
+			return nil, fmt.Errorf("element not found or not visible: %w", ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// get navigates to url, capping the page load timeout by the context deadline
+func (c *WhatsAppClient) get(ctx context.Context, url string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	timeout := defaultTimeout
+	if deadline, ok := ctx.Deadline(); ok {
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return context.DeadlineExceeded
+		}
+		if remaining < timeout {
+			timeout = remaining
+		}
+	}
+	if err := c.driver.SetPageLoadTimeout(timeout); err != nil {
+		log.Printf("Warning: failed to set page load timeout: %v\n", err)
+	}
+
+	if err := c.driver.Get(url); err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		return err
 	}
-	log.Printf("Element not found or not visible: %s=%s\n", by, value)
-	return nil, fmt.Errorf("element not found or not visible after %v", timeout)
+	return nil
 }
 
 // GetQRCode opens WhatsApp Web and returns the QR code element
-func (c *WhatsAppClient) GetQRCode(sessionID string) (string, error) {
+func (c *WhatsAppClient) GetQRCode(ctx context.Context, sessionID string) (string, error) {
 	log.Printf("Getting QR code for session %s...\n", sessionID)
 
 	// Delete all cookies before starting
@@ -237,8 +273,8 @@ func (c *WhatsAppClient) GetQRCode(sessionID string) (string, error) {
 	}
 
 	// Navigate to WhatsApp Web
-	if err := c.driver.Get(whatsappURL); err != nil {
-		return "", fmt.Errorf("failed to open WhatsApp Web: %v", err)
+	if err := c.get(ctx, whatsappURL); err != nil {
+		return "", fmt.Errorf("failed to open WhatsApp Web: %w", err)
 	}
 
 	// Check if already authorized
@@ -263,13 +299,16 @@ func (c *WhatsAppClient) GetQRCode(sessionID string) (string, error) {
 
 	// Wait for QR code to appear with timeout
 	log.Println("Waiting for QR code element...")
-	qrElement, err := c.waitForElement(selenium.ByXPATH, qrCodeXPath, defaultTimeout)
+	qrElement, err := c.waitForElement(ctx, selenium.ByXPATH, qrCodeXPath, defaultTimeout)
 	if err != nil {
+		if ctx.Err() != nil {
+			return "", fmt.Errorf("failed to find QR code element: %w", err)
+		}
 		// Try alternative QR code selector
 		log.Println("Trying alternative QR code selector...")
-		qrElement, err = c.waitForElement(selenium.ByCSSSelector, "canvas", defaultTimeout)
+		qrElement, err = c.waitForElement(ctx, selenium.ByCSSSelector, "canvas", defaultTimeout)
 		if err != nil {
-			return "", fmt.Errorf("failed to find QR code element: %v", err)
+			return "", fmt.Errorf("failed to find QR code element: %w", err)
 		}
 	}
 
@@ -303,7 +342,11 @@ func (c *WhatsAppClient) GetQRCode(sessionID string) (string, error) {
 }
 
 // GetSessionData retrieves cookies and local storage data
-func (c *WhatsAppClient) GetSessionData() ([]byte, error) {
+func (c *WhatsAppClient) GetSessionData(ctx context.Context) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	cookies, err := c.driver.GetCookies()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get cookies: %v", err)
@@ -324,42 +367,44 @@ func (c *WhatsAppClient) GetSessionData() ([]byte, error) {
 }
 
 // RestoreSession restores a previous session using cookies and localStorage
-func (c *WhatsAppClient) RestoreSession(sessionData []byte) error {
+func (c *WhatsAppClient) RestoreSession(ctx context.Context, sessionData []byte) error {
 	// First navigate to WhatsApp Web
-	if err := c.driver.Get(whatsappURL); err != nil {
-		return fmt.Errorf("failed to open WhatsApp Web: %v", err)
+	if err := c.get(ctx, whatsappURL); err != nil {
+		return fmt.Errorf("failed to open WhatsApp Web: %w", err)
 	}
 
-	var data map[string]interface{}
+	var data struct {
+		Cookies      []selenium.Cookie `json:"cookies"`
+		LocalStorage [][]string        `json:"localStorage"`
+	}
 	if err := json.Unmarshal(sessionData, &data); err != nil {
 		return fmt.Errorf("failed to unmarshal session data: %v", err)
 	}
 
 	// Restore cookies
-	if cookies, ok := data["cookies"].([]selenium.Cookie); ok {
-		for _, cookie := range cookies {
-			if err := c.driver.AddCookie(&cookie); err != nil {
-				return fmt.Errorf("failed to restore cookie: %v", err)
-			}
+	for i := range data.Cookies {
+		if err := c.driver.AddCookie(&data.Cookies[i]); err != nil {
+			return fmt.Errorf("failed to restore cookie: %v", err)
 		}
 	}
 
 	// Restore localStorage
-	if localStorage, ok := data["localStorage"].([]interface{}); ok {
-		script := "localStorage.clear();"
-		for _, item := range localStorage {
-			if pair, ok := item.([]interface{}); ok && len(pair) == 2 {
-				key := pair[0].(string)
-				value := pair[1].(string)
-				script += fmt.Sprintf("localStorage.setItem('%s', '%s');", key, value)
+	if len(data.LocalStorage) > 0 {
+		script := `
+			localStorage.clear();
+			for (const [key, value] of arguments[0]) {
+				localStorage.setItem(key, value);
 			}
-		}
-		if _, err := c.driver.ExecuteScript(script, nil); err != nil {
+		`
+		if _, err := c.driver.ExecuteScript(script, []interface{}{data.LocalStorage}); err != nil {
 			return fmt.Errorf("failed to restore localStorage: %v", err)
 		}
 	}
 
 	// Refresh the page after restoring session data
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 	if err := c.driver.Refresh(); err != nil {
 		return fmt.Errorf("failed to refresh page: %v", err)
 	}
@@ -367,37 +412,18 @@ func (c *WhatsAppClient) RestoreSession(sessionData []byte) error {
 	return nil
 }
 
-// SendMessage sends a message to a specific phone number
-func (c *WhatsAppClient) SendMessage(phoneNumber, message string) error {
-	// Open chat with phone number
-	url := fmt.Sprintf("%s/send?phone=%s", whatsappURL, phoneNumber)
-	if err := c.driver.Get(url); err != nil {
-		return fmt.Errorf("failed to open chat: %v", err)
-	}
-
-	// Wait for message input to be ready
-	input, err := c.waitForElement(selenium.ByCSSSelector, "div[contenteditable='true']", defaultTimeout)
-	if err != nil {
-		return fmt.Errorf("failed to find message input: %v", err)
-	}
-
-	if err := input.SendKeys(message); err != nil {
-		return fmt.Errorf("failed to input message: %v", err)
-	}
+// Close closes the WebDriver session and ChromeDriver service. The service is
+// stopped even if the browser refuses to quit so no chromedriver is left behind.
+func (c *WhatsAppClient) Close() error {
+	quitErr := c.driver.Quit()
+	stopErr := c.service.Stop()
+	registry.remove(c.port, c.userDataDir)
 
-	// Send message
-	if err := input.SendKeys(selenium.EnterKey); err != nil {
-		return fmt.Errorf("failed to send message: %v", err)
+	if quitErr != nil {
+		return fmt.Errorf("failed to quit driver: %v", quitErr)
 	}
-
-	return nil
-}
-
-// Close closes the WebDriver session and ChromeDriver service
-func (c *WhatsAppClient) Close() error {
-	if err := c.driver.Quit(); err != nil {
-		return fmt.Errorf("failed to quit driver: %v", err)
+	if stopErr != nil {
+		return fmt.Errorf("failed to stop ChromeDriver: %v", stopErr)
 	}
-	c.service.Stop()
 	return nil
 }
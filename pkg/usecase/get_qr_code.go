@@ -1,6 +1,7 @@
 package usecase
 
 import (
+	"context"
 	"fmt"
 	//"whatsapp-parser/pkg/domain"
 	"whatsapp-parser/pkg/repository"
@@ -31,7 +32,7 @@ type Result struct {
 }
 
 // Execute handles the QR code retrieval process
-func (uc *GetQRCodeUseCase) Execute(sessionID string, profileID int) (*Result, error) {
+func (uc *GetQRCodeUseCase) Execute(ctx context.Context, sessionID string, profileID int) (*Result, error) {
 	// Get profile
 	profile, err := uc.profileRepository.Get(profileID)
 	if err != nil {
@@ -51,7 +52,7 @@ func (uc *GetQRCodeUseCase) Execute(sessionID string, profileID int) (*Result, e
 	}
 
 	// Get QR code
-	qrCode, err := uc.whatsappClient.GetQRCode(sessionID)
+	qrCode, err := uc.whatsappClient.GetQRCode(ctx, sessionID)
 	if err != nil {
 		if err.Error() == "Already authorized" {
 			return &Result{Error: "Already authorized"}, nil